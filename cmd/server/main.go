@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
+	"wemadeit/internal/blob"
 	"wemadeit/internal/config"
 	"wemadeit/internal/db"
 	"wemadeit/internal/server"
@@ -17,6 +20,7 @@ func main() {
 	dataDir := flag.String("data", defaultDataDir(), "data directory")
 	configPath := flag.String("config", defaultConfigPath(), "config file")
 	seed := flag.Bool("seed", true, "seed sample data on first run")
+	schedule := flag.Duration("schedule", time.Hour, "interval between background jobs (0 disables them)")
 	flag.Parse()
 
 	cfg, err := config.Load(*configPath)
@@ -40,6 +44,8 @@ func main() {
 	}
 
 	srv := server.New(store, cfg, *configPath)
+	srv.SetBlobStore(blob.Dir{Root: filepath.Join(*dataDir, "blobs")})
+	go srv.RunScheduler(context.Background(), *schedule)
 	fmt.Println("WeMadeIt API listening on", *addr)
 	if err := http.ListenAndServe(*addr, srv.Handler()); err != nil {
 		fmt.Println("Server error:", err)
@@ -0,0 +1,51 @@
+// Package addressbook converts contacts and organizations to and from vCard
+// 3.0/4.0 and CSV address-book files.
+package addressbook
+
+import "strings"
+
+// Entry is one address-book card: a person, or an organization when Kind is
+// KindOrganization. Company names the person's organization.
+type Entry struct {
+	Kind        string `json:"kind"`
+	UID         string `json:"uid"`
+	FirstName   string `json:"firstName"`
+	LastName    string `json:"lastName"`
+	Company     string `json:"company"`
+	JobTitle    string `json:"jobTitle"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Mobile      string `json:"mobile"`
+	Website     string `json:"website"`
+	LinkedInURL string `json:"linkedinUrl"`
+	Address     string `json:"address"`
+	City        string `json:"city"`
+	Country     string `json:"country"`
+	TaxID       string `json:"taxId"`
+	Industry    string `json:"industry"`
+	Notes       string `json:"notes"`
+}
+
+const (
+	KindIndividual   = "individual"
+	KindOrganization = "org"
+)
+
+// Name is the display name: the person's full name, or the company for
+// organization cards.
+func (e Entry) Name() string {
+	if e.Kind == KindOrganization {
+		return e.Company
+	}
+	return strings.TrimSpace(e.FirstName + " " + e.LastName)
+}
+
+// splitName splits a formatted name on its last space, which is right for
+// the usual "Given Family" order.
+func splitName(full string) (first, last string) {
+	full = strings.TrimSpace(full)
+	if i := strings.LastIndexByte(full, ' '); i > 0 {
+		return strings.TrimSpace(full[:i]), strings.TrimSpace(full[i+1:])
+	}
+	return full, ""
+}
@@ -0,0 +1,250 @@
+package addressbook
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Fields lists the Entry fields a CSV column can be mapped to.
+var Fields = []string{
+	"firstName", "lastName", "fullName", "company", "jobTitle", "email", "phone", "mobile",
+	"website", "linkedinUrl", "address", "city", "country", "taxId", "industry", "notes",
+}
+
+// CSVMapping maps Entry fields to columns, referenced by header name
+// (case-insensitive) or 1-based position. An empty Delimiter is sniffed
+// from the first line.
+type CSVMapping struct {
+	Delimiter string            `json:"delimiter"`
+	NoHeader  bool              `json:"noHeader"`
+	Columns   map[string]string `json:"columns"`
+}
+
+// Preview is what a file would import as, for checking a mapping before
+// committing to it.
+type Preview struct {
+	Header    []string   `json:"header"`
+	Delimiter string     `json:"delimiter"`
+	Mapping   CSVMapping `json:"mapping"`
+	Rows      []Entry    `json:"rows"`
+	Total     int        `json:"total"`
+}
+
+// headerAliases are the lower-cased column names address books export
+// (Google, Outlook, Apple and Italian spreadsheets).
+var headerAliases = map[string][]string{
+	"firstName":   {"first name", "firstname", "given name", "nome"},
+	"lastName":    {"last name", "lastname", "family name", "surname", "cognome"},
+	"fullName":    {"name", "full name", "display name", "nome completo"},
+	"company":     {"company", "organization", "organisation", "organization 1 - name", "azienda", "società", "ragione sociale"},
+	"jobTitle":    {"job title", "title", "organization 1 - title", "ruolo", "qualifica"},
+	"email":       {"email", "e-mail", "email address", "e-mail address", "e-mail 1 - value", "mail"},
+	"phone":       {"phone", "business phone", "work phone", "phone 1 - value", "telefono"},
+	"mobile":      {"mobile", "mobile phone", "cell", "cellulare", "phone 2 - value"},
+	"website":     {"website", "web page", "web site", "url", "website 1 - value", "sito", "sito web"},
+	"linkedinUrl": {"linkedin", "linkedin url"},
+	"address":     {"address", "business street", "street", "address 1 - street", "indirizzo"},
+	"city":        {"city", "business city", "address 1 - city", "città", "citta"},
+	"country":     {"country", "business country/region", "address 1 - country", "paese", "nazione"},
+	"taxId":       {"tax id", "vat", "vat number", "partita iva", "p.iva", "piva"},
+	"industry":    {"industry", "settore"},
+	"notes":       {"notes", "note"},
+}
+
+// SuggestMapping guesses a column for each field from the header names.
+func SuggestMapping(header []string) map[string]string {
+	out := make(map[string]string)
+	for _, field := range Fields {
+		for _, h := range header {
+			name := strings.ToLower(strings.TrimSpace(h))
+			if strings.EqualFold(name, field) || slices.Contains(headerAliases[field], name) {
+				out[field] = h
+				break
+			}
+		}
+	}
+	return out
+}
+
+// PreviewCSV reads the header and the first n rows. Without mapped columns
+// the suggested mapping is used and returned.
+func PreviewCSV(data []byte, m CSVMapping, n int) (Preview, error) {
+	if m.Delimiter == "" {
+		m.Delimiter = sniffDelimiter(data)
+	}
+	header, rows, err := readCSV(data, m)
+	if err != nil {
+		return Preview{}, err
+	}
+	if len(m.Columns) == 0 {
+		m.Columns = SuggestMapping(header)
+	}
+	entries, err := mapRows(header, rows[:min(n, len(rows))], m, KindIndividual)
+	if err != nil {
+		return Preview{}, err
+	}
+	return Preview{Header: header, Delimiter: m.Delimiter, Mapping: m, Rows: entries, Total: len(rows)}, nil
+}
+
+// ReadCSV maps every row to an Entry of the given kind. Rows without a name,
+// company or e-mail are skipped.
+func ReadCSV(data []byte, m CSVMapping, kind string) ([]Entry, error) {
+	if m.Delimiter == "" {
+		m.Delimiter = sniffDelimiter(data)
+	}
+	header, rows, err := readCSV(data, m)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Columns) == 0 {
+		m.Columns = SuggestMapping(header)
+	}
+	return mapRows(header, rows, m, kind)
+}
+
+func readCSV(data []byte, m CSVMapping) ([]string, [][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+	d := m.Delimiter
+	if d == `\t` || d == "tab" {
+		d = "\t"
+	}
+	cr.Comma = []rune(d)[0]
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("empty file")
+	}
+	if m.NoHeader {
+		return nil, rows, nil
+	}
+	return rows[0], rows[1:], nil
+}
+
+func mapRows(header []string, rows [][]string, m CSVMapping, kind string) ([]Entry, error) {
+	cols := make(map[string]int, len(m.Columns))
+	for field, ref := range m.Columns {
+		if !slices.Contains(Fields, field) {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+		idx, err := columnIndex(header, ref)
+		if err != nil {
+			return nil, err
+		}
+		if idx >= 0 {
+			cols[field] = idx
+		}
+	}
+	out := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		get := func(field string) string {
+			if i, ok := cols[field]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		e := Entry{
+			Kind:        kind,
+			FirstName:   get("firstName"),
+			LastName:    get("lastName"),
+			Company:     get("company"),
+			JobTitle:    get("jobTitle"),
+			Email:       get("email"),
+			Phone:       get("phone"),
+			Mobile:      get("mobile"),
+			Website:     get("website"),
+			LinkedInURL: get("linkedinUrl"),
+			Address:     get("address"),
+			City:        get("city"),
+			Country:     get("country"),
+			TaxID:       get("taxId"),
+			Industry:    get("industry"),
+			Notes:       get("notes"),
+		}
+		if full := get("fullName"); full != "" {
+			if kind == KindOrganization && e.Company == "" {
+				e.Company = full
+			} else if e.FirstName == "" && e.LastName == "" {
+				e.FirstName, e.LastName = splitName(full)
+			}
+		}
+		if e.Name() == "" && e.Email == "" && e.Company == "" {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func columnIndex(header []string, ref string) (int, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return -1, nil
+	}
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 1 {
+			return -1, fmt.Errorf("column %q: positions start at 1", ref)
+		}
+		return n - 1, nil
+	}
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), ref) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("column %q not found", ref)
+}
+
+// sniffDelimiter picks the most frequent of , ; and tab on the first line.
+func sniffDelimiter(data []byte) string {
+	line, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+	best, bestCount := ",", 0
+	for _, d := range []string{",", ";", "\t"} {
+		if c := bytes.Count(line, []byte(d)); c > bestCount {
+			best, bestCount = d, c
+		}
+	}
+	return best
+}
+
+var (
+	contactCSVHeader = []string{"First Name", "Last Name", "Company", "Job Title", "Email", "Phone", "Mobile", "LinkedIn", "Notes"}
+	orgCSVHeader     = []string{"Name", "Industry", "Website", "Email", "Phone", "Tax ID", "Address", "City", "Country", "Notes"}
+)
+
+// WriteCSV writes entries with headers that SuggestMapping reads back.
+func WriteCSV(w io.Writer, entries []Entry, kind string) error {
+	cw := csv.NewWriter(w)
+	header := contactCSVHeader
+	if kind == KindOrganization {
+		header = orgCSVHeader
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		var row []string
+		if kind == KindOrganization {
+			row = []string{e.Company, e.Industry, e.Website, e.Email, e.Phone, e.TaxID, e.Address, e.City, e.Country, e.Notes}
+		} else {
+			row = []string{e.FirstName, e.LastName, e.Company, e.JobTitle, e.Email, e.Phone, e.Mobile, e.LinkedInURL, e.Notes}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
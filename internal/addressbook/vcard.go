@@ -0,0 +1,304 @@
+package addressbook
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseVCard reads every card in a vCard 2.1/3.0/4.0 stream. The first
+// e-mail wins; phones typed "cell" fill Mobile, others Phone.
+func ParseVCard(r io.Reader) ([]Entry, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	out := make([]Entry, 0)
+	var cur *Entry
+	var formatted string
+	seen := false
+	for _, line := range lines {
+		name, params, value, ok := splitContentLine(line)
+		if !ok {
+			continue
+		}
+		// Apple and Google prefix grouped properties: "item1.EMAIL".
+		if i := strings.LastIndexByte(name, '.'); i >= 0 {
+			name = name[i+1:]
+		}
+		switch name {
+		case "BEGIN":
+			if strings.EqualFold(value, "VCARD") {
+				cur = &Entry{Kind: KindIndividual}
+				formatted = ""
+				seen = true
+			}
+			continue
+		case "END":
+			if cur != nil && strings.EqualFold(value, "VCARD") {
+				if cur.FirstName == "" && cur.LastName == "" && cur.Kind == KindIndividual {
+					cur.FirstName, cur.LastName = splitName(formatted)
+				}
+				if cur.Kind == KindOrganization && cur.Company == "" {
+					cur.Company = formatted
+				}
+				if cur.Name() != "" || cur.Email != "" || cur.Company != "" {
+					out = append(out, *cur)
+				}
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		types := strings.ToLower(params["TYPE"])
+		switch name {
+		case "KIND":
+			if strings.EqualFold(value, "org") || strings.EqualFold(value, "organization") {
+				cur.Kind = KindOrganization
+			}
+		case "X-ABSHOWAS":
+			if strings.EqualFold(value, "COMPANY") {
+				cur.Kind = KindOrganization
+			}
+		case "UID":
+			cur.UID = unescapeText(value)
+		case "FN":
+			formatted = unescapeText(value)
+		case "N":
+			parts := splitStructured(value)
+			if len(parts) > 0 {
+				cur.LastName = parts[0]
+			}
+			if len(parts) > 1 {
+				cur.FirstName = parts[1]
+			}
+			// Middle names stay with the given name.
+			if len(parts) > 2 && parts[2] != "" {
+				cur.FirstName = strings.TrimSpace(cur.FirstName + " " + parts[2])
+			}
+		case "ORG":
+			if parts := splitStructured(value); len(parts) > 0 {
+				cur.Company = parts[0]
+			}
+		case "TITLE":
+			cur.JobTitle = unescapeText(value)
+		case "EMAIL":
+			if cur.Email == "" {
+				cur.Email = strings.TrimSpace(strings.TrimPrefix(value, "mailto:"))
+			}
+		case "TEL":
+			v := strings.TrimSpace(strings.TrimPrefix(value, "tel:"))
+			if strings.Contains(types, "cell") {
+				if cur.Mobile == "" {
+					cur.Mobile = v
+				}
+			} else if cur.Phone == "" {
+				cur.Phone = v
+			}
+		case "URL":
+			v := unescapeText(value)
+			if strings.Contains(strings.ToLower(v), "linkedin.com") {
+				cur.LinkedInURL = v
+			} else if cur.Website == "" {
+				cur.Website = v
+			}
+		case "X-SOCIALPROFILE":
+			if strings.Contains(strings.ToLower(value), "linkedin") || strings.Contains(types, "linkedin") {
+				cur.LinkedInURL = unescapeText(value)
+			}
+		case "ADR":
+			// PO box; extended; street; locality; region; postal code; country.
+			parts := splitStructured(value)
+			get := func(i int) string {
+				if i < len(parts) {
+					return parts[i]
+				}
+				return ""
+			}
+			if cur.Address == "" {
+				cur.Address = strings.Join(nonEmpty([]string{get(2), get(5)}), " ")
+				cur.City = get(3)
+				cur.Country = get(6)
+			}
+		case "NOTE":
+			cur.Notes = unescapeText(value)
+		}
+	}
+	if !seen {
+		return nil, errors.New("not a vCard file")
+	}
+	return out, nil
+}
+
+// WriteVCard writes the entries as vCard 3.0, which every address book
+// still imports.
+func WriteVCard(w io.Writer, entries []Entry) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		// Fold at 75 octets like iCalendar.
+		for len(s) > 75 {
+			cut := 75
+			for cut > 0 && s[cut]&0xC0 == 0x80 {
+				cut--
+			}
+			bw.WriteString(s[:cut] + "\r\n ")
+			s = s[cut:]
+		}
+		bw.WriteString(s + "\r\n")
+	}
+	for _, e := range entries {
+		line("BEGIN:VCARD")
+		line("VERSION:3.0")
+		if e.UID != "" {
+			line("UID:" + escapeText(e.UID))
+		}
+		if e.Kind == KindOrganization {
+			line("FN:" + escapeText(e.Company))
+			line("N:" + escapeText(e.Company) + ";;;;")
+			line("ORG:" + escapeText(e.Company))
+			line("X-ABSHOWAS:COMPANY")
+		} else {
+			line("FN:" + escapeText(e.Name()))
+			line(fmt.Sprintf("N:%s;%s;;;", escapeText(e.LastName), escapeText(e.FirstName)))
+			if e.Company != "" {
+				line("ORG:" + escapeText(e.Company))
+			}
+		}
+		if e.JobTitle != "" {
+			line("TITLE:" + escapeText(e.JobTitle))
+		}
+		if e.Email != "" {
+			line("EMAIL;TYPE=INTERNET:" + e.Email)
+		}
+		if e.Phone != "" {
+			line("TEL;TYPE=WORK,VOICE:" + e.Phone)
+		}
+		if e.Mobile != "" {
+			line("TEL;TYPE=CELL:" + e.Mobile)
+		}
+		if e.Website != "" {
+			line("URL:" + e.Website)
+		}
+		if e.LinkedInURL != "" {
+			line("X-SOCIALPROFILE;TYPE=linkedin:" + e.LinkedInURL)
+		}
+		if e.Address != "" || e.City != "" || e.Country != "" {
+			line(fmt.Sprintf("ADR;TYPE=WORK:;;%s;%s;;;%s", escapeText(e.Address), escapeText(e.City), escapeText(e.Country)))
+		}
+		if e.Notes != "" {
+			line("NOTE:" + escapeText(e.Notes))
+		}
+		line("END:VCARD")
+	}
+	return bw.Flush()
+}
+
+// splitContentLine splits NAME;PARAM=V:VALUE. Bare vCard 2.1 parameters
+// ("TEL;CELL:") are treated as TYPE values.
+func splitContentLine(line string) (name string, params map[string]string, value string, ok bool) {
+	inQuote := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuote = !inQuote
+		} else if c == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", false
+	}
+	parts := strings.Split(line[:colon], ";")
+	name = strings.ToUpper(strings.TrimSpace(parts[0]))
+	params = make(map[string]string)
+	for _, p := range parts[1:] {
+		k, v, found := strings.Cut(p, "=")
+		if !found {
+			k, v = "TYPE", p
+		}
+		k = strings.ToUpper(k)
+		v = strings.Trim(v, `"`)
+		if params[k] != "" {
+			v = params[k] + "," + v
+		}
+		params[k] = v
+	}
+	return name, params, line[colon+1:], true
+}
+
+// splitStructured splits a ';'-separated value, honouring escapes.
+func splitStructured(v string) []string {
+	var out []string
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		switch {
+		case v[i] == '\\' && i+1 < len(v):
+			i++
+			if v[i] == 'n' || v[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(v[i])
+			}
+		case v[i] == ';':
+			out = append(out, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(v[i])
+		}
+	}
+	return append(out, strings.TrimSpace(b.String()))
+}
+
+func unescapeText(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+1 < len(v) {
+			i++
+			if v[i] == 'n' || v[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(v[i])
+			}
+			continue
+		}
+		b.WriteByte(v[i])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeText(v string) string {
+	return textEscaper.Replace(v)
+}
+
+func nonEmpty(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, v := range in {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
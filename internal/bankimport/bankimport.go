@@ -0,0 +1,135 @@
+package bankimport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transaction is a single bank statement line, normalized across formats.
+// Amount is signed: positive for credits (money in), negative for debits.
+type Transaction struct {
+	ExternalID   string    `json:"externalId"`
+	BookedAt     time.Time `json:"bookedAt"`
+	Amount       float64   `json:"amount"`
+	Currency     string    `json:"currency"`
+	Counterparty string    `json:"counterparty"`
+	Reference    string    `json:"reference"`
+	Description  string    `json:"description"`
+}
+
+// fingerprint returns a stable identifier used to de-duplicate re-imports
+// of the same statement. Bank-provided references win when present;
+// otherwise the fields are hashed, with occurrence telling apart identical
+// lines within one statement, 0 being the first.
+func (t Transaction) fingerprint(occurrence int) string {
+	if id := strings.TrimSpace(t.ExternalID); id != "" {
+		return id
+	}
+	raw := fmt.Sprintf("%s|%.2f|%s|%s|%s|%s",
+		t.BookedAt.Format("2006-01-02"),
+		t.Amount,
+		strings.ToUpper(t.Currency),
+		strings.TrimSpace(t.Counterparty),
+		strings.TrimSpace(t.Reference),
+		strings.TrimSpace(t.Description),
+	)
+	if occurrence > 0 {
+		raw += fmt.Sprintf("|#%d", occurrence)
+	}
+	sum := sha256.Sum256([]byte(raw))
+	return "sha256:" + hex.EncodeToString(sum[:12])
+}
+
+// Fingerprints returns the fingerprint of every transaction of one
+// statement. Two real transfers can share every field, so repeats within
+// the statement are numbered: a re-import yields the same fingerprints and
+// still de-duplicates, while the repeats themselves are kept.
+func Fingerprints(txs []Transaction) []string {
+	seen := make(map[string]int, len(txs))
+	out := make([]string, 0, len(txs))
+	for _, t := range txs {
+		base := t.fingerprint(0)
+		n := seen[base]
+		seen[base] = n + 1
+		if n == 0 || strings.TrimSpace(t.ExternalID) != "" {
+			out = append(out, base)
+			continue
+		}
+		out = append(out, t.fingerprint(n))
+	}
+	return out
+}
+
+var fallbackDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+	"02/01/2006",
+	"02.01.2006",
+	"02-01-2006",
+	"2006/01/02",
+	"02/01/06",
+	"02.01.06",
+}
+
+func parseDate(v string, layout string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+	if layout != "" {
+		return time.ParseInLocation(layout, v, time.Local)
+	}
+	for _, l := range fallbackDateLayouts {
+		if t, err := time.ParseInLocation(l, v, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", v)
+}
+
+// parseAmount accepts the usual bank export spellings: "1.234,56", "1,234.56",
+// "-12.00", "12,00 EUR", "(12.00)".
+func parseAmount(v string, decimalComma bool) (float64, error) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	negative := false
+	if strings.HasPrefix(v, "(") && strings.HasSuffix(v, ")") {
+		negative = true
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "("), ")")
+	}
+	var b strings.Builder
+	for _, r := range v {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',':
+			b.WriteRune(r)
+		case r == '-':
+			negative = !negative
+		}
+	}
+	clean := b.String()
+	if decimalComma {
+		clean = strings.ReplaceAll(clean, ".", "")
+		clean = strings.ReplaceAll(clean, ",", ".")
+	} else {
+		clean = strings.ReplaceAll(clean, ",", "")
+	}
+	if clean == "" {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+	f, err := strconv.ParseFloat(clean, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+	if negative {
+		f = -f
+	}
+	return math.Round(f*100) / 100, nil
+}
@@ -0,0 +1,105 @@
+package bankimport
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in           string
+		decimalComma bool
+		want         float64
+		wantErr      bool
+	}{
+		{in: "", want: 0},
+		{in: "12.00", want: 12},
+		{in: "-12.00", want: -12},
+		{in: "1,234.56", want: 1234.56},
+		{in: "1.234,56", decimalComma: true, want: 1234.56},
+		{in: "12,5", decimalComma: true, want: 12.5},
+		{in: "-0,99", decimalComma: true, want: -0.99},
+		{in: "12,00 EUR", decimalComma: true, want: 12},
+		{in: "EUR 1,000", want: 1000},
+		{in: "(12.00)", want: -12},
+		{in: "(-12.00)", want: 12},
+		{in: "10.005", want: 10.01},
+		{in: "EUR", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.in, tt.decimalComma)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAmount(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseAmount(%q, %v) = %v, %v; want %v", tt.in, tt.decimalComma, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2025, 3, 7, 0, 0, 0, 0, time.Local)
+	for _, in := range []string{"2025-03-07", "07/03/2025", "07.03.2025", "07-03-2025", "2025/03/07", "07.03.25"} {
+		got, err := parseDate(in, "")
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if got, err := parseDate("03/07/2025", "01/02/2006"); err != nil || !got.Equal(want) {
+		t.Errorf("parseDate with layout = %v, %v; want %v", got, err, want)
+	}
+	for _, in := range []string{"", "yesterday"} {
+		if _, err := parseDate(in, ""); err == nil {
+			t.Errorf("parseDate(%q): want an error", in)
+		}
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	day := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	coffee := Transaction{BookedAt: day, Amount: -3.5, Currency: "EUR", Counterparty: "Bar", Description: "Coffee"}
+	rent := Transaction{BookedAt: day, Amount: -900, Currency: "EUR", Counterparty: "Landlord"}
+	fee := Transaction{ExternalID: "BANK-1", BookedAt: day, Amount: -1, Currency: "EUR"}
+
+	statement := []Transaction{coffee, rent, coffee, fee, fee, coffee}
+	got := Fingerprints(statement)
+	if len(got) != len(statement) {
+		t.Fatalf("got %d fingerprints for %d lines", len(got), len(statement))
+	}
+
+	// Repeated lines are told apart, the first keeping the plain hash.
+	if got[0] != coffee.fingerprint(0) || got[2] != coffee.fingerprint(1) || got[5] != coffee.fingerprint(2) {
+		t.Errorf("repeated lines: %v", got)
+	}
+	if got[0] == got[2] || got[2] == got[5] || got[0] == got[5] {
+		t.Errorf("repeated lines share a fingerprint: %v", got)
+	}
+	// Bank references are used as they are, even when repeated.
+	if got[3] != "BANK-1" || got[4] != "BANK-1" {
+		t.Errorf("bank references: %q, %q", got[3], got[4])
+	}
+
+	// A re-import of the same statement yields the same fingerprints.
+	again := Fingerprints(statement)
+	for i := range got {
+		if again[i] != got[i] {
+			t.Errorf("line %d: %q on re-import, was %q", i, again[i], got[i])
+		}
+	}
+
+	// The hash ignores case in the currency and surrounding spaces.
+	loose := coffee
+	loose.Currency, loose.Counterparty = "eur", " Bar "
+	if loose.fingerprint(0) != coffee.fingerprint(0) {
+		t.Error("fingerprint depends on currency case or spacing")
+	}
+	other := coffee
+	other.Amount = -3.6
+	if other.fingerprint(0) == coffee.fingerprint(0) {
+		t.Error("different amounts share a fingerprint")
+	}
+}
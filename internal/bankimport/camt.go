@@ -0,0 +1,174 @@
+package bankimport
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// camtDocument covers the subset of ISO 20022 camt.053 (BankToCustomerStatement)
+// needed for reconciliation. Tags are matched by local name so any schema
+// version (camt.053.001.02 .. .08) decodes the same way.
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Dt   string `xml:"Dt"`
+	DtTm string `xml:"DtTm"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm"`
+	Pty  struct {
+		Name string `xml:"Nm"`
+	} `xml:"Pty"`
+}
+
+func (p camtParty) name() string {
+	if n := strings.TrimSpace(p.Name); n != "" {
+		return n
+	}
+	return strings.TrimSpace(p.Pty.Name)
+}
+
+type camtEntry struct {
+	Amount      camtAmount `xml:"Amt"`
+	CreditDebit string     `xml:"CdtDbtInd"`
+	Status      struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate   camtDate `xml:"BookgDt"`
+	ValueDate     camtDate `xml:"ValDt"`
+	ServicerRef   string   `xml:"AcctSvcrRef"`
+	AdditionalInf string   `xml:"AddtlNtryInf"`
+	Details       []struct {
+		Refs struct {
+			EndToEndID  string `xml:"EndToEndId"`
+			ServicerRef string `xml:"AcctSvcrRef"`
+			TxID        string `xml:"TxId"`
+		} `xml:"Refs"`
+		Amount        camtAmount `xml:"Amt"`
+		Debtor        camtParty  `xml:"RltdPties>Dbtr"`
+		Creditor      camtParty  `xml:"RltdPties>Cdtr"`
+		Unstructured  []string   `xml:"RmtInf>Ustrd"`
+		StructuredRef []string   `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+		AdditionalInf string     `xml:"AddtlTxInf"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// ParseCAMT053 reads an ISO 20022 camt.053 statement. Batched entries with
+// several transaction details are expanded into one Transaction per detail.
+func ParseCAMT053(r io.Reader) ([]Transaction, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Statements) == 0 {
+		return nil, errors.New("camt.053: no statements found")
+	}
+
+	out := make([]Transaction, 0)
+	for _, st := range doc.Statements {
+		for _, e := range st.Entries {
+			status := strings.ToUpper(firstNonEmpty(e.Status.Code, e.Status.Value))
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			dateRaw := firstNonEmpty(e.BookingDate.Dt, e.BookingDate.DtTm, e.ValueDate.Dt, e.ValueDate.DtTm)
+			if len(dateRaw) > 10 {
+				dateRaw = dateRaw[:10]
+			}
+			booked, err := parseDate(dateRaw, "2006-01-02")
+			if err != nil {
+				return nil, err
+			}
+			debit := strings.EqualFold(strings.TrimSpace(e.CreditDebit), "DBIT")
+
+			if len(e.Details) == 0 {
+				amount, err := parseAmount(e.Amount.Value, false)
+				if err != nil {
+					return nil, err
+				}
+				if debit {
+					amount = -amount
+				}
+				out = append(out, Transaction{
+					ExternalID:  strings.TrimSpace(e.ServicerRef),
+					BookedAt:    booked,
+					Amount:      amount,
+					Currency:    strings.ToUpper(strings.TrimSpace(e.Amount.Currency)),
+					Description: strings.TrimSpace(e.AdditionalInf),
+				})
+				continue
+			}
+
+			for idx, d := range e.Details {
+				amt := d.Amount
+				if strings.TrimSpace(amt.Value) == "" || len(e.Details) == 1 {
+					amt = e.Amount
+				}
+				amount, err := parseAmount(amt.Value, false)
+				if err != nil {
+					return nil, err
+				}
+				counterparty := d.Debtor.name()
+				if debit {
+					amount = -amount
+					counterparty = d.Creditor.name()
+				}
+
+				externalID := firstNonEmpty(d.Refs.ServicerRef, d.Refs.TxID, e.ServicerRef)
+				if externalID != "" && len(e.Details) > 1 && externalID == strings.TrimSpace(e.ServicerRef) {
+					externalID = externalID + "#" + strconv.Itoa(idx+1)
+				}
+				if endToEnd := strings.TrimSpace(d.Refs.EndToEndID); externalID == "" && endToEnd != "" && !strings.EqualFold(endToEnd, "NOTPROVIDED") {
+					externalID = endToEnd
+				}
+
+				refs := make([]string, 0, len(d.Unstructured)+len(d.StructuredRef))
+				for _, s := range d.StructuredRef {
+					if s = strings.TrimSpace(s); s != "" {
+						refs = append(refs, s)
+					}
+				}
+				for _, s := range d.Unstructured {
+					if s = strings.TrimSpace(s); s != "" {
+						refs = append(refs, s)
+					}
+				}
+
+				out = append(out, Transaction{
+					ExternalID:   externalID,
+					BookedAt:     booked,
+					Amount:       amount,
+					Currency:     strings.ToUpper(strings.TrimSpace(amt.Currency)),
+					Counterparty: counterparty,
+					Reference:    strings.Join(refs, " "),
+					Description:  firstNonEmpty(d.AdditionalInf, e.AdditionalInf),
+				})
+			}
+		}
+	}
+	return out, nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package bankimport
+
+import (
+	"strings"
+	"testing"
+)
+
+const camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="EUR">1500.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><Dt>2025-03-07</Dt></BookgDt>
+        <AcctSvcrRef>REF-1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Amt Ccy="EUR">1500.00</Amt>
+          <RltdPties><Dbtr><Nm>ACME S.r.l.</Nm></Dbtr></RltdPties>
+          <RmtInf><Ustrd>QUO-2025-004</Ustrd><Strd><CdtrRefInf><Ref>RF18539007547034</Ref></CdtrRefInf></Strd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">300.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><DtTm>2025-03-08T10:15:00</DtTm></BookgDt>
+        <AcctSvcrRef>BATCH-9</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">100.00</Amt>
+            <RltdPties><Dbtr><Pty><Nm>First Payer</Nm></Pty></Dbtr></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <Refs><EndToEndId>E2E-2</EndToEndId><TxId>TX-2</TxId></Refs>
+            <Amt Ccy="EUR">200.00</Amt>
+            <RltdPties><Dbtr><Nm>Second Payer</Nm></Dbtr></RltdPties>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">45.90</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <ValDt><Dt>2025-03-09</Dt></ValDt>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E-3</EndToEndId></Refs>
+          <RltdPties><Cdtr><Nm>Hosting Ltd</Nm></Cdtr></RltdPties>
+          <AddtlTxInf>Monthly hosting</AddtlTxInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">9.99</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts>PDNG</Sts>
+        <BookgDt><Dt>2025-03-10</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">2.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><Dt>2025-03-10</Dt></BookgDt>
+        <AcctSvcrRef>FEE-1</AcctSvcrRef>
+        <AddtlNtryInf>Account fee</AddtlNtryInf>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	got, err := ParseCAMT053(strings.NewReader(camtStatement))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Transaction{
+		{ExternalID: "REF-1", BookedAt: date(2025, 3, 7), Amount: 1500, Currency: "EUR", Counterparty: "ACME S.r.l.", Reference: "RF18539007547034 QUO-2025-004"},
+		// A batch is split per detail; the entry's reference is numbered.
+		{ExternalID: "BATCH-9#1", BookedAt: date(2025, 3, 8), Amount: 100, Currency: "EUR", Counterparty: "First Payer"},
+		{ExternalID: "TX-2", BookedAt: date(2025, 3, 8), Amount: 200, Currency: "EUR", Counterparty: "Second Payer"},
+		// Debits pay the creditor and fall back to the value date.
+		{ExternalID: "E2E-3", BookedAt: date(2025, 3, 9), Amount: -45.9, Currency: "EUR", Counterparty: "Hosting Ltd", Description: "Monthly hosting"},
+		// The pending entry is skipped; entries without details are kept.
+		{ExternalID: "FEE-1", BookedAt: date(2025, 3, 10), Amount: -2.5, Currency: "EUR", Description: "Account fee"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range got {
+		if !sameTransaction(got[i], want[i]) {
+			t.Errorf("transaction %d:\n got %+v\nwant %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseCAMT053Errors(t *testing.T) {
+	for name, input := range map[string]string{
+		"not xml":       "date,amount",
+		"no statements": `<Document><BkToCstmrStmt></BkToCstmrStmt></Document>`,
+		"bad date":      `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">1</Amt><BookgDt><Dt>07.03.2025</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>`,
+		"bad amount":    `<Document><BkToCstmrStmt><Stmt><Ntry><Amt Ccy="EUR">n/a</Amt><BookgDt><Dt>2025-03-07</Dt></BookgDt></Ntry></Stmt></BkToCstmrStmt></Document>`,
+	} {
+		if _, err := ParseCAMT053(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package bankimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVMapping describes how the columns of a bank CSV export map to
+// transaction fields. Columns are referenced by header name (case-insensitive)
+// or by 1-based position, e.g. "Data valuta" or "3".
+type CSVMapping struct {
+	Delimiter       string `json:"delimiter"`
+	SkipRows        int    `json:"skipRows"`
+	NoHeader        bool   `json:"noHeader"`
+	DateColumn      string `json:"date"`
+	DateFormat      string `json:"dateFormat"`
+	AmountColumn    string `json:"amount"`
+	CreditColumn    string `json:"credit"`
+	DebitColumn     string `json:"debit"`
+	CurrencyColumn  string `json:"currency"`
+	CounterpartyCol string `json:"counterparty"`
+	ReferenceColumn string `json:"reference"`
+	DescriptionCol  string `json:"description"`
+	IDColumn        string `json:"id"`
+	DecimalComma    bool   `json:"decimalComma"`
+	DefaultCurrency string `json:"defaultCurrency"`
+}
+
+func ParseCSV(r io.Reader, m CSVMapping) ([]Transaction, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.TrimLeadingSpace = true
+	if d := m.Delimiter; d != "" {
+		if d == `\t` || d == "tab" {
+			d = "\t"
+		}
+		cr.Comma = []rune(d)[0]
+	}
+
+	for i := 0; i < m.SkipRows; i++ {
+		if _, err := cr.Read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return []Transaction{}, nil
+			}
+			return nil, err
+		}
+	}
+
+	var header []string
+	if !m.NoHeader {
+		rec, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return []Transaction{}, nil
+			}
+			return nil, err
+		}
+		header = rec
+		if len(header) > 0 {
+			header[0] = strings.TrimPrefix(header[0], "\ufeff")
+		}
+	}
+
+	col := func(name string) (int, error) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return -1, nil
+		}
+		if n, err := strconv.Atoi(name); err == nil {
+			if n < 1 {
+				return -1, fmt.Errorf("column %q: positions start at 1", name)
+			}
+			return n - 1, nil
+		}
+		for i, h := range header {
+			if strings.EqualFold(strings.TrimSpace(h), name) {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("column %q not found", name)
+	}
+
+	dateIdx, err := col(m.DateColumn)
+	if err != nil {
+		return nil, err
+	}
+	if dateIdx < 0 {
+		return nil, errors.New("mapping: date column is required")
+	}
+	amountIdx, err := col(m.AmountColumn)
+	if err != nil {
+		return nil, err
+	}
+	creditIdx, err := col(m.CreditColumn)
+	if err != nil {
+		return nil, err
+	}
+	debitIdx, err := col(m.DebitColumn)
+	if err != nil {
+		return nil, err
+	}
+	if amountIdx < 0 && creditIdx < 0 && debitIdx < 0 {
+		return nil, errors.New("mapping: amount (or credit/debit) column is required")
+	}
+	currencyIdx, err := col(m.CurrencyColumn)
+	if err != nil {
+		return nil, err
+	}
+	counterpartyIdx, err := col(m.CounterpartyCol)
+	if err != nil {
+		return nil, err
+	}
+	referenceIdx, err := col(m.ReferenceColumn)
+	if err != nil {
+		return nil, err
+	}
+	descriptionIdx, err := col(m.DescriptionCol)
+	if err != nil {
+		return nil, err
+	}
+	idIdx, err := col(m.IDColumn)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultCurrency := strings.ToUpper(strings.TrimSpace(m.DefaultCurrency))
+	if defaultCurrency == "" {
+		defaultCurrency = "EUR"
+	}
+
+	out := make([]Transaction, 0)
+	line := m.SkipRows
+	if !m.NoHeader {
+		line++
+	}
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line++
+
+		field := func(idx int) string {
+			if idx < 0 || idx >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[idx])
+		}
+		if strings.TrimSpace(strings.Join(rec, "")) == "" {
+			continue
+		}
+
+		var tx Transaction
+		tx.BookedAt, err = parseDate(field(dateIdx), m.DateFormat)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if amountIdx >= 0 {
+			tx.Amount, err = parseAmount(field(amountIdx), m.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		} else {
+			credit, err := parseAmount(field(creditIdx), m.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			debit, err := parseAmount(field(debitIdx), m.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if debit > 0 {
+				debit = -debit
+			}
+			tx.Amount = credit + debit
+		}
+		tx.Currency = strings.ToUpper(field(currencyIdx))
+		if tx.Currency == "" {
+			tx.Currency = defaultCurrency
+		}
+		tx.Counterparty = field(counterpartyIdx)
+		tx.Reference = field(referenceIdx)
+		tx.Description = field(descriptionIdx)
+		tx.ExternalID = field(idIdx)
+		out = append(out, tx)
+	}
+	return out, nil
+}
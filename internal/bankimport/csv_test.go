@@ -0,0 +1,118 @@
+package bankimport
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping CSVMapping
+		want    []Transaction
+	}{
+		{
+			name: "header names, decimal comma and semicolons",
+			input: "\ufeffData;Importo;Divisa;Ordinante;Causale\n" +
+				"07/03/2025;1.234,56;eur;ACME S.r.l.;QUO-2025-004\n" +
+				"\n" +
+				"08/03/2025;-12,50;;Bank;Fees\n",
+			mapping: CSVMapping{Delimiter: ";", DateColumn: "data", AmountColumn: "IMPORTO", CurrencyColumn: "Divisa", CounterpartyCol: "Ordinante", ReferenceColumn: "Causale", DecimalComma: true},
+			want: []Transaction{
+				{BookedAt: date(2025, 3, 7), Amount: 1234.56, Currency: "EUR", Counterparty: "ACME S.r.l.", Reference: "QUO-2025-004"},
+				{BookedAt: date(2025, 3, 8), Amount: -12.5, Currency: "EUR", Counterparty: "Bank", Reference: "Fees"},
+			},
+		},
+		{
+			name: "positions, no header, skipped preamble and tabs",
+			input: "Account 123\n" +
+				"2025-03-07\t100.00\tTX-1\tAcme\n",
+			mapping: CSVMapping{Delimiter: "tab", SkipRows: 1, NoHeader: true, DateColumn: "1", AmountColumn: "2", IDColumn: "3", CounterpartyCol: "4", DefaultCurrency: "usd"},
+			want: []Transaction{
+				{ExternalID: "TX-1", BookedAt: date(2025, 3, 7), Amount: 100, Currency: "USD", Counterparty: "Acme"},
+			},
+		},
+		{
+			name: "credit and debit columns",
+			input: "Date,Credit,Debit,Text\n" +
+				"2025-03-07,250.00,,Invoice 7\n" +
+				"2025-03-08,,40.00,Hosting\n" +
+				"2025-03-09,,-5.00,Card fee\n",
+			mapping: CSVMapping{DateColumn: "Date", CreditColumn: "Credit", DebitColumn: "Debit", DescriptionCol: "Text"},
+			want: []Transaction{
+				{BookedAt: date(2025, 3, 7), Amount: 250, Currency: "EUR", Description: "Invoice 7"},
+				{BookedAt: date(2025, 3, 8), Amount: -40, Currency: "EUR", Description: "Hosting"},
+				{BookedAt: date(2025, 3, 9), Amount: -5, Currency: "EUR", Description: "Card fee"},
+			},
+		},
+		{
+			name: "repeated lines are all kept",
+			input: "Date,Amount,Text\n" +
+				"2025-03-07,-3.50,Coffee\n" +
+				"2025-03-07,-3.50,Coffee\n",
+			mapping: CSVMapping{DateColumn: "Date", AmountColumn: "Amount", DescriptionCol: "Text"},
+			want: []Transaction{
+				{BookedAt: date(2025, 3, 7), Amount: -3.5, Currency: "EUR", Description: "Coffee"},
+				{BookedAt: date(2025, 3, 7), Amount: -3.5, Currency: "EUR", Description: "Coffee"},
+			},
+		},
+		{
+			name:    "header only",
+			input:   "Date,Amount\n",
+			mapping: CSVMapping{DateColumn: "Date", AmountColumn: "Amount"},
+			want:    []Transaction{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV(strings.NewReader(tt.input), tt.mapping)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d transactions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !sameTransaction(got[i], tt.want[i]) {
+					t.Errorf("line %d:\n got %+v\nwant %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		mapping CSVMapping
+		wantErr string
+	}{
+		{"no date column", "Date,Amount\n", CSVMapping{AmountColumn: "Amount"}, "date column is required"},
+		{"no amount column", "Date,Amount\n", CSVMapping{DateColumn: "Date"}, "amount (or credit/debit) column is required"},
+		{"unknown column", "Date,Amount\n", CSVMapping{DateColumn: "Datum", AmountColumn: "Amount"}, `column "Datum" not found`},
+		{"position zero", "Date,Amount\n", CSVMapping{DateColumn: "0", AmountColumn: "2"}, "positions start at 1"},
+		{"bad date", "Date,Amount\n2025-03-07,1\nsoon,2\n", CSVMapping{DateColumn: "Date", AmountColumn: "Amount"}, "line 3"},
+		{"bad amount", "Date,Amount\n2025-03-07,lots\n", CSVMapping{DateColumn: "Date", AmountColumn: "Amount"}, "invalid amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCSV(strings.NewReader(tt.input), tt.mapping)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func sameTransaction(a, b Transaction) bool {
+	bookedA, bookedB := a.BookedAt, b.BookedAt
+	a.BookedAt, b.BookedAt = time.Time{}, time.Time{}
+	return bookedA.Equal(bookedB) && a == b
+}
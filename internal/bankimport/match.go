@@ -0,0 +1,121 @@
+package bankimport
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Keyword is a piece of text expected to show up in the remittance info of a
+// transfer paying a given Payment (quotation number, organization name, ...).
+type Keyword struct {
+	Text   string
+	Label  string
+	Weight float64
+}
+
+// Candidate is a planned payment that a bank line may settle.
+type Candidate struct {
+	PaymentID string
+	Amount    float64
+	Currency  string
+	DueAt     *time.Time
+	Keywords  []Keyword
+}
+
+type Suggestion struct {
+	PaymentID string   `json:"paymentId"`
+	Score     float64  `json:"score"`
+	Reasons   []string `json:"reasons"`
+}
+
+// Suggest ranks candidates for a transaction by amount, date proximity (within
+// window of DueAt) and reference text. Only credits are matched; candidates
+// scoring below a plain amount match are dropped.
+func Suggest(tx Transaction, candidates []Candidate, window time.Duration, limit int) []Suggestion {
+	if tx.Amount <= 0 {
+		return []Suggestion{}
+	}
+	haystack := normalizeText(strings.Join([]string{tx.Reference, tx.Description, tx.Counterparty}, " "))
+
+	out := make([]Suggestion, 0)
+	for _, c := range candidates {
+		if c.Currency != "" && tx.Currency != "" && !strings.EqualFold(c.Currency, tx.Currency) {
+			continue
+		}
+		score := 0.0
+		reasons := make([]string, 0, 3)
+
+		diff := math.Abs(tx.Amount - c.Amount)
+		switch {
+		case diff <= 0.01:
+			score += 50
+			reasons = append(reasons, "amount")
+		case c.Amount > 0 && diff/c.Amount <= 0.02:
+			// Bank fees or rounding on the payer's side.
+			score += 25
+			reasons = append(reasons, "amount~")
+		}
+
+		if c.DueAt != nil && window > 0 {
+			delta := tx.BookedAt.Sub(*c.DueAt)
+			if delta < 0 {
+				delta = -delta
+			}
+			if delta <= window {
+				score += 20 * (1 - float64(delta)/float64(window))
+				reasons = append(reasons, "date")
+			}
+		}
+
+		for _, k := range c.Keywords {
+			needle := normalizeText(k.Text)
+			if len(needle) < 3 {
+				continue
+			}
+			if strings.Contains(haystack, needle) {
+				score += k.Weight
+				reasons = append(reasons, k.Label)
+			}
+		}
+
+		if score < 45 {
+			continue
+		}
+		out = append(out, Suggestion{
+			PaymentID: c.PaymentID,
+			Score:     math.Round(score*10) / 10,
+			Reasons:   reasons,
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// normalizeText lowercases and collapses punctuation so "QUO-2025-004" matches
+// "quo 2025 004" and "ACME S.r.l." matches "acme srl".
+func normalizeText(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case r == '.' || r == '\'':
+			// drop
+		default:
+			if !space && b.Len() > 0 {
+				b.WriteByte(' ')
+				space = true
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
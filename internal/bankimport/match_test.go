@@ -0,0 +1,96 @@
+package bankimport
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSuggest(t *testing.T) {
+	booked := time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC)
+	due := func(offset time.Duration) *time.Time {
+		d := booked.Add(offset)
+		return &d
+	}
+	const day = 24 * time.Hour
+	quote := Keyword{Text: "QUO-2025-004", Label: "quotation", Weight: 30}
+	org := Keyword{Text: "ACME S.r.l.", Label: "organization", Weight: 15}
+
+	tests := []struct {
+		name       string
+		tx         Transaction
+		candidates []Candidate
+		limit      int
+		want       []Suggestion
+	}{
+		{
+			name:       "exact amount",
+			tx:         Transaction{BookedAt: booked, Amount: 1000, Currency: "EUR"},
+			candidates: []Candidate{{PaymentID: "p1", Amount: 1000, Currency: "eur"}},
+			want:       []Suggestion{{PaymentID: "p1", Score: 50, Reasons: []string{"amount"}}},
+		},
+		{
+			name:       "a fee off the amount needs more evidence",
+			tx:         Transaction{BookedAt: booked, Amount: 985, Reference: "quo 2025 004"},
+			candidates: []Candidate{{PaymentID: "p1", Amount: 1000, Keywords: []Keyword{quote}}, {PaymentID: "p2", Amount: 1000}},
+			want:       []Suggestion{{PaymentID: "p1", Score: 55, Reasons: []string{"amount~", "quotation"}}},
+		},
+		{
+			name: "closer due dates score higher",
+			tx:   Transaction{BookedAt: booked, Amount: 500},
+			candidates: []Candidate{
+				{PaymentID: "late", Amount: 500, DueAt: due(-5 * day)},
+				{PaymentID: "outside", Amount: 500, DueAt: due(11 * day)},
+				{PaymentID: "same day", Amount: 500, DueAt: due(0)},
+			},
+			want: []Suggestion{
+				{PaymentID: "same day", Score: 70, Reasons: []string{"amount", "date"}},
+				{PaymentID: "late", Score: 60, Reasons: []string{"amount", "date"}},
+				{PaymentID: "outside", Score: 50, Reasons: []string{"amount"}},
+			},
+		},
+		{
+			name: "keywords match across punctuation and case",
+			tx:   Transaction{BookedAt: booked, Amount: 42, Counterparty: "Acme Srl", Description: "Payment Quo/2025/004"},
+			candidates: []Candidate{
+				{PaymentID: "p1", Amount: 1000, Keywords: []Keyword{quote, org}},
+				{PaymentID: "p2", Amount: 1000, Keywords: []Keyword{{Text: "Ac", Label: "short", Weight: 50}}},
+			},
+			want: []Suggestion{{PaymentID: "p1", Score: 45, Reasons: []string{"quotation", "organization"}}},
+		},
+		{
+			name:       "debits are not matched",
+			tx:         Transaction{BookedAt: booked, Amount: -1000},
+			candidates: []Candidate{{PaymentID: "p1", Amount: 1000}},
+			want:       []Suggestion{},
+		},
+		{
+			name:       "other currencies are skipped",
+			tx:         Transaction{BookedAt: booked, Amount: 1000, Currency: "USD"},
+			candidates: []Candidate{{PaymentID: "p1", Amount: 1000, Currency: "EUR"}, {PaymentID: "p2", Amount: 1000}},
+			want:       []Suggestion{{PaymentID: "p2", Score: 50, Reasons: []string{"amount"}}},
+		},
+		{
+			name: "limit keeps the best",
+			tx:   Transaction{BookedAt: booked, Amount: 1000, Reference: "QUO-2025-004"},
+			candidates: []Candidate{
+				{PaymentID: "p1", Amount: 1000},
+				{PaymentID: "p2", Amount: 1000, Keywords: []Keyword{quote}},
+				{PaymentID: "p3", Amount: 1000, DueAt: due(day)},
+			},
+			limit: 2,
+			want: []Suggestion{
+				{PaymentID: "p2", Score: 80, Reasons: []string{"amount", "quotation"}},
+				{PaymentID: "p3", Score: 68, Reasons: []string{"amount", "date"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Suggest(tt.tx, tt.candidates, 10*day, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
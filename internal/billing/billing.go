@@ -0,0 +1,71 @@
+package billing
+
+import (
+	"fmt"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// Months returns the length of an interval in months, or 0 if unknown.
+func Months(interval models.BillingInterval) int {
+	switch interval {
+	case models.BillingMonthly:
+		return 1
+	case models.BillingQuarterly:
+		return 3
+	case models.BillingYearly:
+		return 12
+	default:
+		return 0
+	}
+}
+
+// AddMonths adds n months to t, clamping to the last day of the target month
+// so a plan starting on Jan 31 bills on Feb 28/29 rather than Mar 3.
+func AddMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	target := time.Date(y, m+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	last := time.Date(target.Year(), target.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if d > last {
+		d = last
+	}
+	return time.Date(target.Year(), target.Month(), d, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+}
+
+// DueDates lists the billing dates of a plan falling within [from, until],
+// bounded by the plan's own start and end.
+func DueDates(p models.BillingPlan, from, until time.Time) []time.Time {
+	step := Months(p.Interval)
+	out := make([]time.Time, 0)
+	if step == 0 || p.StartAt.IsZero() {
+		return out
+	}
+	for n := 0; ; n++ {
+		d := AddMonths(p.StartAt, n*step)
+		if d.After(until) {
+			break
+		}
+		if p.EndAt != nil && d.After(*p.EndAt) {
+			break
+		}
+		if d.Before(from) {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// PeriodLabel names the billing period starting at d, e.g. "2026-03",
+// "Q2 2026" or "2026".
+func PeriodLabel(interval models.BillingInterval, d time.Time) string {
+	switch interval {
+	case models.BillingQuarterly:
+		return fmt.Sprintf("Q%d %d", (int(d.Month())-1)/3+1, d.Year())
+	case models.BillingYearly:
+		return fmt.Sprintf("%d", d.Year())
+	default:
+		return d.Format("2006-01")
+	}
+}
@@ -0,0 +1,129 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestAddMonths(t *testing.T) {
+	tests := []struct {
+		from string
+		n    int
+		want string
+	}{
+		{"2025-01-15", 1, "2025-02-15"},
+		{"2025-01-31", 1, "2025-02-28"},
+		{"2024-01-31", 1, "2024-02-29"},
+		{"2025-01-31", 2, "2025-03-31"},
+		{"2025-03-31", 1, "2025-04-30"},
+		{"2025-08-31", 3, "2025-11-30"},
+		{"2025-11-30", 3, "2026-02-28"},
+		{"2024-02-29", 12, "2025-02-28"},
+		{"2025-12-31", 1, "2026-01-31"},
+		{"2025-03-31", -1, "2025-02-28"},
+		{"2025-05-10", 0, "2025-05-10"},
+	}
+	for _, tt := range tests {
+		if got := AddMonths(day(tt.from), tt.n); !got.Equal(day(tt.want)) {
+			t.Errorf("AddMonths(%s, %d) = %s, want %s", tt.from, tt.n, got.Format("2006-01-02"), tt.want)
+		}
+	}
+}
+
+func TestAddMonthsKeepsTimeOfDay(t *testing.T) {
+	start := time.Date(2025, 1, 31, 9, 30, 0, 0, time.UTC)
+	want := time.Date(2025, 2, 28, 9, 30, 0, 0, time.UTC)
+	if got := AddMonths(start, 1); !got.Equal(want) {
+		t.Errorf("AddMonths = %v, want %v", got, want)
+	}
+}
+
+func TestDueDates(t *testing.T) {
+	end := day("2025-06-30")
+	tests := []struct {
+		name        string
+		plan        models.BillingPlan
+		from, until string
+		want        []string
+	}{
+		{
+			name:  "monthly from the 31st never drifts",
+			plan:  models.BillingPlan{Interval: models.BillingMonthly, StartAt: day("2025-01-31")},
+			from:  "2025-01-01",
+			until: "2025-05-31",
+			want:  []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31"},
+		},
+		{
+			name:  "window starts after the plan",
+			plan:  models.BillingPlan{Interval: models.BillingQuarterly, StartAt: day("2024-11-30")},
+			from:  "2025-01-01",
+			until: "2025-12-31",
+			want:  []string{"2025-02-28", "2025-05-30", "2025-08-30", "2025-11-30"},
+		},
+		{
+			name:  "plan end bounds the dates",
+			plan:  models.BillingPlan{Interval: models.BillingMonthly, StartAt: day("2025-03-31"), EndAt: &end},
+			from:  "2025-01-01",
+			until: "2025-12-31",
+			want:  []string{"2025-03-31", "2025-04-30", "2025-05-31", "2025-06-30"},
+		},
+		{
+			name:  "yearly on a leap day",
+			plan:  models.BillingPlan{Interval: models.BillingYearly, StartAt: day("2024-02-29")},
+			from:  "2024-01-01",
+			until: "2028-12-31",
+			want:  []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"},
+		},
+		{
+			name:  "bounds are inclusive",
+			plan:  models.BillingPlan{Interval: models.BillingMonthly, StartAt: day("2025-01-10")},
+			from:  "2025-02-10",
+			until: "2025-03-10",
+			want:  []string{"2025-02-10", "2025-03-10"},
+		},
+		{
+			name:  "plan starting after the window",
+			plan:  models.BillingPlan{Interval: models.BillingMonthly, StartAt: day("2026-01-01")},
+			from:  "2025-01-01",
+			until: "2025-12-31",
+		},
+		{
+			name:  "unknown interval",
+			plan:  models.BillingPlan{Interval: "weekly", StartAt: day("2025-01-01")},
+			from:  "2025-01-01",
+			until: "2025-12-31",
+		},
+		{
+			name:  "no start",
+			plan:  models.BillingPlan{Interval: models.BillingMonthly},
+			from:  "2025-01-01",
+			until: "2025-12-31",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DueDates(tt.plan, day(tt.from), day(tt.until))
+			if got == nil {
+				t.Fatal("DueDates returned nil, want an empty slice")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d dates %v, want %v", len(got), got, tt.want)
+			}
+			for i, w := range tt.want {
+				if !got[i].Equal(day(w)) {
+					t.Errorf("date %d = %s, want %s", i, got[i].Format("2006-01-02"), w)
+				}
+			}
+		})
+	}
+}
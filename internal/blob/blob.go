@@ -0,0 +1,112 @@
+// Package blob stores file contents by their SHA-256, so identical uploads
+// share one copy. Store is the extension point for other backends (e.g. an
+// S3-compatible bucket); Dir keeps blobs on the local disk.
+package blob
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store holds content-addressed blobs. Keys are lowercase hex SHA-256
+// digests of the content.
+type Store interface {
+	// Put stores the content of r and returns its key and size. Storing
+	// content that is already present is a no-op.
+	Put(ctx context.Context, r io.Reader) (key string, size int64, err error)
+	// Open returns the content of a blob, or ErrNotFound.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey reports whether key has the form of a blob key.
+func ValidKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Dir stores blobs as files under Root, fanned out by the first two hex
+// digits of their key.
+type Dir struct {
+	Root string
+}
+
+func (d Dir) path(key string) string {
+	return filepath.Join(d.Root, key[:2], key)
+}
+
+func (d Dir) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	if err := os.MkdirAll(d.Root, 0o755); err != nil {
+		return "", 0, err
+	}
+	tmp, err := os.CreateTemp(d.Root, ".upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	dst := d.path(key)
+	if _, err := os.Stat(dst); err == nil {
+		return key, size, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", 0, err
+	}
+	return key, size, nil
+}
+
+func (d Dir) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrNotFound
+	}
+	f, err := os.Open(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (d Dir) Delete(_ context.Context, key string) error {
+	if !ValidKey(key) {
+		return nil
+	}
+	err := os.Remove(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -35,6 +35,24 @@ type Settings struct {
 	OllamaOverallTimeoutSeconds int          `json:"ollama_overall_timeout_seconds"`
 	OllamaMaxAttempts           int          `json:"ollama_max_attempts"`
 	OllamaBackoffBaseMs         int          `json:"ollama_backoff_base_ms"`
+	BillingHorizonDays          int          `json:"billing_horizon_days"`
+	DomainRenewalLeadDays       int          `json:"domain_renewal_lead_days"`
+	RDAPBaseURL                 string       `json:"rdap_base_url"`
+	WHOISServer                 string       `json:"whois_server"`
+	DomainRefreshDays           int          `json:"domain_refresh_days"`
+	ReportingCurrency           string       `json:"reporting_currency"`
+	ECBRatesURL                 string       `json:"ecb_rates_url"`
+	// FollowUpDigestHour is the local hour after which the daily follow-up
+	// digest is sent; -1 disables it.
+	FollowUpDigestHour int `json:"follow_up_digest_hour"`
+	// MeetingImportDir is a directory of .ics files (e.g. a synced CalDAV
+	// collection) imported as meetings every hour; empty disables it.
+	MeetingImportDir string `json:"meeting_import_dir"`
+	// MaildirPath is a local maildir whose new/ messages are logged as email
+	// interactions every hour; empty disables it.
+	MaildirPath string `json:"maildir_path"`
+	// AttachmentMaxMB caps the size of one uploaded attachment.
+	AttachmentMaxMB int `json:"attachment_max_mb"`
 }
 
 func DefaultSettings() Settings {
@@ -54,6 +72,17 @@ func DefaultSettings() Settings {
 		OllamaOverallTimeoutSeconds: 180,
 		OllamaMaxAttempts:           5,
 		OllamaBackoffBaseMs:         0,
+		BillingHorizonDays:          45,
+		DomainRenewalLeadDays:       60,
+		RDAPBaseURL:                 "https://rdap.org",
+		WHOISServer:                 "",
+		DomainRefreshDays:           0,
+		ReportingCurrency:           "EUR",
+		ECBRatesURL:                 "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml",
+		FollowUpDigestHour:          8,
+		MeetingImportDir:            "",
+		MaildirPath:                 "",
+		AttachmentMaxMB:             25,
 	}
 }
 
@@ -121,6 +150,27 @@ func Load(path string) (Settings, error) {
 	if cfg.OllamaBackoffBaseMs == 0 {
 		cfg.OllamaBackoffBaseMs = DefaultSettings().OllamaBackoffBaseMs
 	}
+	if cfg.BillingHorizonDays == 0 {
+		cfg.BillingHorizonDays = DefaultSettings().BillingHorizonDays
+	}
+	if cfg.DomainRenewalLeadDays == 0 {
+		cfg.DomainRenewalLeadDays = DefaultSettings().DomainRenewalLeadDays
+	}
+	if cfg.RDAPBaseURL == "" {
+		cfg.RDAPBaseURL = DefaultSettings().RDAPBaseURL
+	}
+	if cfg.ReportingCurrency == "" {
+		cfg.ReportingCurrency = DefaultSettings().ReportingCurrency
+	}
+	if cfg.ECBRatesURL == "" {
+		cfg.ECBRatesURL = DefaultSettings().ECBRatesURL
+	}
+	if _, ok := raw["follow_up_digest_hour"]; !ok {
+		cfg.FollowUpDigestHour = DefaultSettings().FollowUpDigestHour
+	}
+	if cfg.AttachmentMaxMB <= 0 {
+		cfg.AttachmentMaxMB = DefaultSettings().AttachmentMaxMB
+	}
 
 	// Cloud-backed Ollama models can be significantly slower (cold starts, network latency).
 	// Avoid brittle timeouts when using them.
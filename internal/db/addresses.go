@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+const addressColumns = `id, organization_id, address_type, street, postal_code, city, province, country, created_at, updated_at`
+
+func (s *Store) SaveAddress(a models.Address) error {
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO addresses (`+addressColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		a.ID,
+		a.OrganizationID,
+		string(a.Type),
+		a.Street,
+		a.PostalCode,
+		a.City,
+		a.Province,
+		a.Country,
+		a.CreatedAt.Unix(),
+		a.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+// LoadAddresses returns the addresses of one organization, or of all
+// organizations when organizationID is empty, oldest first.
+func (s *Store) LoadAddresses(organizationID string) ([]models.Address, error) {
+	rows, err := s.DB.Query(
+		`SELECT `+addressColumns+` FROM addresses
+		WHERE ? = '' OR organization_id = ?
+		ORDER BY organization_id, created_at, id;`,
+		organizationID, organizationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make([]models.Address, 0)
+	for rows.Next() {
+		a, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses, rows.Err()
+}
+
+func (s *Store) FindAddressByID(id string) (models.Address, bool, error) {
+	a, err := scanAddress(s.DB.QueryRow(`SELECT `+addressColumns+` FROM addresses WHERE id = ?;`, id))
+	if err == sql.ErrNoRows {
+		return models.Address{}, false, nil
+	}
+	if err != nil {
+		return models.Address{}, false, err
+	}
+	return a, true, nil
+}
+
+func scanAddress(row rowScanner) (models.Address, error) {
+	var a models.Address
+	var addressType string
+	var createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&a.ID,
+		&a.OrganizationID,
+		&addressType,
+		&a.Street,
+		&a.PostalCode,
+		&a.City,
+		&a.Province,
+		&a.Country,
+		&createdUnix,
+		&updatedUnix,
+	); err != nil {
+		return models.Address{}, err
+	}
+	a.Type = models.AddressType(addressType)
+	a.CreatedAt = time.Unix(createdUnix, 0)
+	a.UpdatedAt = time.Unix(updatedUnix, 0)
+	return a, nil
+}
+
+func (s *Store) DeleteAddress(id string) error {
+	_, err := s.DB.Exec(`DELETE FROM addresses WHERE id = ?;`, id)
+	return err
+}
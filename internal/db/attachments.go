@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+const attachmentColumns = `id, entity_type, entity_id, blob_key, filename, content_type, size, uploaded_by_user_id, created_at`
+
+// SaveAttachment stores an attachment and registers its blob, so the blob
+// sweep knows about it.
+func (s *Store) SaveAttachment(a models.Attachment) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(
+		`INSERT OR IGNORE INTO blobs (key, size, created_at) VALUES (?, ?, ?);`,
+		a.SHA256, a.Size, a.CreatedAt.Unix(),
+	); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(
+		`INSERT OR REPLACE INTO attachments (`+attachmentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		a.ID,
+		a.EntityType,
+		a.EntityID,
+		a.SHA256,
+		a.Filename,
+		a.ContentType,
+		a.Size,
+		a.UploadedByUserID,
+		a.CreatedAt.Unix(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadAttachments returns the attachments of one entity, newest first.
+func (s *Store) LoadAttachments(entityType, entityID string) ([]models.Attachment, error) {
+	rows, err := s.DB.Query(
+		`SELECT `+attachmentColumns+` FROM attachments WHERE entity_type = ? AND entity_id = ? ORDER BY created_at DESC, id DESC;`,
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]models.Attachment, 0)
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (s *Store) FindAttachmentByID(id string) (models.Attachment, bool, error) {
+	a, err := scanAttachment(s.DB.QueryRow(`SELECT `+attachmentColumns+` FROM attachments WHERE id = ?;`, id))
+	if err == sql.ErrNoRows {
+		return models.Attachment{}, false, nil
+	}
+	if err != nil {
+		return models.Attachment{}, false, err
+	}
+	return a, true, nil
+}
+
+func scanAttachment(row rowScanner) (models.Attachment, error) {
+	var a models.Attachment
+	var createdUnix int64
+	if err := row.Scan(
+		&a.ID,
+		&a.EntityType,
+		&a.EntityID,
+		&a.SHA256,
+		&a.Filename,
+		&a.ContentType,
+		&a.Size,
+		&a.UploadedByUserID,
+		&createdUnix,
+	); err != nil {
+		return models.Attachment{}, err
+	}
+	a.CreatedAt = time.Unix(createdUnix, 0)
+	return a, nil
+}
+
+func (s *Store) DeleteAttachment(id string) error {
+	_, err := s.DB.Exec(`DELETE FROM attachments WHERE id = ?;`, id)
+	return err
+}
+
+// UnreferencedBlobs returns the keys of stored blobs no attachment uses.
+func (s *Store) UnreferencedBlobs() ([]string, error) {
+	rows, err := s.DB.Query(`SELECT key FROM blobs WHERE key NOT IN (SELECT blob_key FROM attachments) ORDER BY key;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// ForgetBlob unregisters a blob once its content has been deleted.
+func (s *Store) ForgetBlob(key string) error {
+	_, err := s.DB.Exec(`DELETE FROM blobs WHERE key = ? AND key NOT IN (SELECT blob_key FROM attachments);`, key)
+	return err
+}
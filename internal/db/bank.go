@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// InsertBankTransaction stores an imported bank line unless one with the same
+// external ID already exists. It reports whether a row was inserted.
+func (s *Store) InsertBankTransaction(t models.BankTransaction) (bool, error) {
+	res, err := s.DB.Exec(
+		`INSERT OR IGNORE INTO bank_transactions
+		(id, external_id, source, booked_at, amount, currency, counterparty, reference, description, status, payment_id, matched_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		t.ID,
+		t.ExternalID,
+		t.Source,
+		t.BookedAt.Unix(),
+		t.Amount,
+		t.Currency,
+		t.Counterparty,
+		t.Reference,
+		t.Description,
+		string(t.Status),
+		t.PaymentID,
+		unixOrZero(t.MatchedAt),
+		t.CreatedAt.Unix(),
+		t.UpdatedAt.Unix(),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *Store) UpdateBankTransactionStatus(id string, status models.BankTransactionStatus) error {
+	_, err := s.DB.Exec(
+		`UPDATE bank_transactions SET status = ?, payment_id = '', matched_at = 0, updated_at = ? WHERE id = ?;`,
+		string(status),
+		time.Now().Unix(),
+		id,
+	)
+	return err
+}
+
+// LoadBankTransactions returns statement lines, newest first. An empty status
+// returns every line.
+func (s *Store) LoadBankTransactions(status models.BankTransactionStatus) ([]models.BankTransaction, error) {
+	query := `SELECT id, external_id, source, booked_at, amount, currency, counterparty, reference, description, status, payment_id, matched_at, created_at, updated_at FROM bank_transactions`
+	args := []any{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, string(status))
+	}
+	query += ` ORDER BY booked_at DESC, created_at DESC;`
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.BankTransaction, 0)
+	for rows.Next() {
+		t, err := scanBankTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) FindBankTransactionByID(id string) (models.BankTransaction, bool, error) {
+	row := s.DB.QueryRow(`SELECT id, external_id, source, booked_at, amount, currency, counterparty, reference, description, status, payment_id, matched_at, created_at, updated_at FROM bank_transactions WHERE id = ? LIMIT 1;`, id)
+	t, err := scanBankTransaction(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.BankTransaction{}, false, nil
+		}
+		return models.BankTransaction{}, false, err
+	}
+	return t, true, nil
+}
+
+func (s *Store) DeleteBankTransaction(id string) error {
+	_, err := s.DB.Exec(`DELETE FROM bank_transactions WHERE id = ?;`, id)
+	return err
+}
+
+// Errors from ReconcileBankTransaction when another request got there first.
+var (
+	ErrPaymentNotPlanned  = errors.New("payment is not planned")
+	ErrTransactionMatched = errors.New("transaction is already matched")
+)
+
+// ReconcileBankTransaction marks a payment as paid from a bank line and links
+// the two in a single transaction. Both rows are checked again in it, so two
+// concurrent reconciles cannot match the same payment or bank line.
+func (s *Store) ReconcileBankTransaction(transactionID string, paymentID string, method string, paidAt time.Time) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now().Unix()
+	res, err := tx.Exec(
+		`UPDATE payments SET status = ?, paid_at = ?, method = ?, updated_at = ? WHERE id = ? AND status = ?;`,
+		string(models.PaymentPaid),
+		paidAt.Unix(),
+		method,
+		now,
+		paymentID,
+		string(models.PaymentPlanned),
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrPaymentNotPlanned
+	}
+	res, err = tx.Exec(
+		`UPDATE bank_transactions SET status = ?, payment_id = ?, matched_at = ?, updated_at = ? WHERE id = ? AND status <> ?;`,
+		string(models.BankTransactionMatched),
+		paymentID,
+		now,
+		now,
+		transactionID,
+		string(models.BankTransactionMatched),
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrTransactionMatched
+	}
+	return tx.Commit()
+}
+
+func scanBankTransaction(row rowScanner) (models.BankTransaction, error) {
+	var t models.BankTransaction
+	var status string
+	var bookedUnix, matchedUnix, createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&t.ID,
+		&t.ExternalID,
+		&t.Source,
+		&bookedUnix,
+		&t.Amount,
+		&t.Currency,
+		&t.Counterparty,
+		&t.Reference,
+		&t.Description,
+		&status,
+		&t.PaymentID,
+		&matchedUnix,
+		&createdUnix,
+		&updatedUnix,
+	); err != nil {
+		return models.BankTransaction{}, err
+	}
+	t.Status = models.BankTransactionStatus(status)
+	t.BookedAt = time.Unix(bookedUnix, 0)
+	if matchedUnix > 0 {
+		m := time.Unix(matchedUnix, 0)
+		t.MatchedAt = &m
+	}
+	t.CreatedAt = time.Unix(createdUnix, 0)
+	t.UpdatedAt = time.Unix(updatedUnix, 0)
+	return t, nil
+}
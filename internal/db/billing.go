@@ -0,0 +1,169 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+func (s *Store) SaveBillingPlan(p models.BillingPlan) error {
+	active := 0
+	if p.Active {
+		active = 1
+	}
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO billing_plans
+		(id, deal_id, project_id, title, interval, amount, currency, start_at, end_at, active, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		p.ID,
+		p.DealID,
+		p.ProjectID,
+		p.Title,
+		string(p.Interval),
+		p.Amount,
+		p.Currency,
+		p.StartAt.Unix(),
+		unixOrZero(p.EndAt),
+		active,
+		p.Notes,
+		p.CreatedAt.Unix(),
+		p.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+func (s *Store) LoadBillingPlans() ([]models.BillingPlan, error) {
+	return s.loadBillingPlans("")
+}
+
+func (s *Store) loadBillingPlans(where string, args ...any) ([]models.BillingPlan, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT id, deal_id, project_id, title, interval, amount, currency, start_at, end_at, active, notes, created_at, updated_at FROM billing_plans`+where+` ORDER BY created_at DESC;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.BillingPlan, 0)
+	for rows.Next() {
+		var p models.BillingPlan
+		var interval string
+		var active int
+		var startUnix, endUnix, createdUnix, updatedUnix int64
+		if err := rows.Scan(
+			&p.ID,
+			&p.DealID,
+			&p.ProjectID,
+			&p.Title,
+			&interval,
+			&p.Amount,
+			&p.Currency,
+			&startUnix,
+			&endUnix,
+			&active,
+			&p.Notes,
+			&createdUnix,
+			&updatedUnix,
+		); err != nil {
+			return nil, err
+		}
+		p.Interval = models.BillingInterval(interval)
+		p.StartAt = time.Unix(startUnix, 0)
+		if endUnix > 0 {
+			t := time.Unix(endUnix, 0)
+			p.EndAt = &t
+		}
+		p.Active = active != 0
+		p.CreatedAt = time.Unix(createdUnix, 0)
+		p.UpdatedAt = time.Unix(updatedUnix, 0)
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) DeleteBillingPlan(id string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Generated payments stay; only the schedule goes away.
+	if _, err = tx.Exec(`DELETE FROM billing_plan_payments WHERE plan_id = ?;`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM billing_plans WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreateScheduledPayment saves a generated payment together with the
+// (kind, key, period) marker that produced it. It is a no-op returning false
+// when that marker already exists, which keeps the scheduler idempotent.
+func (s *Store) CreateScheduledPayment(kind string, key string, period time.Time, p models.Payment) (created bool, err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var res sql.Result
+	switch kind {
+	case "billing_plan":
+		res, err = tx.Exec(`INSERT OR IGNORE INTO billing_plan_payments (plan_id, period_at, payment_id) VALUES (?, ?, ?);`, key, period.Unix(), p.ID)
+	case "domain_renewal":
+		res, err = tx.Exec(`INSERT OR IGNORE INTO domain_renewal_payments (domain_id, expires_at, payment_id) VALUES (?, ?, ?);`, key, period.Unix(), p.ID)
+	default:
+		err = fmt.Errorf("unknown schedule kind %q", kind)
+	}
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		err = tx.Commit()
+		return false, err
+	}
+
+	if _, err = tx.Exec(
+		`INSERT INTO payments
+		(`+paymentColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		p.ID,
+		p.DealID,
+		p.Title,
+		p.Amount,
+		p.Currency,
+		string(p.Status),
+		unixOrZero(p.DueAt),
+		unixOrZero(p.PaidAt),
+		p.Method,
+		p.Notes,
+		p.GilAmount,
+		p.RicAmount,
+		p.ReportingAmount,
+		p.ReportingCurrency,
+		p.CreatedAt.Unix(),
+		p.UpdatedAt.Unix(),
+	); err != nil {
+		return false, err
+	}
+	err = tx.Commit()
+	return err == nil, err
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+func (s *Store) SaveCalendarFeed(f models.CalendarFeed) error {
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO calendar_feeds (user_id, token, categories, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?);`,
+		f.UserID,
+		f.Token,
+		strings.Join(f.Categories, ","),
+		f.CreatedAt.Unix(),
+		f.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+func (s *Store) FindCalendarFeedByUser(userID string) (models.CalendarFeed, bool, error) {
+	return s.findCalendarFeed(`user_id = ?`, userID)
+}
+
+func (s *Store) FindCalendarFeedByToken(token string) (models.CalendarFeed, bool, error) {
+	return s.findCalendarFeed(`token = ?`, token)
+}
+
+func (s *Store) findCalendarFeed(where string, arg string) (models.CalendarFeed, bool, error) {
+	var f models.CalendarFeed
+	var categories string
+	var createdUnix, updatedUnix int64
+	err := s.DB.QueryRow(
+		`SELECT user_id, token, categories, created_at, updated_at FROM calendar_feeds WHERE `+where+`;`,
+		arg,
+	).Scan(&f.UserID, &f.Token, &categories, &createdUnix, &updatedUnix)
+	if err == sql.ErrNoRows {
+		return models.CalendarFeed{}, false, nil
+	}
+	if err != nil {
+		return models.CalendarFeed{}, false, err
+	}
+	f.Categories = make([]string, 0)
+	for _, c := range strings.Split(categories, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			f.Categories = append(f.Categories, c)
+		}
+	}
+	f.CreatedAt = time.Unix(createdUnix, 0)
+	f.UpdatedAt = time.Unix(updatedUnix, 0)
+	return f, true, nil
+}
+
+func (s *Store) DeleteCalendarFeed(userID string) error {
+	_, err := s.DB.Exec(`DELETE FROM calendar_feeds WHERE user_id = ?;`, userID)
+	return err
+}
@@ -0,0 +1,198 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+const customFieldColumns = `id, entity_type, key, label, field_type, options, required, position, created_at, updated_at`
+
+func (s *Store) SaveCustomField(f models.CustomField) error {
+	options, err := json.Marshal(f.Options)
+	if err != nil {
+		return err
+	}
+	required := 0
+	if f.Required {
+		required = 1
+	}
+	_, err = s.DB.Exec(
+		`INSERT OR REPLACE INTO custom_fields (`+customFieldColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		f.ID,
+		f.EntityType,
+		f.Key,
+		f.Label,
+		string(f.Type),
+		string(options),
+		required,
+		f.Position,
+		f.CreatedAt.Unix(),
+		f.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+// LoadCustomFields returns the fields of one entity type, or of all types
+// when entityType is empty, in display order.
+func (s *Store) LoadCustomFields(entityType string) ([]models.CustomField, error) {
+	rows, err := s.DB.Query(
+		`SELECT `+customFieldColumns+` FROM custom_fields
+		WHERE ? = '' OR entity_type = ?
+		ORDER BY entity_type, position, created_at;`,
+		entityType, entityType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fields := make([]models.CustomField, 0)
+	for rows.Next() {
+		f, err := scanCustomField(rows)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+	return fields, rows.Err()
+}
+
+func (s *Store) FindCustomFieldByID(id string) (models.CustomField, bool, error) {
+	f, err := scanCustomField(s.DB.QueryRow(`SELECT `+customFieldColumns+` FROM custom_fields WHERE id = ?;`, id))
+	if err == sql.ErrNoRows {
+		return models.CustomField{}, false, nil
+	}
+	if err != nil {
+		return models.CustomField{}, false, err
+	}
+	return f, true, nil
+}
+
+func scanCustomField(row rowScanner) (models.CustomField, error) {
+	var f models.CustomField
+	var fieldType, options string
+	var required int
+	var createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&f.ID,
+		&f.EntityType,
+		&f.Key,
+		&f.Label,
+		&fieldType,
+		&options,
+		&required,
+		&f.Position,
+		&createdUnix,
+		&updatedUnix,
+	); err != nil {
+		return models.CustomField{}, err
+	}
+	f.Type = models.CustomFieldType(fieldType)
+	f.Options = make([]string, 0)
+	_ = json.Unmarshal([]byte(options), &f.Options)
+	f.Required = required != 0
+	f.CreatedAt = time.Unix(createdUnix, 0)
+	f.UpdatedAt = time.Unix(updatedUnix, 0)
+	return f, nil
+}
+
+// DeleteCustomField removes a field and every value stored for it.
+func (s *Store) DeleteCustomField(id string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM custom_field_values WHERE field_id = ?;`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM custom_fields WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReorderCustomFields numbers the given fields of entityType in order.
+func (s *Store) ReorderCustomFields(entityType string, ids []string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now().Unix()
+	for i, id := range ids {
+		if _, err = tx.Exec(`UPDATE custom_fields SET position = ?, updated_at = ? WHERE id = ? AND entity_type = ?;`, i+1, now, id, entityType); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// LoadCustomFieldValues returns the stored values of an entity type as
+// entity ID -> field ID -> value.
+func (s *Store) LoadCustomFieldValues(entityType string) (map[string]map[string]string, error) {
+	rows, err := s.DB.Query(`SELECT entity_id, field_id, value FROM custom_field_values WHERE entity_type = ?;`, entityType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]map[string]string)
+	for rows.Next() {
+		var entityID, fieldID, value string
+		if err := rows.Scan(&entityID, &fieldID, &value); err != nil {
+			return nil, err
+		}
+		if out[entityID] == nil {
+			out[entityID] = make(map[string]string)
+		}
+		out[entityID][fieldID] = value
+	}
+	return out, rows.Err()
+}
+
+// SaveCustomFieldValues sets the given field values (by field ID) of one
+// entity; an empty value clears the field. Fields not mentioned are kept.
+func (s *Store) SaveCustomFieldValues(entityType, entityID string, values map[string]string) (err error) {
+	if len(values) == 0 {
+		return nil
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for fieldID, value := range values {
+		if value == "" {
+			_, err = tx.Exec(`DELETE FROM custom_field_values WHERE entity_type = ? AND entity_id = ? AND field_id = ?;`, entityType, entityID, fieldID)
+		} else {
+			_, err = tx.Exec(
+				`INSERT OR REPLACE INTO custom_field_values (entity_type, entity_id, field_id, value) VALUES (?, ?, ?, ?);`,
+				entityType, entityID, fieldID, value,
+			)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -44,6 +44,17 @@ func (s *Store) Close() error {
 }
 
 func (s *Store) migrate() error {
+	var hadProjectMembers, hadDomains, hadDealOwners int
+	if err := s.DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'project_members';`).Scan(&hadProjectMembers); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	if err := s.DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'domains';`).Scan(&hadDomains); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	if err := s.DB.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('deals') WHERE name = 'owner_user_id';`).Scan(&hadDealOwners); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
 	stmts := []string{
 		`CREATE TABLE IF NOT EXISTS users (
 			id TEXT PRIMARY KEY,
@@ -184,6 +195,8 @@ func (s *Store) migrate() error {
 				due_date INTEGER NOT NULL DEFAULT 0,
 			estimated_hours INTEGER NOT NULL DEFAULT 0,
 			actual_hours INTEGER NOT NULL DEFAULT 0,
+			column_id TEXT NOT NULL DEFAULT '',
+			position REAL NOT NULL DEFAULT 0,
 			created_at INTEGER NOT NULL,
 			updated_at INTEGER NOT NULL
 		);`,
@@ -242,6 +255,209 @@ func (s *Store) migrate() error {
 			created_at INTEGER NOT NULL,
 			updated_at INTEGER NOT NULL
 		);`,
+		`CREATE TABLE IF NOT EXISTS bank_transactions (
+			id TEXT PRIMARY KEY,
+			external_id TEXT NOT NULL UNIQUE,
+			source TEXT NOT NULL DEFAULT '',
+			booked_at INTEGER NOT NULL DEFAULT 0,
+			amount REAL NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'EUR',
+			counterparty TEXT NOT NULL DEFAULT '',
+			reference TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'unmatched',
+			payment_id TEXT NOT NULL DEFAULT '',
+			matched_at INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS billing_plans (
+			id TEXT PRIMARY KEY,
+			deal_id TEXT NOT NULL,
+			project_id TEXT NOT NULL DEFAULT '',
+			title TEXT NOT NULL DEFAULT '',
+			interval TEXT NOT NULL DEFAULT 'monthly',
+			amount REAL NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'EUR',
+			start_at INTEGER NOT NULL DEFAULT 0,
+			end_at INTEGER NOT NULL DEFAULT 0,
+			active INTEGER NOT NULL DEFAULT 1,
+			notes TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS billing_plan_payments (
+			plan_id TEXT NOT NULL,
+			period_at INTEGER NOT NULL,
+			payment_id TEXT NOT NULL,
+			PRIMARY KEY (plan_id, period_at)
+		);`,
+		`CREATE TABLE IF NOT EXISTS domains (
+			id TEXT PRIMARY KEY,
+			organization_id TEXT NOT NULL DEFAULT '',
+			deal_id TEXT NOT NULL DEFAULT '',
+			name TEXT NOT NULL UNIQUE,
+			registrar TEXT NOT NULL DEFAULT '',
+			acquired_at INTEGER NOT NULL DEFAULT 0,
+			expires_at INTEGER NOT NULL DEFAULT 0,
+			auto_renew INTEGER NOT NULL DEFAULT 0,
+			renewal_price REAL NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'EUR',
+			dns_notes TEXT NOT NULL DEFAULT '',
+			last_checked_at INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS domain_renewal_payments (
+			domain_id TEXT NOT NULL,
+			expires_at INTEGER NOT NULL,
+			payment_id TEXT NOT NULL,
+			PRIMARY KEY (domain_id, expires_at)
+		);`,
+		`CREATE TABLE IF NOT EXISTS exchange_rates (
+			id TEXT PRIMARY KEY,
+			date INTEGER NOT NULL,
+			base TEXT NOT NULL,
+			quote TEXT NOT NULL,
+			rate REAL NOT NULL,
+			source TEXT NOT NULL DEFAULT 'manual',
+			created_at INTEGER NOT NULL,
+			UNIQUE(date, base, quote)
+		);`,
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id TEXT PRIMARY KEY,
+			user_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			title TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL DEFAULT '',
+			entity_type TEXT NOT NULL DEFAULT '',
+			entity_id TEXT NOT NULL DEFAULT '',
+			dedupe_key TEXT UNIQUE,
+			read_at INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS history (
+			id TEXT PRIMARY KEY,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			field TEXT NOT NULL,
+			old_value TEXT NOT NULL DEFAULT '',
+			new_value TEXT NOT NULL DEFAULT '',
+			user_id TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS custom_fields (
+			id TEXT PRIMARY KEY,
+			entity_type TEXT NOT NULL,
+			key TEXT NOT NULL,
+			label TEXT NOT NULL,
+			field_type TEXT NOT NULL,
+			options TEXT NOT NULL DEFAULT '[]',
+			required INTEGER NOT NULL DEFAULT 0,
+			position INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			UNIQUE(entity_type, key)
+		);`,
+		`CREATE TABLE IF NOT EXISTS custom_field_values (
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			field_id TEXT NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (entity_type, entity_id, field_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS labels (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+			color TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS taggings (
+			label_id TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (label_id, entity_type, entity_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS notes (
+			id TEXT PRIMARY KEY,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			author_user_id TEXT NOT NULL DEFAULT '',
+			body TEXT NOT NULL,
+			mentions TEXT NOT NULL DEFAULT '[]',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS project_members (
+			project_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL,
+			PRIMARY KEY (project_id, user_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS task_columns (
+			id TEXT PRIMARY KEY,
+			project_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			color TEXT NOT NULL,
+			category TEXT NOT NULL,
+			position INTEGER NOT NULL DEFAULT 0,
+			wip_limit INTEGER NOT NULL DEFAULT 0,
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS deal_contacts (
+			deal_id TEXT NOT NULL,
+			contact_id TEXT NOT NULL,
+			role TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			PRIMARY KEY (deal_id, contact_id, role)
+		);`,
+		`CREATE TABLE IF NOT EXISTS addresses (
+			id TEXT PRIMARY KEY,
+			organization_id TEXT NOT NULL,
+			address_type TEXT NOT NULL,
+			street TEXT NOT NULL DEFAULT '',
+			postal_code TEXT NOT NULL DEFAULT '',
+			city TEXT NOT NULL DEFAULT '',
+			province TEXT NOT NULL DEFAULT '',
+			country TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS blobs (
+			key TEXT PRIMARY KEY,
+			size INTEGER NOT NULL,
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS attachments (
+			id TEXT PRIMARY KEY,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			blob_key TEXT NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			uploaded_by_user_id TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS note_revisions (
+			id TEXT PRIMARY KEY,
+			note_id TEXT NOT NULL,
+			body TEXT NOT NULL,
+			edited_by_user_id TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL
+		);`,
+		`CREATE TABLE IF NOT EXISTS calendar_feeds (
+			user_id TEXT PRIMARY KEY,
+			token TEXT NOT NULL UNIQUE,
+			categories TEXT NOT NULL DEFAULT '',
+			created_at INTEGER NOT NULL,
+			updated_at INTEGER NOT NULL
+		);`,
 	}
 	for _, stmt := range stmts {
 		if _, err := s.DB.Exec(stmt); err != nil {
@@ -250,6 +466,18 @@ func (s *Store) migrate() error {
 	}
 
 	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_payments_deal_id ON payments(deal_id);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_bank_transactions_status ON bank_transactions(status);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_history_entity ON history(entity_type, entity_id, created_at);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_custom_field_values_field ON custom_field_values(field_id);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_taggings_entity ON taggings(entity_type, entity_id);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_notes_entity ON notes(entity_type, entity_id, created_at);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_note_revisions_note ON note_revisions(note_id, created_at);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_deal_contacts_contact ON deal_contacts(contact_id);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_project_members_user ON project_members(user_id);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_task_columns_project ON task_columns(project_id, position);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_attachments_entity ON attachments(entity_type, entity_id, created_at);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_attachments_blob ON attachments(blob_key);`)
 
 	// Forward-only compatibility for older DBs.
 	_, _ = s.DB.Exec(`ALTER TABLE users ADD COLUMN username TEXT NOT NULL DEFAULT '';`)
@@ -269,7 +497,54 @@ func (s *Store) migrate() error {
 	_, _ = s.DB.Exec(`ALTER TABLE deals ADD COLUMN work_type TEXT NOT NULL DEFAULT '';`)
 	_, _ = s.DB.Exec(`ALTER TABLE deals ADD COLUMN work_closed_at INTEGER NOT NULL DEFAULT 0;`)
 	_, _ = s.DB.Exec(`ALTER TABLE tasks ADD COLUMN owner_user_id TEXT NOT NULL DEFAULT '';`)
-	return nil
+	_, _ = s.DB.Exec(`ALTER TABLE payments ADD COLUMN reporting_amount REAL NOT NULL DEFAULT 0;`)
+	_, _ = s.DB.Exec(`ALTER TABLE payments ADD COLUMN reporting_currency TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE interactions ADD COLUMN external_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_interactions_external_id ON interactions(external_id) WHERE external_id <> '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE interactions ADD COLUMN thread_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE organizations ADD COLUMN parent_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE organizations ADD COLUMN owner_user_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE deals ADD COLUMN owner_user_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE deals ADD COLUMN shared INTEGER NOT NULL DEFAULT 0;`)
+	_, _ = s.DB.Exec(`ALTER TABLE projects ADD COLUMN owner_user_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE tasks ADD COLUMN column_id TEXT NOT NULL DEFAULT '';`)
+	_, _ = s.DB.Exec(`ALTER TABLE tasks ADD COLUMN position REAL NOT NULL DEFAULT 0;`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_organizations_parent_id ON organizations(parent_id);`)
+	_, _ = s.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_interactions_thread_id ON interactions(thread_id);`)
+
+	// Deals from before owners existed go to the first admin, who can hand
+	// them on; salespeople only see the deals they own or that are shared.
+	if hadDealOwners == 0 {
+		if _, err := s.DB.Exec(`UPDATE deals SET owner_user_id = coalesce(
+			(SELECT id FROM users WHERE role = ? ORDER BY created_at, id LIMIT 1), '') WHERE owner_user_id = '';`, string(models.RoleAdmin)); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+	}
+
+	// Lift the domains deals carried into the domains table once, so domains
+	// deleted later stay deleted.
+	if hadDomains == 0 {
+		if err := s.backfillDealDomains(); err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+	}
+
+	// Before project members existed, working on a project meant owning it
+	// or one of its tasks; seed the members from that once, so members
+	// removed later stay removed.
+	if hadProjectMembers == 0 {
+		now := time.Now().Unix()
+		_, _ = s.DB.Exec(`INSERT OR IGNORE INTO project_members (project_id, user_id, role, created_at, updated_at)
+			SELECT id, owner_user_id, 'lead', ?, ? FROM projects WHERE owner_user_id <> '';`, now, now)
+		_, _ = s.DB.Exec(`INSERT OR IGNORE INTO project_members (project_id, user_id, role, created_at, updated_at)
+			SELECT DISTINCT project_id, owner_user_id, 'contributor', ?, ? FROM tasks
+			WHERE owner_user_id <> '' AND owner_user_id IN (SELECT id FROM users);`, now, now)
+	}
+	// Projects from before boards existed get the default one.
+	if err := addDefaultTaskColumns(s.DB, ""); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	return s.migrateSearch()
 }
 
 func (s *Store) SeedIfNeeded() error {
@@ -457,6 +732,7 @@ func (s *Store) SeedIfNeeded() error {
 		OrganizationID:  org.ID,
 		ContactID:       contact.ID,
 		PipelineStageID: defaultStageID,
+		OwnerUserID:     seededUser.ID,
 		Title:           "Website refresh",
 		Description:     "Design + build marketing site refresh.",
 		Value:           12000,
@@ -511,11 +787,17 @@ func (s *Store) SeedIfNeeded() error {
 }
 
 func (s *Store) SaveOrganization(org models.Organization) error {
-	_, err := s.DB.Exec(
+	return saveOrganization(s.DB, org)
+}
+
+func saveOrganization(ex execer, org models.Organization) error {
+	_, err := ex.Exec(
 		`INSERT OR REPLACE INTO organizations
-		(id, name, industry, website, email, phone, billing_email, tax_id, address, city, country, notes, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		(id, parent_id, owner_user_id, name, industry, website, email, phone, billing_email, tax_id, address, city, country, notes, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		org.ID,
+		org.ParentID,
+		org.OwnerUserID,
 		org.Name,
 		org.Industry,
 		org.Website,
@@ -534,7 +816,7 @@ func (s *Store) SaveOrganization(org models.Organization) error {
 }
 
 func (s *Store) LoadOrganizations() ([]models.Organization, error) {
-	rows, err := s.DB.Query(`SELECT id, name, industry, website, email, phone, billing_email, tax_id, address, city, country, notes, created_at, updated_at FROM organizations ORDER BY created_at DESC;`)
+	rows, err := s.DB.Query(`SELECT id, parent_id, owner_user_id, name, industry, website, email, phone, billing_email, tax_id, address, city, country, notes, created_at, updated_at FROM organizations ORDER BY created_at DESC;`)
 	if err != nil {
 		return nil, err
 	}
@@ -546,6 +828,8 @@ func (s *Store) LoadOrganizations() ([]models.Organization, error) {
 		var createdUnix, updatedUnix int64
 		if err := rows.Scan(
 			&org.ID,
+			&org.ParentID,
+			&org.OwnerUserID,
 			&org.Name,
 			&org.Industry,
 			&org.Website,
@@ -607,6 +891,12 @@ func (s *Store) DeleteOrganization(orgID string) error {
 	if _, err = tx.Exec(`DELETE FROM payments WHERE deal_id IN (SELECT id FROM deals WHERE organization_id = ?);`, orgID); err != nil {
 		return err
 	}
+	if _, err = tx.Exec(`DELETE FROM billing_plan_payments WHERE plan_id IN (SELECT id FROM billing_plans WHERE deal_id IN (SELECT id FROM deals WHERE organization_id = ?));`, orgID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM billing_plans WHERE deal_id IN (SELECT id FROM deals WHERE organization_id = ?);`, orgID); err != nil {
+		return err
+	}
 
 	// Delete interactions referencing the org directly.
 	if _, err = tx.Exec(`DELETE FROM interactions WHERE organization_id = ?;`, orgID); err != nil {
@@ -614,26 +904,49 @@ func (s *Store) DeleteOrganization(orgID string) error {
 	}
 
 	// Delete deals, contacts, org.
+	if _, err = tx.Exec(`DELETE FROM domain_renewal_payments WHERE domain_id IN (SELECT id FROM domains WHERE organization_id = ?);`, orgID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM domains WHERE organization_id = ?;`, orgID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM history WHERE entity_type = 'deal' AND entity_id IN (SELECT id FROM deals WHERE organization_id = ?);`, orgID); err != nil {
+		return err
+	}
 	if _, err = tx.Exec(`DELETE FROM deals WHERE organization_id = ?;`, orgID); err != nil {
 		return err
 	}
 	if _, err = tx.Exec(`DELETE FROM contacts WHERE organization_id = ?;`, orgID); err != nil {
 		return err
 	}
+	// Subsidiaries move up to the deleted organization's own parent.
+	if _, err = tx.Exec(`UPDATE organizations SET parent_id = (SELECT parent_id FROM organizations WHERE id = ?) WHERE parent_id = ?;`, orgID, orgID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM addresses WHERE organization_id = ?;`, orgID); err != nil {
+		return err
+	}
 	if _, err = tx.Exec(`DELETE FROM organizations WHERE id = ?;`, orgID); err != nil {
 		return err
 	}
 
+	if err = deleteOrphans(tx); err != nil {
+		return err
+	}
 	err = tx.Commit()
 	return err
 }
 
 func (s *Store) SaveContact(c models.Contact) error {
+	return saveContact(s.DB, c)
+}
+
+func saveContact(ex execer, c models.Contact) error {
 	primary := 0
 	if c.PrimaryContact {
 		primary = 1
 	}
-	_, err := s.DB.Exec(
+	_, err := ex.Exec(
 		`INSERT OR REPLACE INTO contacts
 		(id, organization_id, first_name, last_name, job_title, email, phone, mobile, linkedin_url, notes, primary_contact, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
@@ -726,6 +1039,18 @@ func (s *Store) DeleteContact(contactID string) error {
 	if _, err = tx.Exec(`DELETE FROM payments WHERE deal_id IN (SELECT id FROM deals WHERE contact_id = ?);`, contactID); err != nil {
 		return err
 	}
+	if _, err = tx.Exec(`DELETE FROM billing_plan_payments WHERE plan_id IN (SELECT id FROM billing_plans WHERE deal_id IN (SELECT id FROM deals WHERE contact_id = ?));`, contactID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM billing_plans WHERE deal_id IN (SELECT id FROM deals WHERE contact_id = ?);`, contactID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE domains SET deal_id = '' WHERE deal_id IN (SELECT id FROM deals WHERE contact_id = ?);`, contactID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM history WHERE entity_type = 'deal' AND entity_id IN (SELECT id FROM deals WHERE contact_id = ?);`, contactID); err != nil {
+		return err
+	}
 	if _, err = tx.Exec(`DELETE FROM deals WHERE contact_id = ?;`, contactID); err != nil {
 		return err
 	}
@@ -734,6 +1059,9 @@ func (s *Store) DeleteContact(contactID string) error {
 		return err
 	}
 
+	if err = deleteOrphans(tx); err != nil {
+		return err
+	}
 	err = tx.Commit()
 	return err
 }
@@ -763,11 +1091,13 @@ func (s *Store) SaveDeal(d models.Deal) error {
 		(id, organization_id, contact_id, pipeline_stage_id, title, description,
 		 domain, domain_acquired_at, domain_expires_at, domain_cost,
 		 deposit, costs, taxes, net_total, share_gil, share_ric, work_type, work_closed_at,
-		 value, currency, expected_close_at, status, probability, source, notes, lost_reason, created_at, updated_at)
+		 value, currency, expected_close_at, status, probability, source, notes, lost_reason, created_at, updated_at,
+		 owner_user_id, shared)
 		VALUES (?, ?, ?, ?, ?, ?,
 		        ?, ?, ?, ?,
 		        ?, ?, ?, ?, ?, ?, ?, ?,
-		        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		        ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+		        ?, ?);`,
 		d.ID,
 		d.OrganizationID,
 		d.ContactID,
@@ -796,17 +1126,29 @@ func (s *Store) SaveDeal(d models.Deal) error {
 		d.LostReason,
 		d.CreatedAt.Unix(),
 		d.UpdatedAt.Unix(),
+		d.OwnerUserID,
+		d.Shared,
 	)
 	return err
 }
 
-func (s *Store) LoadDeals() ([]models.Deal, error) {
-	rows, err := s.DB.Query(`SELECT
-		id, organization_id, contact_id, pipeline_stage_id, title, description,
+const dealColumns = `id, organization_id, contact_id, pipeline_stage_id, title, description,
 		domain, domain_acquired_at, domain_expires_at, domain_cost,
 		deposit, costs, taxes, net_total, share_gil, share_ric, work_type, work_closed_at,
-		value, currency, expected_close_at, status, probability, source, notes, lost_reason, created_at, updated_at
-		FROM deals ORDER BY created_at DESC;`)
+		value, currency, expected_close_at, status, probability, source, notes, lost_reason, created_at, updated_at,
+		owner_user_id, shared`
+
+func (s *Store) LoadDeals() ([]models.Deal, error) {
+	return s.loadDeals("")
+}
+
+// loadDeals returns the deals matching where, a SQL condition with its
+// arguments, or all deals when where is empty.
+func (s *Store) loadDeals(where string, args ...any) ([]models.Deal, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT `+dealColumns+` FROM deals`+where+` ORDER BY created_at DESC;`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -814,69 +1156,91 @@ func (s *Store) LoadDeals() ([]models.Deal, error) {
 
 	deals := make([]models.Deal, 0)
 	for rows.Next() {
-		var d models.Deal
-		var domainAcquiredUnix int64
-		var domainExpiresUnix int64
-		var workClosedUnix int64
-		var expectedCloseUnix int64
-		var status string
-		var createdUnix, updatedUnix int64
-		if err := rows.Scan(
-			&d.ID,
-			&d.OrganizationID,
-			&d.ContactID,
-			&d.PipelineStageID,
-			&d.Title,
-			&d.Description,
-			&d.Domain,
-			&domainAcquiredUnix,
-			&domainExpiresUnix,
-			&d.DomainCost,
-			&d.Deposit,
-			&d.Costs,
-			&d.Taxes,
-			&d.NetTotal,
-			&d.ShareGil,
-			&d.ShareRic,
-			&d.WorkType,
-			&workClosedUnix,
-			&d.Value,
-			&d.Currency,
-			&expectedCloseUnix,
-			&status,
-			&d.Probability,
-			&d.Source,
-			&d.Notes,
-			&d.LostReason,
-			&createdUnix,
-			&updatedUnix,
-		); err != nil {
+		d, err := scanDeal(rows)
+		if err != nil {
 			return nil, err
 		}
-		if domainAcquiredUnix > 0 {
-			t := time.Unix(domainAcquiredUnix, 0)
-			d.DomainAcquiredAt = &t
-		}
-		if domainExpiresUnix > 0 {
-			t := time.Unix(domainExpiresUnix, 0)
-			d.DomainExpiresAt = &t
-		}
-		if workClosedUnix > 0 {
-			t := time.Unix(workClosedUnix, 0)
-			d.WorkClosedAt = &t
-		}
-		if expectedCloseUnix > 0 {
-			t := time.Unix(expectedCloseUnix, 0)
-			d.ExpectedCloseAt = &t
-		}
-		d.Status = models.DealStatus(status)
-		d.CreatedAt = time.Unix(createdUnix, 0)
-		d.UpdatedAt = time.Unix(updatedUnix, 0)
 		deals = append(deals, d)
 	}
 	return deals, rows.Err()
 }
 
+func (s *Store) FindDealByID(id string) (models.Deal, bool, error) {
+	row := s.DB.QueryRow(`SELECT `+dealColumns+` FROM deals WHERE id = ? LIMIT 1;`, id)
+	d, err := scanDeal(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Deal{}, false, nil
+		}
+		return models.Deal{}, false, err
+	}
+	return d, true, nil
+}
+
+func scanDeal(row rowScanner) (models.Deal, error) {
+	var d models.Deal
+	var domainAcquiredUnix int64
+	var domainExpiresUnix int64
+	var workClosedUnix int64
+	var expectedCloseUnix int64
+	var status string
+	var createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&d.ID,
+		&d.OrganizationID,
+		&d.ContactID,
+		&d.PipelineStageID,
+		&d.Title,
+		&d.Description,
+		&d.Domain,
+		&domainAcquiredUnix,
+		&domainExpiresUnix,
+		&d.DomainCost,
+		&d.Deposit,
+		&d.Costs,
+		&d.Taxes,
+		&d.NetTotal,
+		&d.ShareGil,
+		&d.ShareRic,
+		&d.WorkType,
+		&workClosedUnix,
+		&d.Value,
+		&d.Currency,
+		&expectedCloseUnix,
+		&status,
+		&d.Probability,
+		&d.Source,
+		&d.Notes,
+		&d.LostReason,
+		&createdUnix,
+		&updatedUnix,
+		&d.OwnerUserID,
+		&d.Shared,
+	); err != nil {
+		return models.Deal{}, err
+	}
+	if domainAcquiredUnix > 0 {
+		t := time.Unix(domainAcquiredUnix, 0)
+		d.DomainAcquiredAt = &t
+	}
+	if domainExpiresUnix > 0 {
+		t := time.Unix(domainExpiresUnix, 0)
+		d.DomainExpiresAt = &t
+	}
+	if workClosedUnix > 0 {
+		t := time.Unix(workClosedUnix, 0)
+		d.WorkClosedAt = &t
+	}
+	if expectedCloseUnix > 0 {
+		t := time.Unix(expectedCloseUnix, 0)
+		d.ExpectedCloseAt = &t
+	}
+	d.Status = models.DealStatus(status)
+	d.CreatedAt = time.Unix(createdUnix, 0)
+	d.UpdatedAt = time.Unix(updatedUnix, 0)
+	return d, nil
+}
+
 func (s *Store) DeleteDeal(dealID string) error {
 	tx, err := s.DB.Begin()
 	if err != nil {
@@ -903,18 +1267,34 @@ func (s *Store) DeleteDeal(dealID string) error {
 	if _, err = tx.Exec(`DELETE FROM payments WHERE deal_id = ?;`, dealID); err != nil {
 		return err
 	}
+	if _, err = tx.Exec(`DELETE FROM billing_plan_payments WHERE plan_id IN (SELECT id FROM billing_plans WHERE deal_id = ?);`, dealID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM billing_plans WHERE deal_id = ?;`, dealID); err != nil {
+		return err
+	}
 	if _, err = tx.Exec(`DELETE FROM interactions WHERE deal_id = ?;`, dealID); err != nil {
 		return err
 	}
+	// Domains belong to the client, not the deal; keep them.
+	if _, err = tx.Exec(`UPDATE domains SET deal_id = '' WHERE deal_id = ?;`, dealID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM history WHERE entity_type = 'deal' AND entity_id = ?;`, dealID); err != nil {
+		return err
+	}
 	if _, err = tx.Exec(`DELETE FROM deals WHERE id = ?;`, dealID); err != nil {
 		return err
 	}
 
+	if err = deleteOrphans(tx); err != nil {
+		return err
+	}
 	err = tx.Commit()
 	return err
 }
 
-func (s *Store) SaveProject(p models.Project) error {
+func (s *Store) SaveProject(p models.Project) (err error) {
 	startUnix := int64(0)
 	if p.StartDate != nil {
 		startUnix = p.StartDate.Unix()
@@ -928,12 +1308,23 @@ func (s *Store) SaveProject(p models.Project) error {
 		actualUnix = p.ActualEndDate.Unix()
 	}
 
-	_, err := s.DB.Exec(
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.Exec(
 		`INSERT OR REPLACE INTO projects
-		(id, deal_id, name, description, code, status, start_date, target_end_date, actual_end_date, budget, currency, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		(id, deal_id, owner_user_id, name, description, code, status, start_date, target_end_date, actual_end_date, budget, currency, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		p.ID,
 		p.DealID,
+		p.OwnerUserID,
 		p.Name,
 		p.Description,
 		p.Code,
@@ -946,11 +1337,25 @@ func (s *Store) SaveProject(p models.Project) error {
 		p.CreatedAt.Unix(),
 		p.UpdatedAt.Unix(),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	// New projects start with the default board.
+	if err = addDefaultTaskColumns(tx, `p.id = ?`, p.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *Store) LoadProjects() ([]models.Project, error) {
-	rows, err := s.DB.Query(`SELECT id, deal_id, name, description, code, status, start_date, target_end_date, actual_end_date, budget, currency, created_at, updated_at FROM projects ORDER BY created_at DESC;`)
+	return s.loadProjects("")
+}
+
+func (s *Store) loadProjects(where string, args ...any) ([]models.Project, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT id, deal_id, owner_user_id, name, description, code, status, start_date, target_end_date, actual_end_date, budget, currency, created_at, updated_at FROM projects`+where+` ORDER BY created_at DESC;`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -965,6 +1370,7 @@ func (s *Store) LoadProjects() ([]models.Project, error) {
 		if err := rows.Scan(
 			&p.ID,
 			&p.DealID,
+			&p.OwnerUserID,
 			&p.Name,
 			&p.Description,
 			&p.Code,
@@ -1013,22 +1419,37 @@ func (s *Store) DeleteProject(projectID string) error {
 	if _, err = tx.Exec(`DELETE FROM tasks WHERE project_id = ?;`, projectID); err != nil {
 		return err
 	}
+	if _, err = tx.Exec(`UPDATE billing_plans SET project_id = '' WHERE project_id = ?;`, projectID); err != nil {
+		return err
+	}
 	if _, err = tx.Exec(`DELETE FROM projects WHERE id = ?;`, projectID); err != nil {
 		return err
 	}
+	if err = deleteOrphans(tx); err != nil {
+		return err
+	}
 	err = tx.Commit()
 	return err
 }
 
 func (s *Store) SaveTask(t models.Task) error {
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO tasks (`+taskFields+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		taskValues(t)...,
+	)
+	return err
+}
+
+// taskFields are the columns SaveTask writes, in the order of taskValues.
+const taskFields = `id, project_id, owner_user_id, title, description, status, priority, due_date, estimated_hours, actual_hours, column_id, position, created_at, updated_at`
+
+func taskValues(t models.Task) []any {
 	dueUnix := int64(0)
 	if t.DueDate != nil {
 		dueUnix = t.DueDate.Unix()
 	}
-	_, err := s.DB.Exec(
-		`INSERT OR REPLACE INTO tasks
-		(id, project_id, owner_user_id, title, description, status, priority, due_date, estimated_hours, actual_hours, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+	return []any{
 		t.ID,
 		t.ProjectID,
 		t.OwnerUserID,
@@ -1039,14 +1460,30 @@ func (s *Store) SaveTask(t models.Task) error {
 		dueUnix,
 		t.EstimatedHours,
 		t.ActualHours,
+		t.ColumnID,
+		t.Position,
 		t.CreatedAt.Unix(),
 		t.UpdatedAt.Unix(),
-	)
-	return err
+	}
 }
 
 func (s *Store) LoadTasks() ([]models.Task, error) {
-	rows, err := s.DB.Query(`SELECT id, project_id, owner_user_id, title, description, status, priority, due_date, estimated_hours, actual_hours, created_at, updated_at FROM tasks ORDER BY created_at DESC;`)
+	return s.loadTasks("")
+}
+
+func (s *Store) FindTask(id string) (models.Task, bool, error) {
+	tasks, err := s.loadTasks(`id = ?`, id)
+	if err != nil || len(tasks) == 0 {
+		return models.Task{}, false, err
+	}
+	return tasks[0], true, nil
+}
+
+func (s *Store) loadTasks(where string, args ...any) ([]models.Task, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT id, project_id, owner_user_id, title, description, status, priority, due_date, estimated_hours, actual_hours, column_id, position, created_at, updated_at FROM tasks`+where+` ORDER BY created_at DESC;`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1069,6 +1506,8 @@ func (s *Store) LoadTasks() ([]models.Task, error) {
 			&dueUnix,
 			&t.EstimatedHours,
 			&t.ActualHours,
+			&t.ColumnID,
+			&t.Position,
 			&createdUnix,
 			&updatedUnix,
 		); err != nil {
@@ -1086,11 +1525,92 @@ func (s *Store) LoadTasks() ([]models.Task, error) {
 	return tasks, rows.Err()
 }
 
-func (s *Store) DeleteTask(taskID string) error {
-	_, err := s.DB.Exec(`DELETE FROM tasks WHERE id = ?;`, taskID)
-	return err
+func (s *Store) DeleteTask(taskID string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM tasks WHERE id = ?;`, taskID); err != nil {
+		return err
+	}
+	if err = deleteOrphans(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func newID() string {
 	return fmt.Sprintf("%d", time.Now().UnixNano())
 }
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// entityTables maps the entity types used by custom fields, taggings and
+// history to their tables.
+var entityTables = map[string]string{
+	"organization": "organizations",
+	"contact":      "contacts",
+	"deal":         "deals",
+	"project":      "projects",
+	"task":         "tasks",
+	"quotation":    "quotations",
+}
+
+// deleteOrphans drops custom field values, taggings, notes, attachments,
+// deal contacts and project members whose entity no longer exists; the entity deletes call it so their
+// cascades need not list every table. Blobs left unreferenced are swept
+// separately.
+func deleteOrphans(ex execer) error {
+	for entityType, table := range entityTables {
+		for _, q := range []string{
+			`DELETE FROM custom_field_values WHERE entity_type = ? AND entity_id NOT IN (SELECT id FROM ` + table + `);`,
+			`DELETE FROM taggings WHERE entity_type = ? AND entity_id NOT IN (SELECT id FROM ` + table + `);`,
+			`DELETE FROM notes WHERE entity_type = ? AND entity_id NOT IN (SELECT id FROM ` + table + `);`,
+			`DELETE FROM attachments WHERE entity_type = ? AND entity_id NOT IN (SELECT id FROM ` + table + `);`,
+		} {
+			if _, err := ex.Exec(q, entityType); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := ex.Exec(`DELETE FROM deal_contacts WHERE deal_id NOT IN (SELECT id FROM deals) OR contact_id NOT IN (SELECT id FROM contacts);`); err != nil {
+		return err
+	}
+	if _, err := ex.Exec(`DELETE FROM project_members WHERE project_id NOT IN (SELECT id FROM projects);`); err != nil {
+		return err
+	}
+	if _, err := ex.Exec(`DELETE FROM task_columns WHERE project_id NOT IN (SELECT id FROM projects);`); err != nil {
+		return err
+	}
+	if _, err := ex.Exec(`UPDATE tasks SET column_id = '' WHERE column_id <> '' AND column_id NOT IN (SELECT id FROM task_columns);`); err != nil {
+		return err
+	}
+	if _, err := ex.Exec(`DELETE FROM billing_plan_payments WHERE plan_id NOT IN (SELECT id FROM billing_plans);`); err != nil {
+		return err
+	}
+	if _, err := ex.Exec(`DELETE FROM domain_renewal_payments WHERE domain_id NOT IN (SELECT id FROM domains);`); err != nil {
+		return err
+	}
+	_, err := ex.Exec(`DELETE FROM note_revisions WHERE note_id NOT IN (SELECT id FROM notes);`)
+	return err
+}
+
+func unixOrZero(t *time.Time) int64 {
+	if t == nil || t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// LoadDealContacts returns the contacts of every deal by deal ID, decision
+// makers first.
+func (s *Store) LoadDealContacts() (map[string][]models.DealContact, error) {
+	rows, err := s.DB.Query(
+		`SELECT deal_id, contact_id, role FROM deal_contacts
+		ORDER BY deal_id, CASE role WHEN 'decision_maker' THEN 0 WHEN 'billing' THEN 1 WHEN 'technical' THEN 2 WHEN 'influencer' THEN 3 ELSE 4 END, created_at, contact_id;`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]models.DealContact)
+	for rows.Next() {
+		var dc models.DealContact
+		var role string
+		if err := rows.Scan(&dc.DealID, &dc.ContactID, &role); err != nil {
+			return nil, err
+		}
+		dc.Role = models.DealContactRole(role)
+		out[dc.DealID] = append(out[dc.DealID], dc)
+	}
+	return out, rows.Err()
+}
+
+// SetDealContacts replaces the contacts of one deal.
+func (s *Store) SetDealContacts(dealID string, contacts []models.DealContact) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM deal_contacts WHERE deal_id = ?;`, dealID); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	for _, dc := range contacts {
+		if _, err = tx.Exec(
+			`INSERT OR IGNORE INTO deal_contacts (deal_id, contact_id, role, created_at) VALUES (?, ?, ?, ?);`,
+			dealID, dc.ContactID, string(dc.Role), now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
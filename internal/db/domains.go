@@ -0,0 +1,204 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"wemadeit/internal/domaininfo"
+	"wemadeit/internal/models"
+)
+
+// backfillDealDomains lifts the single domain deals used to carry into the
+// domains table, with ids derived from the deal. Names are normalized the
+// way saving a deal does, so the deal's next save finds its row instead of
+// adding a second one.
+func (s *Store) backfillDealDomains() error {
+	rows, err := s.DB.Query(`SELECT id, domain FROM deals WHERE TRIM(domain) <> '' ORDER BY created_at, id;`)
+	if err != nil {
+		return err
+	}
+	var dealIDs, names []string
+	for rows.Next() {
+		var id, domain string
+		if err := rows.Scan(&id, &domain); err != nil {
+			rows.Close()
+			return err
+		}
+		dealIDs = append(dealIDs, id)
+		names = append(names, domaininfo.Normalize(domain))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i, dealID := range dealIDs {
+		name := names[i]
+		if name == "" {
+			continue
+		}
+		if _, err := s.DB.Exec(`INSERT OR IGNORE INTO domains
+			(id, organization_id, deal_id, name, acquired_at, expires_at, renewal_price, currency, created_at, updated_at)
+			SELECT 'deal-' || id, organization_id, id, ?, domain_acquired_at, domain_expires_at, domain_cost, currency, created_at, updated_at
+			FROM deals WHERE id = ?;`, name, dealID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) SaveDomain(d models.Domain) error {
+	autoRenew := 0
+	if d.AutoRenew {
+		autoRenew = 1
+	}
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO domains
+		(id, organization_id, deal_id, name, registrar, acquired_at, expires_at, auto_renew, renewal_price, currency, dns_notes, last_checked_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		d.ID,
+		d.OrganizationID,
+		d.DealID,
+		strings.ToLower(strings.TrimSpace(d.Name)),
+		d.Registrar,
+		unixOrZero(d.AcquiredAt),
+		unixOrZero(d.ExpiresAt),
+		autoRenew,
+		d.RenewalPrice,
+		d.Currency,
+		d.DNSNotes,
+		unixOrZero(d.LastCheckedAt),
+		d.CreatedAt.Unix(),
+		d.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+const domainColumns = `id, organization_id, deal_id, name, registrar, acquired_at, expires_at, auto_renew, renewal_price, currency, dns_notes, last_checked_at, created_at, updated_at`
+
+func (s *Store) LoadDomains() ([]models.Domain, error) {
+	return s.loadDomains("", "name ASC")
+}
+
+// LoadDomainsExpiringBefore returns domains with a known expiry between from
+// and until, soonest first.
+func (s *Store) LoadDomainsExpiringBefore(from, until time.Time) ([]models.Domain, error) {
+	return s.loadDomains(`expires_at > 0 AND expires_at >= ? AND expires_at <= ?`, "expires_at ASC", from.Unix(), until.Unix())
+}
+
+func (s *Store) loadDomains(where, order string, args ...any) ([]models.Domain, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT `+domainColumns+` FROM domains`+where+` ORDER BY `+order+`;`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.Domain, 0)
+	for rows.Next() {
+		d, err := scanDomain(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) FindDomainByID(id string) (models.Domain, bool, error) {
+	row := s.DB.QueryRow(`SELECT `+domainColumns+` FROM domains WHERE id = ? LIMIT 1;`, id)
+	d, err := scanDomain(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Domain{}, false, nil
+		}
+		return models.Domain{}, false, err
+	}
+	return d, true, nil
+}
+
+func (s *Store) FindDomainByName(name string) (models.Domain, bool, error) {
+	row := s.DB.QueryRow(`SELECT `+domainColumns+` FROM domains WHERE name = ? LIMIT 1;`, strings.ToLower(strings.TrimSpace(name)))
+	d, err := scanDomain(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Domain{}, false, nil
+		}
+		return models.Domain{}, false, err
+	}
+	return d, true, nil
+}
+
+func (s *Store) DeleteDomain(id string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// A deal still carrying the domain would bring it back on its next save.
+	var name, dealID, dealDomain string
+	switch err = tx.QueryRow(`SELECT domains.name, domains.deal_id, deals.domain FROM domains JOIN deals ON deals.id = domains.deal_id WHERE domains.id = ?;`, id).Scan(&name, &dealID, &dealDomain); {
+	case err == sql.ErrNoRows:
+	case err != nil:
+		return err
+	case domaininfo.Normalize(dealDomain) == name:
+		if _, err = tx.Exec(`UPDATE deals SET domain = '', domain_acquired_at = 0, domain_expires_at = 0, domain_cost = 0 WHERE id = ?;`, dealID); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.Exec(`DELETE FROM domain_renewal_payments WHERE domain_id = ?;`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM domains WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func scanDomain(row rowScanner) (models.Domain, error) {
+	var d models.Domain
+	var autoRenew int
+	var acquiredUnix, expiresUnix, checkedUnix, createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&d.ID,
+		&d.OrganizationID,
+		&d.DealID,
+		&d.Name,
+		&d.Registrar,
+		&acquiredUnix,
+		&expiresUnix,
+		&autoRenew,
+		&d.RenewalPrice,
+		&d.Currency,
+		&d.DNSNotes,
+		&checkedUnix,
+		&createdUnix,
+		&updatedUnix,
+	); err != nil {
+		return models.Domain{}, err
+	}
+	if acquiredUnix > 0 {
+		t := time.Unix(acquiredUnix, 0)
+		d.AcquiredAt = &t
+	}
+	if expiresUnix > 0 {
+		t := time.Unix(expiresUnix, 0)
+		d.ExpiresAt = &t
+	}
+	if checkedUnix > 0 {
+		t := time.Unix(checkedUnix, 0)
+		d.LastCheckedAt = &t
+	}
+	d.AutoRenew = autoRenew != 0
+	d.CreatedAt = time.Unix(createdUnix, 0)
+	d.UpdatedAt = time.Unix(updatedUnix, 0)
+	return d, nil
+}
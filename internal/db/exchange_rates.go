@@ -0,0 +1,103 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// SaveExchangeRate upserts a rate; an existing rate for the same day and pair
+// is replaced.
+func (s *Store) SaveExchangeRate(r models.ExchangeRate) error {
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO exchange_rates (id, date, base, quote, rate, source, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?);`,
+		r.ID,
+		r.Date.Unix(),
+		r.Base,
+		r.Quote,
+		r.Rate,
+		r.Source,
+		r.CreatedAt.Unix(),
+	)
+	return err
+}
+
+// ImportExchangeRates upserts rates in one transaction and reports how many
+// rows were written.
+func (s *Store) ImportExchangeRates(rates []models.ExchangeRate) (n int, err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	stmt, err := tx.Prepare(
+		`INSERT OR REPLACE INTO exchange_rates (id, date, base, quote, rate, source, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?);`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, r := range rates {
+		if _, err = stmt.Exec(r.ID, r.Date.Unix(), r.Base, r.Quote, r.Rate, r.Source, r.CreatedAt.Unix()); err != nil {
+			return 0, err
+		}
+		n++
+	}
+	err = tx.Commit()
+	return n, err
+}
+
+func (s *Store) LoadExchangeRates() ([]models.ExchangeRate, error) {
+	rows, err := s.DB.Query(`SELECT id, date, base, quote, rate, source, created_at FROM exchange_rates ORDER BY date DESC, base ASC, quote ASC;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.ExchangeRate, 0)
+	for rows.Next() {
+		r, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) FindExchangeRateByID(id string) (models.ExchangeRate, bool, error) {
+	row := s.DB.QueryRow(`SELECT id, date, base, quote, rate, source, created_at FROM exchange_rates WHERE id = ? LIMIT 1;`, id)
+	r, err := scanExchangeRate(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.ExchangeRate{}, false, nil
+		}
+		return models.ExchangeRate{}, false, err
+	}
+	return r, true, nil
+}
+
+func (s *Store) DeleteExchangeRate(id string) error {
+	_, err := s.DB.Exec(`DELETE FROM exchange_rates WHERE id = ?;`, id)
+	return err
+}
+
+func scanExchangeRate(row rowScanner) (models.ExchangeRate, error) {
+	var r models.ExchangeRate
+	var dateUnix, createdUnix int64
+	if err := row.Scan(&r.ID, &dateUnix, &r.Base, &r.Quote, &r.Rate, &r.Source, &createdUnix); err != nil {
+		return models.ExchangeRate{}, err
+	}
+	r.Date = time.Unix(dateUnix, 0).UTC()
+	r.CreatedAt = time.Unix(createdUnix, 0)
+	return r, nil
+}
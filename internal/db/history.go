@@ -0,0 +1,86 @@
+package db
+
+import (
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+func (s *Store) AddHistory(entries ...models.HistoryEntry) (err error) {
+	if len(entries) == 0 {
+		return nil
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = insertHistory(tx, entries); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertHistory(ex execer, entries []models.HistoryEntry) error {
+	for _, e := range entries {
+		if _, err := ex.Exec(
+			`INSERT INTO history (id, entity_type, entity_id, field, old_value, new_value, user_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+			e.ID,
+			e.EntityType,
+			e.EntityID,
+			e.Field,
+			e.OldValue,
+			e.NewValue,
+			e.UserID,
+			e.CreatedAt.Unix(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadHistory returns the changes recorded for one entity, oldest first.
+func (s *Store) LoadHistory(entityType, entityID string) ([]models.HistoryEntry, error) {
+	return s.queryHistory(
+		`SELECT id, entity_type, entity_id, field, old_value, new_value, user_id, created_at
+		FROM history WHERE entity_type = ? AND entity_id = ? ORDER BY created_at ASC, id ASC;`,
+		entityType, entityID,
+	)
+}
+
+// LoadHistoryByField returns every recorded change of one field across all
+// entities of a type, oldest first.
+func (s *Store) LoadHistoryByField(entityType, field string) ([]models.HistoryEntry, error) {
+	return s.queryHistory(
+		`SELECT id, entity_type, entity_id, field, old_value, new_value, user_id, created_at
+		FROM history WHERE entity_type = ? AND field = ? ORDER BY created_at ASC, id ASC;`,
+		entityType, field,
+	)
+}
+
+func (s *Store) queryHistory(query string, args ...any) ([]models.HistoryEntry, error) {
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.HistoryEntry, 0)
+	for rows.Next() {
+		var e models.HistoryEntry
+		var createdUnix int64
+		if err := rows.Scan(&e.ID, &e.EntityType, &e.EntityID, &e.Field, &e.OldValue, &e.NewValue, &e.UserID, &createdUnix); err != nil {
+			return nil, err
+		}
+		e.CreatedAt = time.Unix(createdUnix, 0)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
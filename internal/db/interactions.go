@@ -1,6 +1,7 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 
 	"wemadeit/internal/models"
@@ -22,8 +23,8 @@ func (s *Store) SaveInteraction(i models.Interaction) error {
 
 	_, err := s.DB.Exec(
 		`INSERT OR REPLACE INTO interactions
-		(id, user_id, organization_id, contact_id, deal_id, interaction_type, subject, body, occurred_at, duration_minutes, transcript, cleaned_transcript, follow_up_completed, follow_up_date, follow_up_notes, transcription_language, transcription_status, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		(id, user_id, organization_id, contact_id, deal_id, interaction_type, subject, body, occurred_at, duration_minutes, transcript, cleaned_transcript, follow_up_completed, follow_up_date, follow_up_notes, transcription_language, transcription_status, external_id, thread_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		i.ID,
 		i.UserID,
 		i.OrganizationID,
@@ -41,14 +42,25 @@ func (s *Store) SaveInteraction(i models.Interaction) error {
 		i.FollowUpNotes,
 		i.TranscriptionLanguage,
 		i.TranscriptionStatus,
+		i.ExternalID,
+		i.ThreadID,
 		i.CreatedAt.Unix(),
 		i.UpdatedAt.Unix(),
 	)
 	return err
 }
 
+const interactionColumns = `id, user_id, organization_id, contact_id, deal_id, interaction_type, subject, body, occurred_at, duration_minutes, transcript, cleaned_transcript, follow_up_completed, follow_up_date, follow_up_notes, transcription_language, transcription_status, external_id, thread_id, created_at, updated_at`
+
 func (s *Store) LoadInteractions() ([]models.Interaction, error) {
-	rows, err := s.DB.Query(`SELECT id, user_id, organization_id, contact_id, deal_id, interaction_type, subject, body, occurred_at, duration_minutes, transcript, cleaned_transcript, follow_up_completed, follow_up_date, follow_up_notes, transcription_language, transcription_status, created_at, updated_at FROM interactions ORDER BY occurred_at DESC, created_at DESC;`)
+	return s.loadInteractions("")
+}
+
+func (s *Store) loadInteractions(where string, args ...any) ([]models.Interaction, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT `+interactionColumns+` FROM interactions`+where+` ORDER BY occurred_at DESC, created_at DESC;`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -56,52 +68,123 @@ func (s *Store) LoadInteractions() ([]models.Interaction, error) {
 
 	out := make([]models.Interaction, 0)
 	for rows.Next() {
-		var i models.Interaction
-		var occurredAtUnix int64
-		var followUpCompleted int
-		var followUpUnix int64
-		var interactionType string
-		var createdUnix, updatedUnix int64
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.OrganizationID,
-			&i.ContactID,
-			&i.DealID,
-			&interactionType,
-			&i.Subject,
-			&i.Body,
-			&occurredAtUnix,
-			&i.DurationMinutes,
-			&i.Transcript,
-			&i.CleanedTranscript,
-			&followUpCompleted,
-			&followUpUnix,
-			&i.FollowUpNotes,
-			&i.TranscriptionLanguage,
-			&i.TranscriptionStatus,
-			&createdUnix,
-			&updatedUnix,
-		); err != nil {
+		i, err := scanInteraction(rows)
+		if err != nil {
 			return nil, err
 		}
-		i.InteractionType = models.InteractionType(interactionType)
-		if occurredAtUnix > 0 {
-			i.OccurredAt = time.Unix(occurredAtUnix, 0)
-		}
-		i.FollowUpCompleted = followUpCompleted != 0
-		if followUpUnix > 0 {
-			t := time.Unix(followUpUnix, 0)
-			i.FollowUpDate = &t
-		}
-		i.CreatedAt = time.Unix(createdUnix, 0)
-		i.UpdatedAt = time.Unix(updatedUnix, 0)
 		out = append(out, i)
 	}
 	return out, rows.Err()
 }
 
+func (s *Store) FindInteractionByID(id string) (models.Interaction, bool, error) {
+	row := s.DB.QueryRow(`SELECT `+interactionColumns+` FROM interactions WHERE id = ? LIMIT 1;`, id)
+	i, err := scanInteraction(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Interaction{}, false, nil
+		}
+		return models.Interaction{}, false, err
+	}
+	return i, true, nil
+}
+
+// UpdateInteractionFollowUps changes only the follow-up fields of the given
+// interactions and records their history, all in one transaction.
+func (s *Store) UpdateInteractionFollowUps(items []models.Interaction, history []models.HistoryEntry) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for _, it := range items {
+		done := 0
+		if it.FollowUpCompleted {
+			done = 1
+		}
+		if _, err = tx.Exec(
+			`UPDATE interactions SET follow_up_date = ?, follow_up_notes = ?, follow_up_completed = ?, updated_at = ? WHERE id = ?;`,
+			unixOrZero(it.FollowUpDate),
+			it.FollowUpNotes,
+			done,
+			it.UpdatedAt.Unix(),
+			it.ID,
+		); err != nil {
+			return err
+		}
+	}
+	if err = insertHistory(tx, history); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// FindInteractionByExternalID looks up an imported interaction by the ID it
+// had in its source, e.g. "ics:<uid>".
+func (s *Store) FindInteractionByExternalID(externalID string) (models.Interaction, bool, error) {
+	row := s.DB.QueryRow(`SELECT `+interactionColumns+` FROM interactions WHERE external_id = ? LIMIT 1;`, externalID)
+	i, err := scanInteraction(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Interaction{}, false, nil
+		}
+		return models.Interaction{}, false, err
+	}
+	return i, true, nil
+}
+
 func (s *Store) DeleteInteraction(interactionID string) error {
 	_, err := s.DB.Exec(`DELETE FROM interactions WHERE id = ?;`, interactionID)
 	return err
 }
+
+func scanInteraction(row rowScanner) (models.Interaction, error) {
+	var i models.Interaction
+	var occurredAtUnix int64
+	var followUpCompleted int
+	var followUpUnix int64
+	var interactionType string
+	var createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrganizationID,
+		&i.ContactID,
+		&i.DealID,
+		&interactionType,
+		&i.Subject,
+		&i.Body,
+		&occurredAtUnix,
+		&i.DurationMinutes,
+		&i.Transcript,
+		&i.CleanedTranscript,
+		&followUpCompleted,
+		&followUpUnix,
+		&i.FollowUpNotes,
+		&i.TranscriptionLanguage,
+		&i.TranscriptionStatus,
+		&i.ExternalID,
+		&i.ThreadID,
+		&createdUnix,
+		&updatedUnix,
+	); err != nil {
+		return models.Interaction{}, err
+	}
+	i.InteractionType = models.InteractionType(interactionType)
+	if occurredAtUnix > 0 {
+		i.OccurredAt = time.Unix(occurredAtUnix, 0)
+	}
+	i.FollowUpCompleted = followUpCompleted != 0
+	if followUpUnix > 0 {
+		t := time.Unix(followUpUnix, 0)
+		i.FollowUpDate = &t
+	}
+	i.CreatedAt = time.Unix(createdUnix, 0)
+	i.UpdatedAt = time.Unix(updatedUnix, 0)
+	return i, nil
+}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+func (s *Store) SaveLabel(l models.Label) error {
+	_, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO labels (id, name, color, created_at, updated_at) VALUES (?, ?, ?, ?, ?);`,
+		l.ID,
+		l.Name,
+		l.Color,
+		l.CreatedAt.Unix(),
+		l.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+func (s *Store) LoadLabels() ([]models.Label, error) {
+	rows, err := s.DB.Query(`SELECT id, name, color, created_at, updated_at FROM labels ORDER BY name COLLATE NOCASE;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make([]models.Label, 0)
+	for rows.Next() {
+		var l models.Label
+		var createdUnix, updatedUnix int64
+		if err := rows.Scan(&l.ID, &l.Name, &l.Color, &createdUnix, &updatedUnix); err != nil {
+			return nil, err
+		}
+		l.CreatedAt = time.Unix(createdUnix, 0)
+		l.UpdatedAt = time.Unix(updatedUnix, 0)
+		labels = append(labels, l)
+	}
+	return labels, rows.Err()
+}
+
+// DeleteLabel removes a label and untags everything carrying it.
+func (s *Store) DeleteLabel(id string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM taggings WHERE label_id = ?;`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM labels WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadTaggings returns the label IDs of every tagged entity of a type, by
+// entity ID.
+func (s *Store) LoadTaggings(entityType string) (map[string][]string, error) {
+	rows, err := s.DB.Query(
+		`SELECT t.entity_id, t.label_id FROM taggings t JOIN labels l ON l.id = t.label_id
+		WHERE t.entity_type = ? ORDER BY l.name COLLATE NOCASE;`,
+		entityType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string][]string)
+	for rows.Next() {
+		var entityID, labelID string
+		if err := rows.Scan(&entityID, &labelID); err != nil {
+			return nil, err
+		}
+		out[entityID] = append(out[entityID], labelID)
+	}
+	return out, rows.Err()
+}
+
+// CountTaggings returns how many entities carry each label.
+func (s *Store) CountTaggings() (map[string]int, error) {
+	rows, err := s.DB.Query(`SELECT label_id, COUNT(*) FROM taggings GROUP BY label_id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make(map[string]int)
+	for rows.Next() {
+		var labelID string
+		var n int
+		if err := rows.Scan(&labelID, &n); err != nil {
+			return nil, err
+		}
+		out[labelID] = n
+	}
+	return out, rows.Err()
+}
+
+// SetEntityLabels replaces the labels of one entity.
+func (s *Store) SetEntityLabels(entityType, entityID string, labelIDs []string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM taggings WHERE entity_type = ? AND entity_id = ?;`, entityType, entityID); err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	for _, labelID := range labelIDs {
+		if _, err = tx.Exec(
+			`INSERT OR IGNORE INTO taggings (label_id, entity_type, entity_id, created_at) VALUES (?, ?, ?, ?);`,
+			labelID, entityType, entityID, now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TagEntities adds the add labels to, and removes the remove labels from,
+// every given entity in one transaction.
+func (s *Store) TagEntities(entityType string, entityIDs, add, remove []string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now().Unix()
+	for _, entityID := range entityIDs {
+		for _, labelID := range add {
+			if _, err = tx.Exec(
+				`INSERT OR IGNORE INTO taggings (label_id, entity_type, entity_id, created_at) VALUES (?, ?, ?, ?);`,
+				labelID, entityType, entityID, now,
+			); err != nil {
+				return err
+			}
+		}
+		for _, labelID := range remove {
+			if _, err = tx.Exec(
+				`DELETE FROM taggings WHERE label_id = ? AND entity_type = ? AND entity_id = ?;`,
+				labelID, entityType, entityID,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// MissingEntities returns those of ids that name no entity of entityType.
+func (s *Store) MissingEntities(entityType string, ids []string) ([]string, error) {
+	table, ok := entityTables[entityType]
+	if !ok {
+		return nil, fmt.Errorf("unknown entity type %q", entityType)
+	}
+	missing := make([]string, 0)
+	for _, id := range ids {
+		var n int
+		if err := s.DB.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE id = ?;`, id).Scan(&n); err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
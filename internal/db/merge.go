@@ -0,0 +1,99 @@
+package db
+
+import (
+	"wemadeit/internal/models"
+)
+
+// MergeContacts saves survivor, moves everything that referenced mergedID
+// onto it, deletes the merged contact and stores the history entries, all in
+// one transaction.
+func (s *Store) MergeContacts(survivor models.Contact, mergedID string, history []models.HistoryEntry) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = saveContact(tx, survivor); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE deals SET contact_id = ? WHERE contact_id = ?;`, survivor.ID, mergedID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE interactions SET contact_id = ? WHERE contact_id = ?;`, survivor.ID, mergedID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE OR IGNORE deal_contacts SET contact_id = ? WHERE contact_id = ?;`, survivor.ID, mergedID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM deal_contacts WHERE contact_id = ?;`, mergedID); err != nil {
+		return err
+	}
+	if err = mergeEntityRows(tx, "contact", survivor.ID, mergedID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM contacts WHERE id = ?;`, mergedID); err != nil {
+		return err
+	}
+	if err = insertHistory(tx, history); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MergeOrganizations is MergeContacts for organizations: contacts, deals,
+// interactions, domains, addresses and subsidiaries move to the survivor.
+func (s *Store) MergeOrganizations(survivor models.Organization, mergedID string, history []models.HistoryEntry) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = saveOrganization(tx, survivor); err != nil {
+		return err
+	}
+	for _, table := range []string{"contacts", "deals", "interactions", "domains", "addresses"} {
+		if _, err = tx.Exec(`UPDATE `+table+` SET organization_id = ? WHERE organization_id = ?;`, survivor.ID, mergedID); err != nil {
+			return err
+		}
+	}
+	if _, err = tx.Exec(`UPDATE organizations SET parent_id = ? WHERE parent_id = ? AND id <> ?;`, survivor.ID, mergedID, survivor.ID); err != nil {
+		return err
+	}
+	if err = mergeEntityRows(tx, "organization", survivor.ID, mergedID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM organizations WHERE id = ?;`, mergedID); err != nil {
+		return err
+	}
+	if err = insertHistory(tx, history); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mergeEntityRows moves mergedID's custom field values, labels, notes and
+// attachments onto survivorID, keeping the survivor's own value where both have one.
+func mergeEntityRows(ex execer, entityType, survivorID, mergedID string) error {
+	for _, table := range []string{"custom_field_values", "taggings", "notes", "attachments"} {
+		if _, err := ex.Exec(
+			`UPDATE OR IGNORE `+table+` SET entity_id = ? WHERE entity_type = ? AND entity_id = ?;`,
+			survivorID, entityType, mergedID,
+		); err != nil {
+			return err
+		}
+		if _, err := ex.Exec(`DELETE FROM `+table+` WHERE entity_type = ? AND entity_id = ?;`, entityType, mergedID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+const noteColumns = `id, entity_type, entity_id, author_user_id, body, mentions, created_at, updated_at,
+	(SELECT COUNT(*) FROM note_revisions r WHERE r.note_id = notes.id)`
+
+func (s *Store) SaveNote(n models.Note) error {
+	return saveNote(s.DB, n)
+}
+
+func saveNote(ex execer, n models.Note) error {
+	mentions, err := json.Marshal(n.Mentions)
+	if err != nil {
+		return err
+	}
+	_, err = ex.Exec(
+		`INSERT OR REPLACE INTO notes (id, entity_type, entity_id, author_user_id, body, mentions, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?);`,
+		n.ID,
+		n.EntityType,
+		n.EntityID,
+		n.AuthorUserID,
+		n.Body,
+		string(mentions),
+		n.CreatedAt.Unix(),
+		n.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+// EditNote saves n and keeps the body it replaces, prev, as a revision.
+func (s *Store) EditNote(n models.Note, prev models.Note, editorUserID string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(
+		`INSERT INTO note_revisions (id, note_id, body, edited_by_user_id, created_at) VALUES (?, ?, ?, ?, ?);`,
+		newID(), prev.ID, prev.Body, editorUserID, n.UpdatedAt.Unix(),
+	); err != nil {
+		return err
+	}
+	if err = saveNote(tx, n); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadNotes returns the notes of one entity, newest first.
+func (s *Store) LoadNotes(entityType, entityID string) ([]models.Note, error) {
+	rows, err := s.DB.Query(
+		`SELECT `+noteColumns+` FROM notes WHERE entity_type = ? AND entity_id = ? ORDER BY created_at DESC, id DESC;`,
+		entityType, entityID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notes := make([]models.Note, 0)
+	for rows.Next() {
+		n, err := scanNote(rows)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+func (s *Store) FindNoteByID(id string) (models.Note, bool, error) {
+	n, err := scanNote(s.DB.QueryRow(`SELECT `+noteColumns+` FROM notes WHERE id = ?;`, id))
+	if err == sql.ErrNoRows {
+		return models.Note{}, false, nil
+	}
+	if err != nil {
+		return models.Note{}, false, err
+	}
+	return n, true, nil
+}
+
+func scanNote(row rowScanner) (models.Note, error) {
+	var n models.Note
+	var mentions string
+	var createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&n.ID,
+		&n.EntityType,
+		&n.EntityID,
+		&n.AuthorUserID,
+		&n.Body,
+		&mentions,
+		&createdUnix,
+		&updatedUnix,
+		&n.Revisions,
+	); err != nil {
+		return models.Note{}, err
+	}
+	n.Mentions = make([]string, 0)
+	_ = json.Unmarshal([]byte(mentions), &n.Mentions)
+	n.CreatedAt = time.Unix(createdUnix, 0)
+	n.UpdatedAt = time.Unix(updatedUnix, 0)
+	return n, nil
+}
+
+// LoadNoteRevisions returns a note's earlier bodies, newest first.
+func (s *Store) LoadNoteRevisions(noteID string) ([]models.NoteRevision, error) {
+	rows, err := s.DB.Query(
+		`SELECT id, note_id, body, edited_by_user_id, created_at FROM note_revisions WHERE note_id = ? ORDER BY created_at DESC, id DESC;`,
+		noteID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.NoteRevision, 0)
+	for rows.Next() {
+		var r models.NoteRevision
+		var createdUnix int64
+		if err := rows.Scan(&r.ID, &r.NoteID, &r.Body, &r.EditedByUserID, &createdUnix); err != nil {
+			return nil, err
+		}
+		r.CreatedAt = time.Unix(createdUnix, 0)
+		revisions = append(revisions, r)
+	}
+	return revisions, rows.Err()
+}
+
+// DeleteNote removes a note together with its revisions.
+func (s *Store) DeleteNote(id string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM note_revisions WHERE note_id = ?;`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM notes WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// CreateNotification stores n and reports whether it was new. A notification
+// whose Key already exists is skipped, which makes generated notifications
+// safe to produce on every scheduler tick.
+func (s *Store) CreateNotification(n models.Notification) (bool, error) {
+	var key any
+	if strings.TrimSpace(n.Key) != "" {
+		key = n.Key
+	}
+	res, err := s.DB.Exec(
+		`INSERT OR IGNORE INTO notifications (id, user_id, kind, title, body, entity_type, entity_id, dedupe_key, read_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		n.ID,
+		n.UserID,
+		n.Kind,
+		n.Title,
+		n.Body,
+		n.EntityType,
+		n.EntityID,
+		key,
+		unixOrZero(n.ReadAt),
+		n.CreatedAt.Unix(),
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// LoadNotifications returns a user's notifications, newest first.
+func (s *Store) LoadNotifications(userID string, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := `SELECT id, user_id, kind, title, body, entity_type, entity_id, COALESCE(dedupe_key, ''), read_at, created_at
+		FROM notifications WHERE user_id = ?`
+	if unreadOnly {
+		query += ` AND read_at = 0`
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?;`
+	rows, err := s.DB.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := make([]models.Notification, 0)
+	for rows.Next() {
+		var n models.Notification
+		var readUnix, createdUnix int64
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Title, &n.Body, &n.EntityType, &n.EntityID, &n.Key, &readUnix, &createdUnix); err != nil {
+			return nil, err
+		}
+		if readUnix > 0 {
+			t := time.Unix(readUnix, 0)
+			n.ReadAt = &t
+		}
+		n.CreatedAt = time.Unix(createdUnix, 0)
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) CountUnreadNotifications(userID string) (int, error) {
+	var n int
+	err := s.DB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at = 0;`, userID).Scan(&n)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return n, err
+}
+
+// MarkNotificationsRead marks the given notifications of a user as read, or
+// all of them when ids is empty.
+func (s *Store) MarkNotificationsRead(userID string, ids []string, at time.Time) (err error) {
+	if len(ids) == 0 {
+		_, err = s.DB.Exec(`UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at = 0;`, at.Unix(), userID)
+		return err
+	}
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	for _, id := range ids {
+		if _, err = tx.Exec(`UPDATE notifications SET read_at = ? WHERE user_id = ? AND id = ? AND read_at = 0;`, at.Unix(), userID, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) DeleteNotification(userID, id string) error {
+	_, err := s.DB.Exec(`DELETE FROM notifications WHERE user_id = ? AND id = ?;`, userID, id)
+	return err
+}
@@ -1,37 +1,33 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 
 	"wemadeit/internal/models"
 )
 
-func (s *Store) SavePayment(p models.Payment) error {
-	dueUnix := int64(0)
-	if p.DueAt != nil {
-		dueUnix = p.DueAt.Unix()
-	}
-	paidUnix := int64(0)
-	if p.PaidAt != nil {
-		paidUnix = p.PaidAt.Unix()
-	}
+const paymentColumns = `id, deal_id, title, amount, currency, status, due_at, paid_at, method, notes, gil_amount, ric_amount, reporting_amount, reporting_currency, created_at, updated_at`
 
+func (s *Store) SavePayment(p models.Payment) error {
 	_, err := s.DB.Exec(
 		`INSERT OR REPLACE INTO payments
-		(id, deal_id, title, amount, currency, status, due_at, paid_at, method, notes, gil_amount, ric_amount, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		(`+paymentColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);`,
 		p.ID,
 		p.DealID,
 		p.Title,
 		p.Amount,
 		p.Currency,
 		string(p.Status),
-		dueUnix,
-		paidUnix,
+		unixOrZero(p.DueAt),
+		unixOrZero(p.PaidAt),
 		p.Method,
 		p.Notes,
 		p.GilAmount,
 		p.RicAmount,
+		p.ReportingAmount,
+		p.ReportingCurrency,
 		p.CreatedAt.Unix(),
 		p.UpdatedAt.Unix(),
 	)
@@ -39,53 +35,23 @@ func (s *Store) SavePayment(p models.Payment) error {
 }
 
 func (s *Store) LoadPayments() ([]models.Payment, error) {
-	rows, err := s.DB.Query(`SELECT id, deal_id, title, amount, currency, status, due_at, paid_at, method, notes, gil_amount, ric_amount, created_at, updated_at FROM payments ORDER BY created_at DESC;`)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	return s.queryPayments(`SELECT ` + paymentColumns + ` FROM payments ORDER BY created_at DESC;`)
+}
 
-	out := make([]models.Payment, 0)
-	for rows.Next() {
-		var p models.Payment
-		var status string
-		var dueUnix, paidUnix, createdUnix, updatedUnix int64
-		if err := rows.Scan(
-			&p.ID,
-			&p.DealID,
-			&p.Title,
-			&p.Amount,
-			&p.Currency,
-			&status,
-			&dueUnix,
-			&paidUnix,
-			&p.Method,
-			&p.Notes,
-			&p.GilAmount,
-			&p.RicAmount,
-			&createdUnix,
-			&updatedUnix,
-		); err != nil {
-			return nil, err
-		}
-		p.Status = models.PaymentStatus(status)
-		if dueUnix > 0 {
-			t := time.Unix(dueUnix, 0)
-			p.DueAt = &t
-		}
-		if paidUnix > 0 {
-			t := time.Unix(paidUnix, 0)
-			p.PaidAt = &t
-		}
-		p.CreatedAt = time.Unix(createdUnix, 0)
-		p.UpdatedAt = time.Unix(updatedUnix, 0)
-		out = append(out, p)
+func (s *Store) LoadPaymentsFor(v Viewer) ([]models.Payment, error) {
+	where, args := v.visibility("payments")
+	if where == "" {
+		return s.LoadPayments()
 	}
-	return out, rows.Err()
+	return s.queryPayments(`SELECT `+paymentColumns+` FROM payments WHERE `+where+` ORDER BY created_at DESC;`, args...)
 }
 
 func (s *Store) LoadPaymentsByDeal(dealID string) ([]models.Payment, error) {
-	rows, err := s.DB.Query(`SELECT id, deal_id, title, amount, currency, status, due_at, paid_at, method, notes, gil_amount, ric_amount, created_at, updated_at FROM payments WHERE deal_id = ? ORDER BY created_at DESC;`, dealID)
+	return s.queryPayments(`SELECT `+paymentColumns+` FROM payments WHERE deal_id = ? ORDER BY created_at DESC;`, dealID)
+}
+
+func (s *Store) queryPayments(query string, args ...any) ([]models.Payment, error) {
+	rows, err := s.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -93,38 +59,10 @@ func (s *Store) LoadPaymentsByDeal(dealID string) ([]models.Payment, error) {
 
 	out := make([]models.Payment, 0)
 	for rows.Next() {
-		var p models.Payment
-		var status string
-		var dueUnix, paidUnix, createdUnix, updatedUnix int64
-		if err := rows.Scan(
-			&p.ID,
-			&p.DealID,
-			&p.Title,
-			&p.Amount,
-			&p.Currency,
-			&status,
-			&dueUnix,
-			&paidUnix,
-			&p.Method,
-			&p.Notes,
-			&p.GilAmount,
-			&p.RicAmount,
-			&createdUnix,
-			&updatedUnix,
-		); err != nil {
+		p, err := scanPayment(rows)
+		if err != nil {
 			return nil, err
 		}
-		p.Status = models.PaymentStatus(status)
-		if dueUnix > 0 {
-			t := time.Unix(dueUnix, 0)
-			p.DueAt = &t
-		}
-		if paidUnix > 0 {
-			t := time.Unix(paidUnix, 0)
-			p.PaidAt = &t
-		}
-		p.CreatedAt = time.Unix(createdUnix, 0)
-		p.UpdatedAt = time.Unix(updatedUnix, 0)
 		out = append(out, p)
 	}
 	return out, rows.Err()
@@ -135,3 +73,59 @@ func (s *Store) DeletePayment(id string) error {
 	return err
 }
 
+func (s *Store) FindPaymentByID(id string) (models.Payment, bool, error) {
+	row := s.DB.QueryRow(`SELECT `+paymentColumns+` FROM payments WHERE id = ? LIMIT 1;`, id)
+	p, err := scanPayment(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Payment{}, false, nil
+		}
+		return models.Payment{}, false, err
+	}
+	return p, true, nil
+}
+
+// UpdatePaymentReporting stores a payment's amount in the reporting currency
+// without touching anything else.
+func (s *Store) UpdatePaymentReporting(id string, amount float64, currency string) error {
+	_, err := s.DB.Exec(`UPDATE payments SET reporting_amount = ?, reporting_currency = ? WHERE id = ?;`, amount, currency, id)
+	return err
+}
+
+func scanPayment(row rowScanner) (models.Payment, error) {
+	var p models.Payment
+	var status string
+	var dueUnix, paidUnix, createdUnix, updatedUnix int64
+	if err := row.Scan(
+		&p.ID,
+		&p.DealID,
+		&p.Title,
+		&p.Amount,
+		&p.Currency,
+		&status,
+		&dueUnix,
+		&paidUnix,
+		&p.Method,
+		&p.Notes,
+		&p.GilAmount,
+		&p.RicAmount,
+		&p.ReportingAmount,
+		&p.ReportingCurrency,
+		&createdUnix,
+		&updatedUnix,
+	); err != nil {
+		return models.Payment{}, err
+	}
+	p.Status = models.PaymentStatus(status)
+	if dueUnix > 0 {
+		t := time.Unix(dueUnix, 0)
+		p.DueAt = &t
+	}
+	if paidUnix > 0 {
+		t := time.Unix(paidUnix, 0)
+		p.PaidAt = &t
+	}
+	p.CreatedAt = time.Unix(createdUnix, 0)
+	p.UpdatedAt = time.Unix(updatedUnix, 0)
+	return p, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// SaveProjectMember adds a user to a project or changes their role.
+func (s *Store) SaveProjectMember(m models.ProjectMember) error {
+	_, err := s.DB.Exec(
+		`INSERT INTO project_members (project_id, user_id, role, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (project_id, user_id) DO UPDATE SET role = excluded.role, updated_at = excluded.updated_at;`,
+		m.ProjectID,
+		m.UserID,
+		string(m.Role),
+		m.CreatedAt.Unix(),
+		m.UpdatedAt.Unix(),
+	)
+	return err
+}
+
+// LoadProjectMembers returns the members of one project, leads first.
+func (s *Store) LoadProjectMembers(projectID string) ([]models.ProjectMember, error) {
+	rows, err := s.DB.Query(
+		`SELECT project_id, user_id, role, created_at, updated_at FROM project_members
+		WHERE project_id = ?
+		ORDER BY CASE role WHEN 'lead' THEN 0 WHEN 'contributor' THEN 1 ELSE 2 END, created_at, user_id;`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.ProjectMember, 0)
+	for rows.Next() {
+		m, err := scanProjectMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+func (s *Store) FindProjectMember(projectID, userID string) (models.ProjectMember, bool, error) {
+	m, err := scanProjectMember(s.DB.QueryRow(
+		`SELECT project_id, user_id, role, created_at, updated_at FROM project_members WHERE project_id = ? AND user_id = ?;`,
+		projectID, userID,
+	))
+	if err == sql.ErrNoRows {
+		return models.ProjectMember{}, false, nil
+	}
+	if err != nil {
+		return models.ProjectMember{}, false, err
+	}
+	return m, true, nil
+}
+
+func scanProjectMember(row rowScanner) (models.ProjectMember, error) {
+	var m models.ProjectMember
+	var role string
+	var createdUnix, updatedUnix int64
+	if err := row.Scan(&m.ProjectID, &m.UserID, &role, &createdUnix, &updatedUnix); err != nil {
+		return models.ProjectMember{}, err
+	}
+	m.Role = models.ProjectRole(role)
+	m.CreatedAt = time.Unix(createdUnix, 0)
+	m.UpdatedAt = time.Unix(updatedUnix, 0)
+	return m, nil
+}
+
+func (s *Store) DeleteProjectMember(projectID, userID string) error {
+	_, err := s.DB.Exec(`DELETE FROM project_members WHERE project_id = ? AND user_id = ?;`, projectID, userID)
+	return err
+}
@@ -59,13 +59,23 @@ func (s *Store) DeleteQuotation(quotationID string) error {
 	if _, err = tx.Exec(`DELETE FROM quotations WHERE id = ?;`, quotationID); err != nil {
 		return err
 	}
+	if err = deleteOrphans(tx); err != nil {
+		return err
+	}
 
 	err = tx.Commit()
 	return err
 }
 
 func (s *Store) LoadQuotations() ([]models.Quotation, error) {
-	rows, err := s.DB.Query(`SELECT id, deal_id, created_by_user_id, number, title, introduction, terms_and_conditions, currency, status, subtotal, tax_rate, tax_amount, discount_amount, total, valid_until, version, public_token, created_at, updated_at FROM quotations ORDER BY created_at DESC;`)
+	return s.loadQuotations("")
+}
+
+func (s *Store) loadQuotations(where string, args ...any) ([]models.Quotation, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT id, deal_id, created_by_user_id, number, title, introduction, terms_and_conditions, currency, status, subtotal, tax_rate, tax_amount, discount_amount, total, valid_until, version, public_token, created_at, updated_at FROM quotations`+where+` ORDER BY created_at DESC;`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -165,7 +175,14 @@ func (s *Store) DeleteQuotationItem(itemID string) (string, error) {
 }
 
 func (s *Store) LoadQuotationItems() ([]models.QuotationItem, error) {
-	rows, err := s.DB.Query(`SELECT id, quotation_id, name, description, quantity, unit_price, unit_type, line_total, position, created_at, updated_at FROM quotation_items ORDER BY quotation_id ASC, position ASC;`)
+	return s.loadQuotationItems("")
+}
+
+func (s *Store) loadQuotationItems(where string, args ...any) ([]models.QuotationItem, error) {
+	if where != "" {
+		where = ` WHERE ` + where
+	}
+	rows, err := s.DB.Query(`SELECT id, quotation_id, name, description, quantity, unit_price, unit_type, line_total, position, created_at, updated_at FROM quotation_items`+where+` ORDER BY quotation_id ASC, position ASC;`, args...)
 	if err != nil {
 		return nil, err
 	}
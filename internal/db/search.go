@@ -0,0 +1,266 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"wemadeit/internal/models"
+)
+
+// searchSource describes how one table feeds the full-text index. Title and
+// body are SQL expressions over the row aliased "new", so the same text
+// serves the sync triggers and the rebuild.
+type searchSource struct {
+	entityType string
+	table      string
+	columns    []string // the columns title and body read
+	title      string
+	body       string
+}
+
+var searchSources = []searchSource{
+	{
+		entityType: "organization",
+		table:      "organizations",
+		columns:    []string{"name", "industry", "website", "email", "phone", "tax_id", "city", "country", "notes"},
+		title:      `new.name`,
+		body:       `concat_ws(' ', new.industry, new.website, new.email, new.phone, new.tax_id, new.city, new.country, new.notes)`,
+	},
+	{
+		entityType: "contact",
+		table:      "contacts",
+		columns:    []string{"first_name", "last_name", "job_title", "email", "phone", "mobile", "notes"},
+		title:      `trim(new.first_name || ' ' || new.last_name)`,
+		body:       `concat_ws(' ', new.job_title, new.email, new.phone, new.mobile, new.notes)`,
+	},
+	{
+		entityType: "deal",
+		table:      "deals",
+		columns:    []string{"title", "description", "notes", "domain"},
+		title:      `new.title`,
+		body:       `concat_ws(' ', new.description, new.notes, new.domain)`,
+	},
+	{
+		entityType: "interaction",
+		table:      "interactions",
+		columns:    []string{"subject", "body", "transcript", "cleaned_transcript", "follow_up_notes"},
+		title:      `new.subject`,
+		body:       `concat_ws(' ', new.body, CASE WHEN new.cleaned_transcript <> '' THEN new.cleaned_transcript ELSE new.transcript END, new.follow_up_notes)`,
+	},
+	{
+		entityType: "quotation",
+		table:      "quotations",
+		columns:    []string{"number", "title", "introduction"},
+		title:      `trim(new.number || ' ' || new.title)`,
+		body:       `new.introduction`,
+	},
+	{
+		entityType: "task",
+		table:      "tasks",
+		columns:    []string{"title", "description"},
+		title:      `new.title`,
+		body:       `new.description`,
+	},
+}
+
+// SearchTypes lists the entity types the search index covers.
+func SearchTypes() []string {
+	out := make([]string, 0, len(searchSources))
+	for _, src := range searchSources {
+		out = append(out, src.entityType)
+	}
+	return out
+}
+
+// migrateSearch creates the FTS5 index, (re)creates the triggers that keep
+// it in sync and rebuilds it, so older databases and changed sources are
+// picked up. search_map gives every entity a stable FTS rowid: saves use
+// INSERT OR REPLACE, which deletes without firing DELETE triggers, so the
+// insert trigger replaces the entity's document by that rowid.
+func (s *Store) migrateSearch() error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS search_map (
+			rowid INTEGER PRIMARY KEY,
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			UNIQUE(entity_type, entity_id)
+		);`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5(
+			title, body, tokenize = 'unicode61 remove_diacritics 2'
+		);`,
+	}
+	for _, src := range searchSources {
+		doc := `INSERT OR IGNORE INTO search_map (entity_type, entity_id) VALUES ('` + src.entityType + `', new.id);
+			DELETE FROM search_index WHERE rowid = (SELECT rowid FROM search_map WHERE entity_type = '` + src.entityType + `' AND entity_id = new.id);
+			INSERT INTO search_index (rowid, title, body) VALUES (
+				(SELECT rowid FROM search_map WHERE entity_type = '` + src.entityType + `' AND entity_id = new.id),
+				` + src.title + `, ` + src.body + `);`
+		stmts = append(stmts,
+			`DROP TRIGGER IF EXISTS search_`+src.table+`_insert;`,
+			`CREATE TRIGGER search_`+src.table+`_insert AFTER INSERT ON `+src.table+` BEGIN `+doc+` END;`,
+			`DROP TRIGGER IF EXISTS search_`+src.table+`_update;`,
+			`CREATE TRIGGER search_`+src.table+`_update AFTER UPDATE OF `+strings.Join(src.columns, ", ")+` ON `+src.table+` BEGIN `+doc+` END;`,
+			`DROP TRIGGER IF EXISTS search_`+src.table+`_delete;`,
+			`CREATE TRIGGER search_`+src.table+`_delete AFTER DELETE ON `+src.table+` BEGIN
+				DELETE FROM search_index WHERE rowid = (SELECT rowid FROM search_map WHERE entity_type = '`+src.entityType+`' AND entity_id = old.id);
+				DELETE FROM search_map WHERE entity_type = '`+src.entityType+`' AND entity_id = old.id;
+			END;`,
+		)
+	}
+	for _, stmt := range stmts {
+		if _, err := s.DB.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return s.RebuildSearchIndex()
+}
+
+// RebuildSearchIndex re-creates every search document from the source
+// tables.
+func (s *Store) RebuildSearchIndex() (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM search_index;`); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM search_map;`); err != nil {
+		return err
+	}
+	for _, src := range searchSources {
+		if _, err = tx.Exec(`INSERT INTO search_map (entity_type, entity_id) SELECT '` + src.entityType + `', id FROM ` + src.table + `;`); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(
+			`INSERT INTO search_index (rowid, title, body)
+			SELECT m.rowid, ` + src.title + `, ` + src.body + `
+			FROM ` + src.table + ` AS new JOIN search_map m ON m.entity_type = '` + src.entityType + `' AND m.entity_id = new.id;`,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Snippet markers around matched terms; they cannot occur in stored text,
+// so callers can escape the snippet and then swap them for markup.
+const (
+	SearchMatchStart = "\x02"
+	SearchMatchEnd   = "\x03"
+)
+
+// Search runs a full-text query and returns the best hits first. Bare words
+// must all match, a trailing * makes a prefix query and "double quotes"
+// search for a phrase. types limits the entity types; empty means all.
+func (s *Store) Search(v Viewer, q string, types []string, limit int) ([]models.SearchHit, error) {
+	match := searchMatchExpression(q)
+	if match == "" {
+		return nil, errors.New("query has no searchable terms")
+	}
+	query := `SELECT m.entity_type, m.entity_id, search_index.title,
+			snippet(search_index, -1, '` + SearchMatchStart + `', '` + SearchMatchEnd + `', '…', 16),
+			bm25(search_index, 10.0, 1.0)
+		FROM search_index JOIN search_map m ON m.rowid = search_index.rowid
+		WHERE search_index MATCH ?`
+	args := []any{match}
+	if len(types) > 0 {
+		query += ` AND m.entity_type IN (?` + strings.Repeat(", ?", len(types)-1) + `)`
+		for _, t := range types {
+			args = append(args, t)
+		}
+	}
+	// Hits on records v may not see are dropped; quotations and interactions
+	// follow their deal.
+	for _, c := range []struct{ entityType, table, column string }{
+		{"deal", "deals", "m.entity_id"},
+		{"quotation", "deals", "(SELECT deal_id FROM quotations WHERE id = m.entity_id)"},
+		{"interaction", "deals", "(SELECT nullif(deal_id, '') FROM interactions WHERE id = m.entity_id)"},
+		{"task", "tasks", "m.entity_id"},
+	} {
+		where, whereArgs := v.visibility(c.table)
+		if where == "" {
+			continue
+		}
+		query += ` AND (m.entity_type != '` + c.entityType + `' OR ` + c.column + ` IS NULL OR ` + c.column + ` IN (SELECT id FROM ` + c.table + ` WHERE ` + where + `))`
+		args = append(args, whereArgs...)
+	}
+	query += ` ORDER BY bm25(search_index, 10.0, 1.0) LIMIT ?;`
+	args = append(args, limit)
+
+	rows, err := s.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := make([]models.SearchHit, 0)
+	for rows.Next() {
+		var h models.SearchHit
+		var rank float64
+		if err := rows.Scan(&h.EntityType, &h.EntityID, &h.Title, &h.Snippet, &rank); err != nil {
+			return nil, err
+		}
+		// bm25 is negative, lower being better.
+		h.Score = -rank
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+// searchMatchExpression turns user input into an FTS5 query in which every
+// term is quoted, so punctuation and FTS keywords are searched literally.
+func searchMatchExpression(q string) string {
+	var terms []string
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		if q[0] == '"' {
+			end := strings.IndexByte(q[1:], '"')
+			phrase := q[1:]
+			if end >= 0 {
+				phrase, q = q[1:end+1], q[end+2:]
+			} else {
+				q = ""
+			}
+			if words := strings.Fields(phrase); len(words) > 0 {
+				terms = append(terms, quoteSearchTerm(strings.Join(words, " ")))
+			}
+			continue
+		}
+		word := q
+		if i := strings.IndexAny(q, " \t\n\""); i >= 0 {
+			word, q = q[:i], q[i:]
+		} else {
+			q = ""
+		}
+		prefix := strings.HasSuffix(word, "*")
+		word = strings.Trim(word, "*")
+		if !hasSearchableRune(word) {
+			continue
+		}
+		term := quoteSearchTerm(word)
+		if prefix {
+			term += "*"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " ")
+}
+
+func quoteSearchTerm(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func hasSearchableRune(s string) bool {
+	for _, r := range s {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// defaultTaskColumns is the board every project starts with: position,
+// name, color and category of each column.
+const defaultTaskColumns = `VALUES
+	(1, 'To Do', '#9ca3af', 'todo'),
+	(2, 'In Progress', '#3b82f6', 'in_progress'),
+	(3, 'Review', '#f59e0b', 'in_progress'),
+	(4, 'Done', '#22c55e', 'done')`
+
+// addDefaultTaskColumns gives the projects matching where that have no
+// columns yet the default board. It is a single statement, so two callers
+// cannot both find a project without columns and both fill it.
+func addDefaultTaskColumns(ex execer, where string, args ...any) error {
+	if where != "" {
+		where = ` AND ` + where
+	}
+	now := time.Now().Unix()
+	_, err := ex.Exec(
+		`INSERT INTO task_columns (id, project_id, name, color, category, position, wip_limit, created_at, updated_at)
+		SELECT p.id || '-column-' || d.column1, p.id, d.column2, d.column3, d.column4, d.column1, 0, ?, ?
+		FROM projects p, (`+defaultTaskColumns+`) d
+		WHERE NOT EXISTS (SELECT 1 FROM task_columns c WHERE c.project_id = p.id)`+where+`;`,
+		append([]any{now, now}, args...)...,
+	)
+	return err
+}
+
+// SaveTaskColumn saves a column. When its category changes, the tasks in it
+// take the new category as their status in the same transaction; they are
+// already in the column, so its WIP limit does not apply.
+func (s *Store) SaveTaskColumn(c models.TaskColumn) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Tasks that were never placed are pinned to the column first, so the
+	// new category does not send them elsewhere.
+	if _, err = tx.Exec(
+		`UPDATE tasks SET column_id = ?, status = ?, updated_at = ? WHERE id IN (
+			SELECT t.id FROM tasks t JOIN task_columns c ON c.project_id = t.project_id
+			WHERE c.id = ? AND c.category <> ? AND `+inColumn+`);`,
+		c.ID, string(c.Category), c.UpdatedAt.Unix(), c.ID, string(c.Category),
+	); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(
+		`INSERT OR REPLACE INTO task_columns (id, project_id, name, color, category, position, wip_limit, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`,
+		c.ID,
+		c.ProjectID,
+		c.Name,
+		c.Color,
+		string(c.Category),
+		c.Position,
+		c.WIPLimit,
+		c.CreatedAt.Unix(),
+		c.UpdatedAt.Unix(),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// LoadTaskColumns returns the columns of one project's board, left to right.
+func (s *Store) LoadTaskColumns(projectID string) ([]models.TaskColumn, error) {
+	rows, err := s.DB.Query(
+		`SELECT id, project_id, name, color, category, position, wip_limit, created_at, updated_at FROM task_columns
+		WHERE project_id = ? ORDER BY position, created_at, id;`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]models.TaskColumn, 0)
+	for rows.Next() {
+		var c models.TaskColumn
+		var category string
+		var createdUnix, updatedUnix int64
+		if err := rows.Scan(&c.ID, &c.ProjectID, &c.Name, &c.Color, &category, &c.Position, &c.WIPLimit, &createdUnix, &updatedUnix); err != nil {
+			return nil, err
+		}
+		c.Category = models.TaskStatus(category)
+		c.CreatedAt = time.Unix(createdUnix, 0)
+		c.UpdatedAt = time.Unix(updatedUnix, 0)
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+// DeleteTaskColumn removes a column; tasks still in it fall back to the
+// first column of their status.
+func (s *Store) DeleteTaskColumn(id string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec(`DELETE FROM task_columns WHERE id = ?;`, id); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE tasks SET column_id = '' WHERE column_id = ?;`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) ReorderTaskColumns(projectID string, ids []string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	now := time.Now().Unix()
+	for i, id := range ids {
+		if _, err = tx.Exec(`UPDATE task_columns SET position = ?, updated_at = ? WHERE id = ? AND project_id = ?;`, i+1, now, id, projectID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ErrColumnFull is returned when a task would go over its column's WIP
+// limit.
+var ErrColumnFull = errors.New("column is at its WIP limit")
+
+// inColumn is the SQL condition that task t of column c's project sits in
+// c. Like the board, it puts tasks that were never placed in the first
+// column of their status, or in the first column when no column has their
+// status.
+const inColumn = `(t.column_id = c.id
+	OR (t.column_id NOT IN (SELECT id FROM task_columns WHERE project_id = c.project_id)
+		AND c.id = coalesce(
+			(SELECT f.id FROM task_columns f WHERE f.project_id = c.project_id AND f.category = t.status ORDER BY f.position, f.created_at, f.id LIMIT 1),
+			(SELECT f.id FROM task_columns f WHERE f.project_id = c.project_id ORDER BY f.position, f.created_at, f.id LIMIT 1))))`
+
+// columnHasRoom is the SQL condition that the column with the first ID has
+// room for the task with the second: it has no WIP limit or fewer other
+// tasks than it.
+const columnHasRoom = `NOT EXISTS (
+	SELECT 1 FROM task_columns c WHERE c.id = ? AND c.wip_limit > 0 AND (
+		SELECT COUNT(*) FROM tasks t
+		WHERE t.id <> ? AND t.project_id = c.project_id AND ` + inColumn + `
+	) >= c.wip_limit
+)`
+
+// MoveTask puts a task in a column at a position, taking the column's
+// category as its status. Only the moved task is written. A task entering
+// the column fails with ErrColumnFull when the column is at its WIP limit;
+// the check and the write are one statement, so concurrent moves cannot
+// both take its last place.
+func (s *Store) MoveTask(taskID, columnID string, status models.TaskStatus, position float64) error {
+	res, err := s.DB.Exec(
+		`UPDATE tasks SET column_id = ?, status = ?, position = ?, updated_at = ?
+		WHERE id = ? AND (column_id = ? OR `+columnHasRoom+`);`,
+		columnID, string(status), position, time.Now().Unix(), taskID, columnID, columnID, taskID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrColumnFull
+	}
+	return nil
+}
+
+// SaveTaskIntoColumn is SaveTask for a task entering the column
+// t.ColumnID. Like MoveTask, it fails with ErrColumnFull and writes nothing
+// when the column is at its WIP limit.
+func (s *Store) SaveTaskIntoColumn(t models.Task) error {
+	res, err := s.DB.Exec(
+		`INSERT OR REPLACE INTO tasks (`+taskFields+`)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE `+columnHasRoom+`;`,
+		append(taskValues(t), t.ColumnID, t.ID)...,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrColumnFull
+	}
+	return nil
+}
+
+// RenumberColumnTasks spreads the positions of a column's tasks back out
+// to 1..n in the given order. Moves only need it once repeated halving has
+// left no room between two neighbours.
+func (s *Store) RenumberColumnTasks(columnID string, taskIDs []string) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	for i, id := range taskIDs {
+		if _, err = tx.Exec(`UPDATE tasks SET column_id = ?, position = ? WHERE id = ?;`, columnID, float64(i+1), id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -170,6 +170,20 @@ func (s *Store) DeleteUser(userID string) (err error) {
 	if _, err = tx.Exec(`DELETE FROM sessions WHERE user_id = ?;`, userID); err != nil {
 		return err
 	}
+	if _, err = tx.Exec(`DELETE FROM notifications WHERE user_id = ?;`, userID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM calendar_feeds WHERE user_id = ?;`, userID); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM project_members WHERE user_id = ?;`, userID); err != nil {
+		return err
+	}
+	for _, table := range []string{"organizations", "deals", "projects"} {
+		if _, err = tx.Exec(`UPDATE `+table+` SET owner_user_id = '' WHERE owner_user_id = ?;`, userID); err != nil {
+			return err
+		}
+	}
 	if _, err = tx.Exec(`DELETE FROM users WHERE id = ?;`, userID); err != nil {
 		return err
 	}
@@ -0,0 +1,187 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// Viewer is the user a query runs for. Admins and project managers see
+// everything; salespeople see the deals they own plus shared ones;
+// developers see the projects they are members of, with their deals
+// and tasks. Payments, quotations, billing plans, interactions and domains
+// follow their deal. Organizations and contacts are visible to everyone.
+type Viewer struct {
+	UserID string
+	Role   models.UserRole
+}
+
+func ViewerFor(u models.User) Viewer {
+	return Viewer{UserID: u.ID, Role: u.Role}
+}
+
+// SeesAll reports whether v is exempt from the visibility rules.
+func (v Viewer) SeesAll() bool {
+	return v.Role == models.RoleAdmin || v.Role == models.RoleProjectManager
+}
+
+// projectMembership is the SQL condition for projects.id being a project
+// the user owns or is a member of.
+const projectMembership = `(projects.owner_user_id = ? OR projects.id IN (SELECT project_id FROM project_members WHERE user_id = ?))`
+
+// visibility returns the SQL condition limiting table to the rows v may
+// see, "" when v sees them all. Anyone but a salesperson is held to the
+// developer rules.
+func (v Viewer) visibility(table string) (string, []any) {
+	if v.SeesAll() {
+		return "", nil
+	}
+	uid := v.UserID
+	switch table {
+	case "deals":
+		if v.Role == models.RoleSales {
+			return `(deals.owner_user_id = ? OR deals.shared = 1)`, []any{uid}
+		}
+		return `deals.id IN (SELECT deal_id FROM projects WHERE ` + projectMembership + `)`, []any{uid, uid}
+	case "projects":
+		if v.Role == models.RoleSales {
+			return "", nil
+		}
+		return projectMembership, []any{uid, uid}
+	case "tasks":
+		if v.Role == models.RoleSales {
+			return "", nil
+		}
+		return `(tasks.owner_user_id = ? OR tasks.project_id IN (SELECT id FROM projects WHERE ` + projectMembership + `))`, []any{uid, uid, uid}
+	case "payments", "quotations", "billing_plans", "interactions", "domains":
+		where, args := v.visibility("deals")
+		cond := table + `.deal_id IN (SELECT id FROM deals WHERE ` + where + `)`
+		if table == "interactions" || table == "domains" {
+			// Interactions and domains need not belong to a deal.
+			cond = `(` + table + `.deal_id = '' OR ` + cond + `)`
+		}
+		return cond, args
+	case "quotation_items":
+		where, args := v.visibility("quotations")
+		return `quotation_items.quotation_id IN (SELECT id FROM quotations WHERE ` + where + `)`, args
+	}
+	return "", nil
+}
+
+func (s *Store) LoadDealsFor(v Viewer) ([]models.Deal, error) {
+	where, args := v.visibility("deals")
+	return s.loadDeals(where, args...)
+}
+
+func (s *Store) LoadProjectsFor(v Viewer) ([]models.Project, error) {
+	where, args := v.visibility("projects")
+	return s.loadProjects(where, args...)
+}
+
+func (s *Store) LoadTasksFor(v Viewer) ([]models.Task, error) {
+	where, args := v.visibility("tasks")
+	return s.loadTasks(where, args...)
+}
+
+func (s *Store) LoadQuotationsFor(v Viewer) ([]models.Quotation, error) {
+	where, args := v.visibility("quotations")
+	return s.loadQuotations(where, args...)
+}
+
+func (s *Store) LoadQuotationItemsFor(v Viewer) ([]models.QuotationItem, error) {
+	where, args := v.visibility("quotation_items")
+	return s.loadQuotationItems(where, args...)
+}
+
+func (s *Store) LoadInteractionsFor(v Viewer) ([]models.Interaction, error) {
+	where, args := v.visibility("interactions")
+	return s.loadInteractions(where, args...)
+}
+
+func (s *Store) LoadBillingPlansFor(v Viewer) ([]models.BillingPlan, error) {
+	where, args := v.visibility("billing_plans")
+	return s.loadBillingPlans(where, args...)
+}
+
+func (s *Store) LoadDomainsFor(v Viewer) ([]models.Domain, error) {
+	where, args := v.visibility("domains")
+	return s.loadDomains(where, "name ASC", args...)
+}
+
+// LoadDomainsExpiringFor is LoadDomainsExpiringBefore limited to the domains
+// v may see.
+func (s *Store) LoadDomainsExpiringFor(v Viewer, from, until time.Time) ([]models.Domain, error) {
+	where, args := v.visibility("domains")
+	if where != "" {
+		where = ` AND ` + where
+	}
+	return s.loadDomains(`expires_at > 0 AND expires_at >= ? AND expires_at <= ?`+where, "expires_at ASC", append([]any{from.Unix(), until.Unix()}, args...)...)
+}
+
+// CanSee reports whether v may see the deal, project or task with the given
+// ID. It is false for records that do not exist and true for entity types
+// the rules do not cover.
+func (s *Store) CanSee(v Viewer, entityType, id string) (bool, error) {
+	table, ok := entityTables[entityType]
+	if !ok {
+		return false, fmt.Errorf("unknown entity type %q", entityType)
+	}
+	where, args := v.visibility(table)
+	query := `SELECT COUNT(*) FROM ` + table + ` WHERE id = ?`
+	if where != "" {
+		query += ` AND ` + where
+	}
+	var n int
+	if err := s.DB.QueryRow(query+`;`, append([]any{id}, args...)...).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// OwnerOf returns the owner of an organization, deal, project or task, with
+// false when the record does not exist.
+func (s *Store) OwnerOf(entityType, id string) (string, bool, error) {
+	table, ok := entityTables[entityType]
+	if !ok || table == "contacts" || table == "quotations" {
+		return "", false, fmt.Errorf("%s records have no owner", entityType)
+	}
+	var owner string
+	err := s.DB.QueryRow(`SELECT owner_user_id FROM `+table+` WHERE id = ?;`, id).Scan(&owner)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return owner, true, nil
+}
+
+// dealQueries select the deal of a record whose visibility follows it.
+var dealQueries = map[string]string{
+	"payment":        `SELECT deal_id FROM payments WHERE id = ?;`,
+	"quotation":      `SELECT deal_id FROM quotations WHERE id = ?;`,
+	"quotation_item": `SELECT quotations.deal_id FROM quotation_items JOIN quotations ON quotations.id = quotation_items.quotation_id WHERE quotation_items.id = ?;`,
+	"interaction":    `SELECT deal_id FROM interactions WHERE id = ?;`,
+	"billing_plan":   `SELECT deal_id FROM billing_plans WHERE id = ?;`,
+	"domain":         `SELECT deal_id FROM domains WHERE id = ?;`,
+}
+
+// DealOf returns the deal a payment, quotation, quotation item, interaction,
+// billing plan or domain belongs to, with false when the record does not exist.
+func (s *Store) DealOf(entityType, id string) (string, bool, error) {
+	query, ok := dealQueries[entityType]
+	if !ok {
+		return "", false, fmt.Errorf("%s records have no deal", entityType)
+	}
+	var dealID string
+	err := s.DB.QueryRow(query, id).Scan(&dealID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return dealID, true, nil
+}
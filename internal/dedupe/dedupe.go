@@ -0,0 +1,296 @@
+// Package dedupe finds likely duplicate contacts and organizations. Records
+// are compared on normalized e-mail, phone, website domain, tax ID and a
+// fuzzy name match; each matching signal adds evidence and the pair score is
+// the combined probability that at least one signal is right.
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Signal weights: how sure a single matching signal makes us.
+const (
+	weightTaxID     = 0.95
+	weightEmail     = 0.9
+	weightDomain    = 0.75
+	weightPhone     = 0.7
+	weightNameExact = 0.7
+	weightNameFuzzy = 0.6
+
+	// Names below this similarity are not evidence at all.
+	minNameSimilarity = 0.88
+)
+
+// Record is the comparable view of a contact or organization.
+type Record struct {
+	ID      string
+	Name    string
+	Emails  []string
+	Phones  []string
+	Domains []string
+	TaxID   string
+}
+
+type Reason struct {
+	Field string  `json:"field"`
+	Value string  `json:"value"`
+	Score float64 `json:"score"`
+}
+
+type Match struct {
+	A       string   `json:"a"`
+	B       string   `json:"b"`
+	Score   float64  `json:"score"`
+	Reasons []Reason `json:"reasons"`
+}
+
+type Options struct {
+	// MinScore drops weaker pairs; zero means 0.5.
+	MinScore float64
+	// CallingCode is assumed for phone numbers without one, e.g. "39".
+	CallingCode string
+}
+
+type normalized struct {
+	id      string
+	name    string
+	emails  []string
+	phones  []string
+	domains []string
+	taxID   string
+}
+
+// Find scores every pair of records and returns those at or above
+// MinScore, best first.
+func Find(records []Record, opts Options) []Match {
+	if opts.MinScore <= 0 {
+		opts.MinScore = 0.5
+	}
+	norm := make([]normalized, len(records))
+	for i, r := range records {
+		n := normalized{id: r.ID, name: Name(r.Name), taxID: TaxID(r.TaxID)}
+		for _, e := range r.Emails {
+			if v := Email(e); v != "" {
+				n.emails = append(n.emails, v)
+			}
+		}
+		for _, p := range r.Phones {
+			if v := Phone(p, opts.CallingCode); v != "" {
+				n.phones = append(n.phones, v)
+			}
+		}
+		for _, d := range r.Domains {
+			if v := Domain(d); v != "" {
+				n.domains = append(n.domains, v)
+			}
+		}
+		norm[i] = n
+	}
+
+	out := make([]Match, 0)
+	for i := range norm {
+		for j := i + 1; j < len(norm); j++ {
+			if m, ok := compare(norm[i], norm[j]); ok && m.Score >= opts.MinScore {
+				out = append(out, m)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	return out
+}
+
+func compare(a, b normalized) (Match, bool) {
+	m := Match{A: a.id, B: b.id, Reasons: make([]Reason, 0)}
+	if a.taxID != "" && a.taxID == b.taxID {
+		m.Reasons = append(m.Reasons, Reason{Field: "taxId", Value: a.taxID, Score: weightTaxID})
+	}
+	if v, ok := shared(a.emails, b.emails); ok {
+		m.Reasons = append(m.Reasons, Reason{Field: "email", Value: v, Score: weightEmail})
+	}
+	if v, ok := shared(a.phones, b.phones); ok {
+		m.Reasons = append(m.Reasons, Reason{Field: "phone", Value: v, Score: weightPhone})
+	}
+	if v, ok := shared(a.domains, b.domains); ok {
+		m.Reasons = append(m.Reasons, Reason{Field: "domain", Value: v, Score: weightDomain})
+	}
+	if a.name != "" && b.name != "" {
+		if a.name == b.name {
+			m.Reasons = append(m.Reasons, Reason{Field: "name", Value: a.name, Score: weightNameExact})
+		} else if sim := Similarity(a.name, b.name); sim >= minNameSimilarity {
+			m.Reasons = append(m.Reasons, Reason{Field: "name", Value: a.name + " ~ " + b.name, Score: round(weightNameFuzzy * sim)})
+		}
+	}
+	if len(m.Reasons) == 0 {
+		return m, false
+	}
+	miss := 1.0
+	for _, r := range m.Reasons {
+		miss *= 1 - r.Score
+	}
+	m.Score = round(1 - miss)
+	return m, true
+}
+
+func shared(a, b []string) (string, bool) {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return x, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Email lower-cases an address; anything without a domain is dropped.
+func Email(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if at := strings.LastIndexByte(v, '@'); at <= 0 || at == len(v)-1 {
+		return ""
+	}
+	return v
+}
+
+// Phone returns an E.164 number ("+39..."). National numbers get
+// callingCode; numbers too short to be real are dropped.
+func Phone(v, callingCode string) string {
+	v = strings.TrimSpace(v)
+	plus := strings.HasPrefix(v, "+")
+	var digits strings.Builder
+	for _, r := range v {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	d := digits.String()
+	switch {
+	case plus:
+	case strings.HasPrefix(d, "00"):
+		d = d[2:]
+	case callingCode != "":
+		d = strings.TrimPrefix(callingCode, "+") + d
+	}
+	if len(d) < 8 || len(d) > 15 {
+		return ""
+	}
+	return "+" + d
+}
+
+// Domain reduces a website URL or e-mail address to its host name.
+func Domain(v string) string {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if at := strings.LastIndexByte(v, '@'); at >= 0 {
+		v = v[at+1:]
+	}
+	if i := strings.Index(v, "://"); i >= 0 {
+		v = v[i+3:]
+	}
+	if i := strings.IndexAny(v, "/?#:"); i >= 0 {
+		v = v[:i]
+	}
+	v = strings.TrimPrefix(v, "www.")
+	if !strings.Contains(v, ".") {
+		return ""
+	}
+	return v
+}
+
+// TaxID keeps letters and digits, upper-cased, so "IT 0123-456" and
+// "it0123456" compare equal.
+func TaxID(v string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(v) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// legalForms are dropped from company names before comparing.
+var legalForms = map[string]bool{
+	"srl": true, "srls": true, "spa": true, "snc": true, "sas": true, "sapa": true,
+	"gmbh": true, "ag": true, "kg": true, "ltd": true, "limited": true, "llc": true,
+	"inc": true, "corp": true, "co": true, "company": true, "sa": true, "sarl": true,
+	"bv": true, "nv": true, "plc": true, "the": true,
+}
+
+var accents = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ä", "a", "ã", "a",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ò", "o", "ó", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ß", "ss",
+)
+
+// Name lower-cases, strips accents, punctuation and legal forms, and sorts
+// the remaining words so word order does not matter.
+func Name(v string) string {
+	v = accents.Replace(strings.ToLower(v))
+	// Join dotted abbreviations ("s.r.l.") before splitting.
+	v = strings.ReplaceAll(v, ".", "")
+	words := strings.FieldsFunc(v, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+	kept := words[:0]
+	for _, w := range words {
+		if !legalForms[w] {
+			kept = append(kept, w)
+		}
+	}
+	sort.Strings(kept)
+	return strings.Join(kept, " ")
+}
+
+// Similarity is the Jaro-Winkler similarity of two strings, from 0 to 1.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	window := max(len(ra), len(rb))/2 - 1
+	window = max(window, 0)
+	matchedA := make([]bool, len(ra))
+	matchedB := make([]bool, len(rb))
+	matches := 0
+	for i := range ra {
+		lo, hi := max(0, i-window), min(len(rb), i+window+1)
+		for j := lo; j < hi; j++ {
+			if !matchedB[j] && ra[i] == rb[j] {
+				matchedA[i], matchedB[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	transpositions := 0
+	j := 0
+	for i := range ra {
+		if !matchedA[i] {
+			continue
+		}
+		for !matchedB[j] {
+			j++
+		}
+		if ra[i] != rb[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	jaro := (m/float64(len(ra)) + m/float64(len(rb)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+func round(v float64) float64 {
+	return float64(int(v*1000+0.5)) / 1000
+}
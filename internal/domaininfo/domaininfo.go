@@ -0,0 +1,95 @@
+package domaininfo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Info is what a registry lookup tells us about a registered domain.
+type Info struct {
+	Name      string     `json:"name"`
+	Registrar string     `json:"registrar"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Source    string     `json:"source"`
+}
+
+// Lookup resolves registration data for a domain name.
+type Lookup interface {
+	Lookup(ctx context.Context, domain string) (Info, error)
+}
+
+var ErrNotFound = errors.New("domain not found")
+
+// Chain tries each lookup in order and returns the first answer carrying an
+// expiry date.
+type Chain []Lookup
+
+func (c Chain) Lookup(ctx context.Context, domain string) (Info, error) {
+	var lastErr error
+	for _, l := range c {
+		if l == nil {
+			continue
+		}
+		info, err := l.Lookup(ctx, domain)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if info.ExpiresAt != nil {
+			return info, nil
+		}
+		lastErr = errors.New("no expiry date in response")
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no domain lookup configured")
+	}
+	return Info{}, lastErr
+}
+
+// Normalize strips schemes, paths and "www." so "https://www.Example.com/x"
+// becomes "example.com".
+func Normalize(name string) string {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if i := strings.Index(n, "://"); i >= 0 {
+		n = n[i+3:]
+	}
+	if i := strings.IndexAny(n, "/?#"); i >= 0 {
+		n = n[:i]
+	}
+	if i := strings.LastIndex(n, "@"); i >= 0 {
+		n = n[i+1:]
+	}
+	if i := strings.Index(n, ":"); i >= 0 {
+		n = n[:i]
+	}
+	n = strings.TrimPrefix(n, "www.")
+	return strings.TrimSuffix(n, ".")
+}
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006.01.02",
+	"02-Jan-2006",
+	"2006/01/02",
+	"02.01.2006",
+}
+
+func parseDate(v string) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if i := strings.Index(v, " ("); i > 0 {
+		v = v[:i]
+	}
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
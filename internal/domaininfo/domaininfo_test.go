@@ -0,0 +1,105 @@
+package domaininfo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"example.com":                      "example.com",
+		" Example.COM ":                    "example.com",
+		"https://www.Example.com/x":        "example.com",
+		"http://example.com:8080/?q=1":     "example.com",
+		"www.example.com.":                 "example.com",
+		"mailto:info@example.com":          "example.com",
+		"https://user:pw@shop.example.com": "shop.example.com",
+		"example.com#top":                  "example.com",
+		"":                                 "",
+	} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)
+	for _, in := range []string{
+		"2026-03-07",
+		"2026-03-07T00:00:00Z",
+		"2026-03-07T00:00:00+0000",
+		"2026-03-07T00:00:00",
+		"2026-03-07 00:00:00",
+		"2026.03.07",
+		"07-Mar-2026",
+		"2026/03/07",
+		"07.03.2026",
+		" 2026-03-07 (YYYY-MM-DD)",
+	} {
+		got, ok := parseDate(in)
+		if !ok || !got.Equal(want) {
+			t.Errorf("parseDate(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	got, ok := parseDate("2026-03-07T10:00:00+02:00")
+	if !ok || !got.Equal(time.Date(2026, 3, 7, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseDate with offset = %v, %v", got, ok)
+	}
+	for _, in := range []string{"", "never", "03/07/2026"} {
+		if _, ok := parseDate(in); ok {
+			t.Errorf("parseDate(%q) succeeded", in)
+		}
+	}
+}
+
+type lookupFunc func(ctx context.Context, domain string) (Info, error)
+
+func (f lookupFunc) Lookup(ctx context.Context, domain string) (Info, error) { return f(ctx, domain) }
+
+func TestChain(t *testing.T) {
+	expires := time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)
+	found := lookupFunc(func(context.Context, string) (Info, error) {
+		return Info{Name: "example.com", ExpiresAt: &expires, Source: "second"}, nil
+	})
+	missing := lookupFunc(func(context.Context, string) (Info, error) { return Info{}, ErrNotFound })
+	undated := lookupFunc(func(context.Context, string) (Info, error) { return Info{Name: "example.com"}, nil })
+	down := errors.New("connection refused")
+	failing := lookupFunc(func(context.Context, string) (Info, error) { return Info{}, down })
+
+	tests := []struct {
+		name       string
+		chain      Chain
+		wantSource string
+		wantErr    error  // matched with errors.Is
+		wantMsg    string // for errors without a sentinel
+	}{
+		{name: "falls through to the next lookup", chain: Chain{missing, nil, found}, wantSource: "second"},
+		{name: "skips answers without an expiry", chain: Chain{undated, found}, wantSource: "second"},
+		{name: "reports the last error", chain: Chain{failing, missing}, wantErr: ErrNotFound},
+		{name: "keeps the cause of a failure", chain: Chain{missing, failing}, wantErr: down},
+		{name: "no expiry anywhere", chain: Chain{undated}, wantMsg: "no expiry date in response"},
+		{name: "empty", chain: Chain{nil}, wantMsg: "no domain lookup configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := tt.chain.Lookup(context.Background(), "example.com")
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMsg != "":
+				if err == nil || err.Error() != tt.wantMsg {
+					t.Fatalf("err = %v, want %q", err, tt.wantMsg)
+				}
+			case err != nil:
+				t.Fatal(err)
+			case info.Source != tt.wantSource:
+				t.Errorf("source = %q, want %q", info.Source, tt.wantSource)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package domaininfo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RDAPClient queries an RDAP service (RFC 9083). BaseURL defaults to the
+// rdap.org bootstrap redirector; point it at a local server in tests.
+type RDAPClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+type rdapResponse struct {
+	LDHName string `json:"ldhName"`
+	Events  []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string `json:"roles"`
+		VCardArray []any    `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+func (c RDAPClient) Lookup(ctx context.Context, domain string) (Info, error) {
+	base := strings.TrimRight(strings.TrimSpace(c.BaseURL), "/")
+	if base == "" {
+		base = "https://rdap.org"
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	name := Normalize(domain)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/domain/"+url.PathEscape(name), nil)
+	if err != nil {
+		return Info{}, err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return Info{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return Info{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Info{}, fmt.Errorf("rdap: unexpected status %d", resp.StatusCode)
+	}
+
+	var body rdapResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return Info{}, fmt.Errorf("rdap: %w", err)
+	}
+
+	info := Info{Name: name, Source: "rdap"}
+	if body.LDHName != "" {
+		info.Name = strings.ToLower(body.LDHName)
+	}
+	for _, ev := range body.Events {
+		t, ok := parseDate(ev.Date)
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(ev.Action) {
+		case "expiration":
+			info.ExpiresAt = &t
+		case "registration":
+			info.CreatedAt = &t
+		}
+	}
+	for _, ent := range body.Entities {
+		isRegistrar := false
+		for _, role := range ent.Roles {
+			if strings.EqualFold(role, "registrar") {
+				isRegistrar = true
+				break
+			}
+		}
+		if isRegistrar {
+			info.Registrar = vcardFN(ent.VCardArray)
+			break
+		}
+	}
+	return info, nil
+}
+
+// vcardFN extracts the "fn" property from a jCard (RFC 7095) array:
+// ["vcard", [["fn", {}, "text", "Registrar Inc."], ...]].
+func vcardFN(card []any) string {
+	if len(card) < 2 {
+		return ""
+	}
+	props, ok := card[1].([]any)
+	if !ok {
+		return ""
+	}
+	for _, p := range props {
+		fields, ok := p.([]any)
+		if !ok || len(fields) < 4 {
+			continue
+		}
+		if name, _ := fields[0].(string); strings.EqualFold(name, "fn") {
+			v, _ := fields[3].(string)
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
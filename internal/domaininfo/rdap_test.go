@@ -0,0 +1,84 @@
+package domaininfo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const rdapExample = `{
+  "objectClassName": "domain",
+  "ldhName": "EXAMPLE.COM",
+  "events": [
+    {"eventAction": "registration", "eventDate": "1995-08-14T04:00:00Z"},
+    {"eventAction": "expiration", "eventDate": "2026-08-13T04:00:00Z"},
+    {"eventAction": "last update of RDAP database", "eventDate": "soon"}
+  ],
+  "entities": [
+    {"roles": ["technical"], "vcardArray": ["vcard", [["fn", {}, "text", "Someone Else"]]]},
+    {"roles": ["Registrar"], "vcardArray": ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", " RESERVED-Internet Assigned Numbers Authority "]]]}
+  ]
+}`
+
+func TestRDAPClient(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/domain/example.com":
+			w.Header().Set("Content-Type", "application/rdap+json")
+			w.Write([]byte(rdapExample))
+		case "/domain/broken.com":
+			w.Write([]byte("<html>"))
+		case "/domain/busy.com":
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	c := RDAPClient{BaseURL: srv.URL + "/", HTTPClient: srv.Client()}
+	ctx := context.Background()
+
+	info, err := c.Lookup(ctx, "https://www.Example.com/about")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paths[0] != "/domain/example.com" {
+		t.Errorf("requested %q", paths[0])
+	}
+	created := time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC)
+	expires := time.Date(2026, 8, 13, 4, 0, 0, 0, time.UTC)
+	if info.Name != "example.com" || info.Source != "rdap" || info.Registrar != "RESERVED-Internet Assigned Numbers Authority" ||
+		info.CreatedAt == nil || !info.CreatedAt.Equal(created) || info.ExpiresAt == nil || !info.ExpiresAt.Equal(expires) {
+		t.Errorf("info = %+v", info)
+	}
+
+	if _, err := c.Lookup(ctx, "unregistered.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unregistered: err = %v, want ErrNotFound", err)
+	}
+	if _, err := c.Lookup(ctx, "busy.com"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("busy: err = %v, want a status error", err)
+	}
+	if _, err := c.Lookup(ctx, "broken.com"); err == nil {
+		t.Error("broken: want a decoding error")
+	}
+}
+
+func TestChainFallsBackFromRDAPToWHOIS(t *testing.T) {
+	rdap := httptest.NewServer(http.NotFoundHandler())
+	defer rdap.Close()
+	addr := whoisServer(t, "Domain Name: example.it\nExpiry Date: 2026-03-07\n")
+
+	chain := Chain{RDAPClient{BaseURL: rdap.URL, HTTPClient: rdap.Client()}, WHOISClient{Addr: addr, Timeout: time.Second}}
+	info, err := chain.Lookup(context.Background(), "example.it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Source != "whois" || info.ExpiresAt == nil || !info.ExpiresAt.Equal(time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("info = %+v", info)
+	}
+}
@@ -0,0 +1,130 @@
+package domaininfo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// WHOISClient speaks the plain port-43 WHOIS protocol (RFC 3912) against a
+// single server, e.g. "whois.nic.it:43". Registries format responses
+// differently, so parsing is best-effort over the common field names.
+type WHOISClient struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+var (
+	whoisExpiryKeys = []string{
+		"registry expiry date",
+		"registrar registration expiration date",
+		"expiration date",
+		"expiry date",
+		"expire date",
+		"expires",
+		"expires on",
+		"paid-till",
+	}
+	whoisCreatedKeys = []string{
+		"creation date",
+		"created",
+		"registered on",
+		"registration time",
+	}
+)
+
+func (c WHOISClient) Lookup(ctx context.Context, domain string) (Info, error) {
+	addr := strings.TrimSpace(c.Addr)
+	if addr == "" {
+		return Info{}, fmt.Errorf("whois: server address is not configured")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "43")
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	name := Normalize(domain)
+
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return Info{}, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := io.WriteString(conn, name+"\r\n"); err != nil {
+		return Info{}, err
+	}
+	return parseWHOIS(name, io.LimitReader(conn, 1<<20))
+}
+
+func parseWHOIS(name string, r io.Reader) (Info, error) {
+	info := Info{Name: name, Source: "whois"}
+	sc := bufio.NewScanner(r)
+	section := ""
+	for sc.Scan() {
+		raw := sc.Text()
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "%") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lower := strings.ToLower(line)
+		if strings.HasPrefix(lower, "no match") || strings.HasPrefix(lower, "not found") || strings.HasPrefix(lower, "no entries found") {
+			return Info{}, ErrNotFound
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key == "status" && strings.EqualFold(value, "available") {
+			return Info{}, ErrNotFound
+		}
+		// Some registries (e.g. .it) nest registrar details under a
+		// "Registrar" header followed by indented "Name:" lines.
+		if value == "" && !strings.HasPrefix(raw, " ") {
+			section = key
+			continue
+		}
+		if !strings.HasPrefix(raw, " ") && !strings.HasPrefix(raw, "\t") {
+			section = ""
+		}
+
+		switch {
+		case key == "registrar" && info.Registrar == "":
+			info.Registrar = value
+		case section == "registrar" && key == "name" && info.Registrar == "":
+			info.Registrar = value
+		case info.ExpiresAt == nil && containsKey(whoisExpiryKeys, key):
+			if t, ok := parseDate(value); ok {
+				info.ExpiresAt = &t
+			}
+		case info.CreatedAt == nil && containsKey(whoisCreatedKeys, key):
+			if t, ok := parseDate(value); ok {
+				info.CreatedAt = &t
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Info{}, err
+	}
+	return info, nil
+}
+
+func containsKey(keys []string, k string) bool {
+	for _, v := range keys {
+		if v == k {
+			return true
+		}
+	}
+	return false
+}
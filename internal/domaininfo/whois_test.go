@@ -0,0 +1,156 @@
+package domaininfo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// whoisServer answers every query on a local port with response and returns
+// its address. The query line is checked to be a bare, normalized name.
+func whoisServer(t *testing.T, response string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				query, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil || !strings.HasSuffix(query, "\r\n") || strings.ContainsAny(strings.TrimSpace(query), "/: ") {
+					conn.Write([]byte("% bad query\n"))
+					return
+				}
+				conn.Write([]byte(response))
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestWHOISClient(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	tests := []struct {
+		name          string
+		response      string
+		wantRegistrar string
+		wantCreated   time.Time
+		wantExpires   time.Time
+		wantErr       error
+	}{
+		{
+			name: "gTLD registry",
+			response: "   Domain Name: EXAMPLE.COM\r\n" +
+				"   Registrar: Example Registrar, Inc.\r\n" +
+				"   Creation Date: 1995-08-14T04:00:00Z\r\n" +
+				"   Registry Expiry Date: 2026-08-13T04:00:00Z\r\n" +
+				"   Registrar Registration Expiration Date: 2027-01-01\r\n",
+			wantRegistrar: "Example Registrar, Inc.",
+			wantCreated:   time.Date(1995, 8, 14, 4, 0, 0, 0, time.UTC),
+			wantExpires:   time.Date(2026, 8, 13, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "nested registrar section",
+			response: "*********************************************************************\n" +
+				"% Use of this service is limited\n" +
+				"Domain:             example.it\n" +
+				"Status:             ok\n" +
+				"Created:            2001-05-10 00:00:00\n" +
+				"Expire Date:        2026-03-07\n" +
+				"\n" +
+				"Registrar\n" +
+				"Registrar:\n" +
+				"  Organization:     Registrar S.p.A.\n" +
+				"  Name:             REGISTRAR-REG\n" +
+				"\n" +
+				"Nameservers\n" +
+				"  Name:             ns1.example.it\n",
+			wantRegistrar: "REGISTRAR-REG",
+			wantCreated:   day(2001, 5, 10),
+			wantExpires:   day(2026, 3, 7),
+		},
+		{
+			name:        "dates with notes",
+			response:    "domain: EXAMPLE.RU\nexpires: 07.03.2026\npaid-till: 2026.03.07 (renewal due)\n",
+			wantExpires: day(2026, 3, 7),
+		},
+		{
+			name:     "unparsable expiry is left unset",
+			response: "Domain Name: example.org\nExpiry Date: in a while\n",
+		},
+		{name: "no match", response: "No match for \"EXAMPLE.COM\".\n", wantErr: ErrNotFound},
+		{name: "not found", response: "% comment\nNOT FOUND\n", wantErr: ErrNotFound},
+		{name: "available", response: "Domain: example.it\nStatus: AVAILABLE\n", wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := WHOISClient{Addr: whoisServer(t, tt.response), Timeout: time.Second}
+			info, err := c.Lookup(context.Background(), "https://www.Example.com/")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Name != "example.com" || info.Source != "whois" || info.Registrar != tt.wantRegistrar {
+				t.Errorf("info = %+v", info)
+			}
+			if !sameDate(info.CreatedAt, tt.wantCreated) {
+				t.Errorf("created = %v, want %v", info.CreatedAt, tt.wantCreated)
+			}
+			if !sameDate(info.ExpiresAt, tt.wantExpires) {
+				t.Errorf("expires = %v, want %v", info.ExpiresAt, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestWHOISClientErrors(t *testing.T) {
+	ctx := context.Background()
+	if _, err := (WHOISClient{}).Lookup(ctx, "example.com"); err == nil {
+		t.Error("no address: want an error")
+	}
+
+	// A server that never answers runs into the timeout.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	start := time.Now()
+	if _, err := (WHOISClient{Addr: ln.Addr().String(), Timeout: 100 * time.Millisecond}).Lookup(ctx, "example.com"); err == nil {
+		t.Error("silent server: want a timeout")
+	}
+	if time.Since(start) > 900*time.Millisecond {
+		t.Error("timeout was not applied")
+	}
+}
+
+// sameDate compares an optional time with want, the zero time meaning unset.
+func sameDate(got *time.Time, want time.Time) bool {
+	if want.IsZero() {
+		return got == nil
+	}
+	return got != nil && got.Equal(want)
+}
@@ -0,0 +1,52 @@
+package fx
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ECBDailyURL is the ECB euro foreign exchange reference rate feed. The
+// 90-day and historical files (eurofxref-hist-90d.xml, eurofxref-hist.xml)
+// share its format.
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB reads an ECB reference-rate XML file. All rates are quoted
+// against EUR.
+func ParseECB(r io.Reader) ([]Rate, error) {
+	var env ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&env); err != nil {
+		return nil, err
+	}
+	out := make([]Rate, 0)
+	for _, day := range env.Days {
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(day.Time))
+		if err != nil {
+			continue
+		}
+		for _, cr := range day.Rates {
+			v, err := strconv.ParseFloat(strings.TrimSpace(cr.Rate), 64)
+			if err != nil || v <= 0 {
+				continue
+			}
+			out = append(out, Rate{Date: date, Base: "EUR", Quote: Code(cr.Currency), Rate: v})
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no exchange rates found in file")
+	}
+	return out, nil
+}
@@ -0,0 +1,173 @@
+// Package fx converts amounts between currencies using dated reference rates.
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ECBBase is the currency every ECB reference rate is quoted against; it is
+// tried first when crossing two currencies.
+const ECBBase = "EUR"
+
+// ErrNoRate is returned when no stored rate links two currencies.
+var ErrNoRate = errors.New("no exchange rate")
+
+// Rate says that on Date one unit of Base buys Rate units of Quote.
+type Rate struct {
+	Date  time.Time
+	Base  string
+	Quote string
+	Rate  float64
+}
+
+type pair struct{ base, quote string }
+
+type datedRate struct {
+	day  time.Time
+	rate float64
+}
+
+// Converter answers conversions from a fixed set of rates. Pairs are usable in
+// both directions, and currencies without a direct pair are crossed through a
+// currency both are paired with (ECB rates are all quoted against EUR).
+type Converter struct {
+	rates  map[pair][]datedRate
+	pivots []string // every currency in a pair; ECBBase first, then sorted
+}
+
+func NewConverter(rates []Rate) *Converter {
+	c := &Converter{rates: make(map[pair][]datedRate)}
+	for _, r := range rates {
+		if r.Rate <= 0 {
+			continue
+		}
+		k := pair{Code(r.Base), Code(r.Quote)}
+		if k.base == "" || k.quote == "" || k.base == k.quote {
+			continue
+		}
+		c.rates[k] = append(c.rates[k], datedRate{day: Day(r.Date), rate: r.Rate})
+		for _, code := range []string{k.base, k.quote} {
+			if !slices.Contains(c.pivots, code) {
+				c.pivots = append(c.pivots, code)
+			}
+		}
+	}
+	for k := range c.rates {
+		list := c.rates[k]
+		sort.Slice(list, func(i, j int) bool { return list[i].day.Before(list[j].day) })
+	}
+	slices.SortFunc(c.pivots, func(a, b string) int {
+		switch {
+		case a == b:
+			return 0
+		case a == ECBBase:
+			return -1
+		case b == ECBBase:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return c
+}
+
+// Convert turns amount in from into to using the rates in effect at.
+func (c *Converter) Convert(amount float64, from, to string, at time.Time) (float64, error) {
+	r, err := c.Rate(from, to, at)
+	if err != nil {
+		return 0, err
+	}
+	return Round(amount * r), nil
+}
+
+// Rate returns how many units of to one unit of from buys at the given time.
+// The latest rate published on or before that day is used; dates before the
+// first known rate are an error rather than a guess from a later one.
+// Without a direct pair the currencies are crossed through the first
+// currency, in a fixed order, that links both.
+func (c *Converter) Rate(from, to string, at time.Time) (float64, error) {
+	from, to = Code(from), Code(to)
+	if from == "" || to == "" {
+		return 0, ErrNoRate
+	}
+	if from == to {
+		return 1, nil
+	}
+	r, err := c.direct(from, to, at)
+	if !errors.Is(err, ErrNoRate) {
+		return r, err
+	}
+	for _, pivot := range c.pivots {
+		if pivot == from || pivot == to {
+			continue
+		}
+		r1, err1 := c.direct(from, pivot, at)
+		if err1 != nil {
+			err = moreSpecific(err, err1)
+			continue
+		}
+		r2, err2 := c.direct(pivot, to, at)
+		if err2 != nil {
+			err = moreSpecific(err, err2)
+			continue
+		}
+		return r1 * r2, nil
+	}
+	return 0, err
+}
+
+// moreSpecific prefers an error saying a rate was too early over ErrNoRate.
+func moreSpecific(cur, next error) error {
+	if errors.Is(cur, ErrNoRate) {
+		return next
+	}
+	return cur
+}
+
+func (c *Converter) direct(from, to string, at time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	if list := c.rates[pair{from, to}]; len(list) > 0 {
+		return lookup(list, from, to, at)
+	}
+	if list := c.rates[pair{to, from}]; len(list) > 0 {
+		r, err := lookup(list, to, from, at)
+		if err != nil {
+			return 0, err
+		}
+		return 1 / r, nil
+	}
+	return 0, ErrNoRate
+}
+
+func lookup(list []datedRate, base, quote string, at time.Time) (float64, error) {
+	day := Day(at)
+	i := sort.Search(len(list), func(i int) bool { return list[i].day.After(day) })
+	if i == 0 {
+		return 0, fmt.Errorf("no %s/%s rate on or before %s, the first is from %s", base, quote, day.Format("2006-01-02"), list[0].day.Format("2006-01-02"))
+	}
+	return list[i-1].rate, nil
+}
+
+// Code normalizes a currency code ("eur " -> "EUR").
+func Code(v string) string {
+	return strings.ToUpper(strings.TrimSpace(v))
+}
+
+// Day truncates t to its calendar day in UTC, the granularity rates are
+// published at.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Round rounds to cents.
+func Round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,90 @@
+package fx
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestConverterRate(t *testing.T) {
+	ecb := []Rate{
+		{Date: day("2024-01-02"), Base: "EUR", Quote: "USD", Rate: 1.10},
+		{Date: day("2024-01-02"), Base: "EUR", Quote: "GBP", Rate: 0.86},
+		{Date: day("2024-02-01"), Base: "EUR", Quote: "USD", Rate: 1.08},
+	}
+	// The same market stored the other way round: both pairs are quoted in
+	// EUR, so crossing USD and GBP has to invert both legs.
+	inverted := []Rate{
+		{Date: day("2024-01-02"), Base: "USD", Quote: "EUR", Rate: 0.90},
+		{Date: day("2024-01-02"), Base: "GBP", Quote: "EUR", Rate: 1.15},
+	}
+	// Neither pair touches EUR; CHF is the only currency linking them.
+	other := []Rate{
+		{Date: day("2024-01-02"), Base: "USD", Quote: "CHF", Rate: 0.85},
+		{Date: day("2024-01-02"), Base: "JPY", Quote: "CHF", Rate: 0.006},
+	}
+
+	tests := []struct {
+		name     string
+		rates    []Rate
+		from, to string
+		at       string
+		want     float64
+		wantErr  string // substring; "" for none
+		noRate   bool   // the error is ErrNoRate
+	}{
+		{name: "same currency", rates: ecb, from: "usd", to: " USD", at: "2023-01-01", want: 1},
+		{name: "direct", rates: ecb, from: "EUR", to: "USD", at: "2024-01-15", want: 1.10},
+		{name: "latest rate on or before the day", rates: ecb, from: "EUR", to: "USD", at: "2024-02-01", want: 1.08},
+		{name: "inverse", rates: ecb, from: "USD", to: "EUR", at: "2024-01-15", want: 1 / 1.10},
+		{name: "cross through the base", rates: ecb, from: "USD", to: "GBP", at: "2024-01-15", want: 0.86 / 1.10},
+		{name: "cross through a quote", rates: inverted, from: "USD", to: "GBP", at: "2024-01-15", want: 0.90 / 1.15},
+		{name: "cross back through a quote", rates: inverted, from: "GBP", to: "USD", at: "2024-01-15", want: 1.15 / 0.90},
+		{name: "cross without EUR", rates: other, from: "USD", to: "JPY", at: "2024-01-15", want: 0.85 / 0.006},
+		{name: "before the first rate", rates: ecb, from: "EUR", to: "USD", at: "2024-01-01", wantErr: "the first is from 2024-01-02"},
+		{name: "cross before the first rate", rates: inverted, from: "USD", to: "GBP", at: "2023-12-31", wantErr: "the first is from 2024-01-02"},
+		{name: "unknown currency", rates: ecb, from: "EUR", to: "JPY", at: "2024-01-15", noRate: true},
+		{name: "no rates", from: "EUR", to: "USD", at: "2024-01-15", noRate: true},
+		{name: "empty code", rates: ecb, from: "", to: "USD", at: "2024-01-15", noRate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConverter(tt.rates).Rate(tt.from, tt.to, day(tt.at))
+			switch {
+			case tt.noRate:
+				if !errors.Is(err, ErrNoRate) {
+					t.Fatalf("err = %v, want ErrNoRate", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || errors.Is(err, ErrNoRate) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("err = %v", err)
+			case math.Abs(got-tt.want) > 1e-12:
+				t.Errorf("rate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConverterConvertRounds(t *testing.T) {
+	c := NewConverter([]Rate{{Date: day("2024-01-02"), Base: "EUR", Quote: "USD", Rate: 1.0857}})
+	got, err := c.Convert(99.99, "EUR", "USD", day("2024-01-02"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 108.56 {
+		t.Errorf("Convert = %v, want 108.56", got)
+	}
+}
@@ -0,0 +1,157 @@
+// Package ical reads and writes RFC 5545 calendars: escaping, CRLF line
+// endings and 75-octet line folding.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type Component string
+
+const (
+	Event Component = "VEVENT"
+	Todo  Component = "VTODO"
+)
+
+// Item is one calendar entry. AllDay items use DATE values; others use UTC
+// date-times. For a Todo, Start is written as DUE.
+type Item struct {
+	Component   Component
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	Start       time.Time
+	Duration    time.Duration
+	AllDay      bool
+	Completed   bool
+	Stamp       time.Time
+}
+
+type Calendar struct {
+	Name  string
+	Items []Item
+}
+
+func (c Calendar) WriteTo(w io.Writer) (int64, error) {
+	lw := &lineWriter{w: bufio.NewWriter(w)}
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:-//WeMadeIt//CRM//EN")
+	lw.line("CALSCALE:GREGORIAN")
+	lw.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + Escape(c.Name))
+	}
+	for _, it := range c.Items {
+		it.write(lw)
+	}
+	lw.line("END:VCALENDAR")
+	if lw.err == nil {
+		lw.err = lw.w.Flush()
+	}
+	return lw.n, lw.err
+}
+
+func (it Item) write(lw *lineWriter) {
+	comp := it.Component
+	if comp == "" {
+		comp = Event
+	}
+	lw.line("BEGIN:" + string(comp))
+	lw.line("UID:" + Escape(it.UID))
+	stamp := it.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	lw.line("DTSTAMP:" + utc(stamp))
+	lw.line("SUMMARY:" + Escape(it.Summary))
+	if it.Description != "" {
+		lw.line("DESCRIPTION:" + Escape(it.Description))
+	}
+	if len(it.Categories) > 0 {
+		cats := make([]string, len(it.Categories))
+		for i, c := range it.Categories {
+			cats[i] = Escape(c)
+		}
+		lw.line("CATEGORIES:" + strings.Join(cats, ","))
+	}
+
+	switch comp {
+	case Todo:
+		if it.AllDay {
+			lw.line("DUE;VALUE=DATE:" + it.Start.Format("20060102"))
+		} else {
+			lw.line("DUE:" + utc(it.Start))
+		}
+		if it.Completed {
+			lw.line("STATUS:COMPLETED")
+		} else {
+			lw.line("STATUS:NEEDS-ACTION")
+		}
+	default:
+		if it.AllDay {
+			lw.line("DTSTART;VALUE=DATE:" + it.Start.Format("20060102"))
+			lw.line("DTEND;VALUE=DATE:" + it.Start.AddDate(0, 0, 1).Format("20060102"))
+		} else {
+			d := it.Duration
+			if d <= 0 {
+				d = 30 * time.Minute
+			}
+			lw.line("DTSTART:" + utc(it.Start))
+			lw.line("DTEND:" + utc(it.Start.Add(d)))
+		}
+		lw.line("TRANSP:TRANSPARENT")
+	}
+	lw.line("END:" + string(comp))
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+// Escape escapes a TEXT value (RFC 5545 3.3.11).
+func Escape(v string) string {
+	return textEscaper.Replace(v)
+}
+
+func utc(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+type lineWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+// line writes a content line folded at 75 octets without splitting UTF-8
+// sequences.
+func (lw *lineWriter) line(s string) {
+	if lw.err != nil {
+		return
+	}
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		lw.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts.
+		limit = 74
+	}
+	lw.write(s + "\r\n")
+}
+
+func (lw *lineWriter) write(s string) {
+	if lw.err != nil {
+		return
+	}
+	n, err := lw.w.WriteString(s)
+	lw.n += int64(n)
+	lw.err = err
+}
@@ -0,0 +1,293 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsed is a VEVENT read from a calendar file. Recurring events are read as
+// their first occurrence; overridden occurrences carry RecurrenceID.
+type Parsed struct {
+	UID          string
+	RecurrenceID string
+	Summary      string
+	Description  string
+	Location     string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Organizer    string
+	Attendees    []string
+}
+
+// Key identifies the event across re-imports.
+func (p Parsed) Key() string {
+	if p.RecurrenceID != "" {
+		return p.UID + "#" + p.RecurrenceID
+	}
+	return p.UID
+}
+
+// Duration is End-Start, or zero for open-ended events.
+func (p Parsed) Duration() time.Duration {
+	if p.End.After(p.Start) {
+		return p.End.Sub(p.Start)
+	}
+	return 0
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the VEVENTs of an iCalendar stream. Events without UID or
+// DTSTART are skipped; other components (VTODO, VTIMEZONE, VALARM) are
+// ignored. Floating times and unknown TZIDs are read in loc.
+func Parse(r io.Reader, loc *time.Location) ([]Parsed, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Parsed, 0)
+	var cur *Parsed
+	var duration string
+	depth := 0 // nesting inside the current VEVENT (VALARM etc.)
+	seenCalendar := false
+	for _, line := range lines {
+		p, ok := parseLine(line)
+		if !ok {
+			continue
+		}
+		switch p.name {
+		case "BEGIN":
+			v := strings.ToUpper(p.value)
+			if v == "VCALENDAR" {
+				seenCalendar = true
+			}
+			if cur != nil {
+				depth++
+			} else if v == "VEVENT" {
+				cur = &Parsed{}
+				duration = ""
+			}
+			continue
+		case "END":
+			if cur == nil {
+				continue
+			}
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if strings.ToUpper(p.value) == "VEVENT" {
+				if cur.End.IsZero() && duration != "" {
+					if d, err := parseDuration(duration); err == nil {
+						cur.End = cur.Start.Add(d)
+					}
+				}
+				if cur.End.IsZero() && cur.AllDay {
+					cur.End = cur.Start.AddDate(0, 0, 1)
+				}
+				if cur.UID != "" && !cur.Start.IsZero() {
+					out = append(out, *cur)
+				}
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil || depth > 0 {
+			continue
+		}
+		switch p.name {
+		case "UID":
+			cur.UID = strings.TrimSpace(p.value)
+		case "RECURRENCE-ID":
+			if t, _, err := parseTime(p, loc); err == nil {
+				cur.RecurrenceID = t.UTC().Format("20060102T150405Z")
+			}
+		case "SUMMARY":
+			cur.Summary = unescape(p.value)
+		case "DESCRIPTION":
+			cur.Description = unescape(p.value)
+		case "LOCATION":
+			cur.Location = unescape(p.value)
+		case "STATUS":
+			cur.Status = strings.ToUpper(strings.TrimSpace(p.value))
+		case "DTSTART":
+			if t, allDay, err := parseTime(p, loc); err == nil {
+				cur.Start, cur.AllDay = t, allDay
+			}
+		case "DTEND":
+			if t, _, err := parseTime(p, loc); err == nil {
+				cur.End = t
+			}
+		case "DURATION":
+			duration = p.value
+		case "ORGANIZER":
+			cur.Organizer = mailAddress(p.value)
+		case "ATTENDEE":
+			if a := mailAddress(p.value); a != "" {
+				cur.Attendees = append(cur.Attendees, a)
+			}
+		}
+	}
+	if !seenCalendar {
+		return nil, errors.New("not an iCalendar file")
+	}
+	return out, nil
+}
+
+// unfold joins continuation lines (RFC 5545 3.1).
+func unfold(r io.Reader) ([]string, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var lines []string
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+// parseLine splits NAME;PARAM=V;PARAM="V":VALUE, honouring quoted params.
+func parseLine(line string) (property, bool) {
+	var p property
+	inQuote := false
+	colon := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuote = !inQuote
+		} else if c == ':' && !inQuote {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return p, false
+	}
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	p.name = strings.ToUpper(strings.TrimSpace(parts[0]))
+	p.value = value
+	p.params = make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		k, v, ok := strings.Cut(param, "=")
+		if !ok {
+			continue
+		}
+		p.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return p, true
+}
+
+func parseTime(p property, loc *time.Location) (time.Time, bool, error) {
+	v := strings.TrimSpace(p.value)
+	if strings.EqualFold(p.params["VALUE"], "DATE") || len(v) == 8 {
+		t, err := time.ParseInLocation("20060102", v, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse("20060102T150405Z", v)
+		return t, false, err
+	}
+	if tzid := p.params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	return t, false, err
+}
+
+// parseDuration reads the subset of RFC 5545 durations calendars emit:
+// [+-]P[nW][nD][T[nH][nM][nS]].
+func parseDuration(v string) (time.Duration, error) {
+	v = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(v)), "+")
+	neg := strings.HasPrefix(v, "-")
+	v = strings.TrimPrefix(v, "-")
+	if !strings.HasPrefix(v, "P") {
+		return 0, errors.New("invalid duration")
+	}
+	var d time.Duration
+	num := ""
+	inTime := false
+	for _, c := range v[1:] {
+		switch {
+		case c >= '0' && c <= '9':
+			num += string(c)
+			continue
+		case c == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(num)
+		if err != nil {
+			return 0, errors.New("invalid duration")
+		}
+		num = ""
+		switch {
+		case c == 'W':
+			d += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D':
+			d += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			d += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			d += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			d += time.Duration(n) * time.Second
+		default:
+			return 0, errors.New("invalid duration")
+		}
+	}
+	if neg {
+		d = -d
+	}
+	return d, nil
+}
+
+func unescape(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] == '\\' && i+1 < len(v) {
+			i++
+			switch v[i] {
+			case 'n', 'N':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(v[i])
+			}
+			continue
+		}
+		b.WriteByte(v[i])
+	}
+	return b.String()
+}
+
+// mailAddress extracts the address from a "mailto:" calendar user value.
+func mailAddress(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 7 && strings.EqualFold(v[:7], "mailto:") {
+		v = v[7:]
+	}
+	if !strings.Contains(v, "@") {
+		return ""
+	}
+	return strings.ToLower(v)
+}
@@ -0,0 +1,314 @@
+// Package mailparse reads RFC 5322 messages: headers, plain-text and HTML
+// bodies and attachments, decoding MIME transfer encodings along the way.
+package mailparse
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxDepth bounds nested multiparts (forwarded messages inside messages).
+const maxDepth = 8
+
+type Address struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+	Data        []byte `json:"-"`
+}
+
+type Message struct {
+	MessageID   string       `json:"messageId"`
+	InReplyTo   string       `json:"inReplyTo"`
+	References  []string     `json:"references"`
+	From        Address      `json:"from"`
+	To          []Address    `json:"to"`
+	Cc          []Address    `json:"cc"`
+	Subject     string       `json:"subject"`
+	Date        time.Time    `json:"date"`
+	Text        string       `json:"text"`
+	HTML        string       `json:"html"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Addresses returns From, To and Cc in that order.
+func (m Message) Addresses() []Address {
+	out := make([]Address, 0, 1+len(m.To)+len(m.Cc))
+	if m.From.Email != "" {
+		out = append(out, m.From)
+	}
+	out = append(out, m.To...)
+	return append(out, m.Cc...)
+}
+
+// Body is the plain-text body, derived from the HTML body when the message
+// has no text part.
+func (m Message) Body() string {
+	if strings.TrimSpace(m.Text) != "" {
+		return strings.TrimSpace(m.Text)
+	}
+	return HTMLToText(m.HTML)
+}
+
+var decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse reads a single message.
+func Parse(r io.Reader) (Message, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return Message{}, err
+	}
+	h := msg.Header
+	m := Message{
+		MessageID:  messageID(h.Get("Message-Id")),
+		InReplyTo:  messageID(h.Get("In-Reply-To")),
+		References: messageIDs(h.Get("References")),
+		Subject:    decodeHeader(h.Get("Subject")),
+	}
+	if from := addressList(h, "From"); len(from) > 0 {
+		m.From = from[0]
+	}
+	m.To = addressList(h, "To")
+	m.Cc = addressList(h, "Cc")
+	if d, err := h.Date(); err == nil {
+		m.Date = d
+	}
+
+	if err := m.readPart(h, msg.Body, 0); err != nil {
+		return m, err
+	}
+	if m.Attachments == nil {
+		m.Attachments = make([]Attachment, 0)
+	}
+	return m, nil
+}
+
+// partHeader is satisfied by both mail.Header and the MIME part headers.
+type partHeader interface {
+	Get(key string) string
+}
+
+func (m *Message) readPart(h partHeader, body io.Reader, depth int) error {
+	if depth > maxDepth {
+		return errors.New("message nested too deeply")
+	}
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		boundary := params["boundary"]
+		if boundary == "" {
+			return errors.New("multipart without boundary")
+		}
+		mr := multipart.NewReader(body, boundary)
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := m.readPart(p.Header, p, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("decode %s part: %w", mediaType, err)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := decodeHeader(firstNonEmpty(dparams["filename"], params["name"]))
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if disposition == "attachment" || filename != "" || !isText {
+		m.Attachments = append(m.Attachments, Attachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Size:        len(data),
+			Data:        data,
+		})
+		return nil
+	}
+
+	text := toUTF8(data, params["charset"])
+	// The first part of each kind wins; later ones are usually quoted
+	// alternatives or signatures.
+	switch mediaType {
+	case "text/plain":
+		if m.Text == "" {
+			m.Text = text
+		}
+	case "text/html":
+		if m.HTML == "" {
+			m.HTML = text
+		}
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// newlineStripper drops CR/LF so base64 lines decode as one stream.
+type newlineStripper struct{ r io.Reader }
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	for {
+		c, err := n.r.Read(p)
+		j := 0
+		for _, b := range p[:c] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[j] = b
+				j++
+			}
+		}
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+func decodeHeader(v string) string {
+	out, err := decoder.DecodeHeader(v)
+	if err != nil {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(out)
+}
+
+func addressList(h mail.Header, key string) []Address {
+	out := make([]Address, 0)
+	if strings.TrimSpace(h.Get(key)) == "" {
+		return out
+	}
+	parser := mail.AddressParser{WordDecoder: decoder}
+	list, err := parser.ParseList(h.Get(key))
+	if err != nil {
+		// Fall back to anything that looks like an address.
+		for _, a := range addrPattern.FindAllString(h.Get(key), -1) {
+			out = append(out, Address{Email: strings.ToLower(a)})
+		}
+		return out
+	}
+	for _, a := range list {
+		out = append(out, Address{Name: a.Name, Email: strings.ToLower(a.Address)})
+	}
+	return out
+}
+
+var addrPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+func messageID(v string) string {
+	ids := messageIDs(v)
+	if len(ids) == 0 {
+		return ""
+	}
+	return ids[0]
+}
+
+// messageIDs returns the <...> ids in a header without their angle brackets.
+func messageIDs(v string) []string {
+	out := make([]string, 0)
+	for {
+		start := strings.IndexByte(v, '<')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(v[start:], '>')
+		if end < 0 {
+			break
+		}
+		if id := strings.TrimSpace(v[start+1 : start+end]); id != "" {
+			out = append(out, id)
+		}
+		v = v[start+end+1:]
+	}
+	if len(out) == 0 {
+		if id := strings.TrimSpace(v); id != "" && !strings.ContainsAny(id, " \t") {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// charsetReader covers what the stdlib decoder lacks for the mail we see:
+// Latin-1 and Windows-1252, decoded byte-per-rune.
+func charsetReader(charset string, r io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(toUTF8(data, charset)), nil
+}
+
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "iso-8859-1", "iso-8859-15", "latin1", "windows-1252", "cp1252":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return string(bytes.ToValidUTF8(data, []byte("�")))
+}
+
+var (
+	htmlDropPattern  = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlBreakPattern = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankRunPattern  = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+)
+
+// HTMLToText is a rough rendering of an HTML body for display and search.
+func HTMLToText(s string) string {
+	s = htmlDropPattern.ReplaceAllString(s, "")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	s = blankRunPattern.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
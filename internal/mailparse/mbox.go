@@ -0,0 +1,48 @@
+package mailparse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// SplitMbox splits an mbox stream into raw messages. Separator lines start
+// with "From "; ">From " quoting (mboxrd) is undone.
+func SplitMbox(r io.Reader) ([][]byte, error) {
+	br := bufio.NewReader(r)
+	var out [][]byte
+	var cur *bytes.Buffer
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			case bytes.HasPrefix(line, []byte("From ")):
+				if cur != nil {
+					out = append(out, cur.Bytes())
+				}
+				cur = &bytes.Buffer{}
+			case cur != nil:
+				if unquoted := bytes.TrimLeft(line, ">"); len(unquoted) < len(line) && bytes.HasPrefix(unquoted, []byte("From ")) {
+					line = line[1:]
+				}
+				cur.Write(line)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if cur != nil {
+		out = append(out, cur.Bytes())
+	}
+	return out, nil
+}
+
+// IsMbox reports whether data looks like an mbox file rather than a single
+// message.
+func IsMbox(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, "\r\n"), []byte("From "))
+}
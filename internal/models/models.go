@@ -4,6 +4,8 @@ import "time"
 
 type Organization struct {
 	ID           string    `json:"id"`
+	ParentID     string    `json:"parentId"`
+	OwnerUserID  string    `json:"ownerUserId"`
 	Name         string    `json:"name"`
 	Industry     string    `json:"industry"`
 	Website      string    `json:"website"`
@@ -17,6 +19,33 @@ type Organization struct {
 	Notes        string    `json:"notes"`
 	CreatedAt    time.Time `json:"createdAt"`
 	UpdatedAt    time.Time `json:"updatedAt"`
+
+	CustomFields CustomValues `json:"customFields,omitempty"`
+	LabelIDs     []string     `json:"labelIds,omitempty"`
+}
+
+// AddressType says what an organization uses an address for.
+type AddressType string
+
+const (
+	AddressBilling  AddressType = "billing"
+	AddressShipping AddressType = "shipping"
+	AddressOffice   AddressType = "office"
+)
+
+// Address is one of an organization's postal addresses. The oldest billing
+// address is the one quotations are made out to.
+type Address struct {
+	ID             string      `json:"id"`
+	OrganizationID string      `json:"organizationId"`
+	Type           AddressType `json:"type"`
+	Street         string      `json:"street"`
+	PostalCode     string      `json:"postalCode"`
+	City           string      `json:"city"`
+	Province       string      `json:"province"`
+	Country        string      `json:"country"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
 }
 
 type Contact struct {
@@ -33,6 +62,9 @@ type Contact struct {
 	PrimaryContact bool      `json:"primaryContact"`
 	CreatedAt      time.Time `json:"createdAt"`
 	UpdatedAt      time.Time `json:"updatedAt"`
+
+	CustomFields CustomValues `json:"customFields,omitempty"`
+	LabelIDs     []string     `json:"labelIds,omitempty"`
 }
 
 type DealStatus string
@@ -50,6 +82,9 @@ type Deal struct {
 	PipelineStageID string `json:"pipelineStageId"`
 	Title           string `json:"title"`
 	Description     string `json:"description"`
+	OwnerUserID     string `json:"ownerUserId"`
+	// Shared deals are visible to every salesperson, not only the owner.
+	Shared bool `json:"shared"`
 
 	// Job tracking (mirrors partner's Excel sheet fields).
 	Domain           string     `json:"domain"`
@@ -76,6 +111,29 @@ type Deal struct {
 	LostReason      string     `json:"lostReason"`
 	CreatedAt       time.Time  `json:"createdAt"`
 	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	CustomFields CustomValues `json:"customFields,omitempty"`
+	LabelIDs     []string     `json:"labelIds,omitempty"`
+	// Contacts are everyone involved in the deal and their roles; ContactID
+	// stays the main contact.
+	Contacts []DealContact `json:"contacts,omitempty"`
+}
+
+// DealContactRole is the part a contact plays in a deal.
+type DealContactRole string
+
+const (
+	DealContactDecisionMaker DealContactRole = "decision_maker"
+	DealContactBilling       DealContactRole = "billing"
+	DealContactTechnical     DealContactRole = "technical"
+	DealContactInfluencer    DealContactRole = "influencer"
+	DealContactOther         DealContactRole = "other"
+)
+
+type DealContact struct {
+	DealID    string          `json:"dealId"`
+	ContactID string          `json:"contactId"`
+	Role      DealContactRole `json:"role"`
 }
 
 type PaymentStatus string
@@ -101,6 +159,11 @@ type Payment struct {
 	GilAmount float64 `json:"gilAmount"`
 	RicAmount float64 `json:"ricAmount"`
 
+	// ReportingAmount is Amount converted into the company reporting
+	// currency. ReportingCurrency is empty when no exchange rate was known.
+	ReportingAmount   float64 `json:"reportingAmount"`
+	ReportingCurrency string  `json:"reportingCurrency"`
+
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -179,6 +242,8 @@ type Interaction struct {
 	FollowUpNotes         string          `json:"followUpNotes"`
 	TranscriptionLanguage string          `json:"transcriptionLanguage"`
 	TranscriptionStatus   string          `json:"transcriptionStatus"`
+	ExternalID            string          `json:"externalId"`
+	ThreadID              string          `json:"threadId"`
 	CreatedAt             time.Time       `json:"createdAt"`
 	UpdatedAt             time.Time       `json:"updatedAt"`
 }
@@ -241,6 +306,7 @@ const (
 type Project struct {
 	ID            string        `json:"id"`
 	DealID        string        `json:"dealId"`
+	OwnerUserID   string        `json:"ownerUserId"`
 	Name          string        `json:"name"`
 	Description   string        `json:"description"`
 	Code          string        `json:"code"`
@@ -252,6 +318,28 @@ type Project struct {
 	Currency      string        `json:"currency"`
 	CreatedAt     time.Time     `json:"createdAt"`
 	UpdatedAt     time.Time     `json:"updatedAt"`
+
+	CustomFields CustomValues `json:"customFields,omitempty"`
+	LabelIDs     []string     `json:"labelIds,omitempty"`
+}
+
+// ProjectRole is what a member may do on a project: leads manage the
+// members, contributors can be assigned tasks and viewers only follow along.
+type ProjectRole string
+
+const (
+	ProjectLead        ProjectRole = "lead"
+	ProjectContributor ProjectRole = "contributor"
+	ProjectViewer      ProjectRole = "viewer"
+)
+
+// ProjectMember puts a user on a project; a user is a member at most once.
+type ProjectMember struct {
+	ProjectID string      `json:"projectId"`
+	UserID    string      `json:"userId"`
+	Role      ProjectRole `json:"role"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
 }
 
 type TaskStatus string
@@ -274,6 +362,240 @@ type Task struct {
 	DueDate        *time.Time `json:"dueDate,omitempty"`
 	EstimatedHours int        `json:"estimatedHours"`
 	ActualHours    int        `json:"actualHours"`
+	ColumnID       string     `json:"columnId"`
+	Position       float64    `json:"position"`
 	CreatedAt      time.Time  `json:"createdAt"`
 	UpdatedAt      time.Time  `json:"updatedAt"`
+
+	LabelIDs []string `json:"labelIds,omitempty"`
+}
+
+// TaskColumn is one column of a project's task board. Tasks in it take its
+// category as their status; a WIPLimit above zero caps how many it holds.
+type TaskColumn struct {
+	ID        string     `json:"id"`
+	ProjectID string     `json:"projectId"`
+	Name      string     `json:"name"`
+	Color     string     `json:"color"`
+	Category  TaskStatus `json:"category"`
+	Position  int        `json:"position"`
+	WIPLimit  int        `json:"wipLimit"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+type BankTransactionStatus string
+
+const (
+	BankTransactionUnmatched BankTransactionStatus = "unmatched"
+	BankTransactionMatched   BankTransactionStatus = "matched"
+	BankTransactionIgnored   BankTransactionStatus = "ignored"
+)
+
+type BankTransaction struct {
+	ID           string                `json:"id"`
+	ExternalID   string                `json:"externalId"`
+	Source       string                `json:"source"`
+	BookedAt     time.Time             `json:"bookedAt"`
+	Amount       float64               `json:"amount"`
+	Currency     string                `json:"currency"`
+	Counterparty string                `json:"counterparty"`
+	Reference    string                `json:"reference"`
+	Description  string                `json:"description"`
+	Status       BankTransactionStatus `json:"status"`
+	PaymentID    string                `json:"paymentId"`
+	MatchedAt    *time.Time            `json:"matchedAt,omitempty"`
+	CreatedAt    time.Time             `json:"createdAt"`
+	UpdatedAt    time.Time             `json:"updatedAt"`
+}
+
+type BillingInterval string
+
+const (
+	BillingMonthly   BillingInterval = "monthly"
+	BillingQuarterly BillingInterval = "quarterly"
+	BillingYearly    BillingInterval = "yearly"
+)
+
+// BillingPlan describes recurring revenue (retainers, hosting, maintenance)
+// for a deal, optionally scoped to one of its projects.
+type BillingPlan struct {
+	ID        string          `json:"id"`
+	DealID    string          `json:"dealId"`
+	ProjectID string          `json:"projectId"`
+	Title     string          `json:"title"`
+	Interval  BillingInterval `json:"interval"`
+	Amount    float64         `json:"amount"`
+	Currency  string          `json:"currency"`
+	StartAt   time.Time       `json:"startAt"`
+	EndAt     *time.Time      `json:"endAt,omitempty"`
+	Active    bool            `json:"active"`
+	Notes     string          `json:"notes"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+}
+
+type Domain struct {
+	ID             string     `json:"id"`
+	OrganizationID string     `json:"organizationId"`
+	DealID         string     `json:"dealId"`
+	Name           string     `json:"name"`
+	Registrar      string     `json:"registrar"`
+	AcquiredAt     *time.Time `json:"acquiredAt,omitempty"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	AutoRenew      bool       `json:"autoRenew"`
+	RenewalPrice   float64    `json:"renewalPrice"`
+	Currency       string     `json:"currency"`
+	DNSNotes       string     `json:"dnsNotes"`
+	LastCheckedAt  *time.Time `json:"lastCheckedAt,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+// ExchangeRate records that on Date one unit of Base bought Rate units of
+// Quote. Source is "manual" or "ecb".
+type ExchangeRate struct {
+	ID        string    `json:"id"`
+	Date      time.Time `json:"date"`
+	Base      string    `json:"base"`
+	Quote     string    `json:"quote"`
+	Rate      float64   `json:"rate"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// HistoryEntry records one field change on an entity, e.g. a deal moving
+// from one pipeline stage to another. OldValue is empty on creation.
+type HistoryEntry struct {
+	ID         string    `json:"id"`
+	EntityType string    `json:"entityType"`
+	EntityID   string    `json:"entityId"`
+	Field      string    `json:"field"`
+	OldValue   string    `json:"oldValue"`
+	NewValue   string    `json:"newValue"`
+	UserID     string    `json:"userId"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Notification is an in-app message for one user. Key deduplicates
+// generated notifications (e.g. one digest per user per day).
+type Notification struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"userId"`
+	Kind       string     `json:"kind"`
+	Title      string     `json:"title"`
+	Body       string     `json:"body"`
+	EntityType string     `json:"entityType"`
+	EntityID   string     `json:"entityId"`
+	Key        string     `json:"-"`
+	ReadAt     *time.Time `json:"readAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Calendar feed categories a user can publish.
+const (
+	CalendarFollowUps  = "follow_ups"
+	CalendarTasks      = "tasks"
+	CalendarPayments   = "payments"
+	CalendarQuotations = "quotations"
+	CalendarDomains    = "domains"
+)
+
+// CalendarFeed is a user's private iCalendar subscription. Anyone holding
+// Token can read the feed, so it is rotated rather than shared.
+type CalendarFeed struct {
+	UserID     string    `json:"userId"`
+	Token      string    `json:"token"`
+	Categories []string  `json:"categories"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+type CustomFieldType string
+
+const (
+	CustomFieldText    CustomFieldType = "text"
+	CustomFieldNumber  CustomFieldType = "number"
+	CustomFieldMoney   CustomFieldType = "money"
+	CustomFieldDate    CustomFieldType = "date"
+	CustomFieldSelect  CustomFieldType = "select"
+	CustomFieldBoolean CustomFieldType = "boolean"
+)
+
+// CustomField is an admin-defined field on organizations, contacts, deals
+// or projects. Key names the value in the entity's customFields and never
+// changes; Options lists the choices of a select field.
+type CustomField struct {
+	ID         string          `json:"id"`
+	EntityType string          `json:"entityType"`
+	Key        string          `json:"key"`
+	Label      string          `json:"label"`
+	Type       CustomFieldType `json:"type"`
+	Options    []string        `json:"options"`
+	Required   bool            `json:"required"`
+	Position   int             `json:"position"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+}
+
+// CustomValues holds an entity's custom field values by field key: float64
+// for number and money, bool for boolean, and strings (dates as
+// YYYY-MM-DD) otherwise.
+type CustomValues map[string]any
+
+// Note is a markdown comment attached to an organization, contact, deal,
+// project, task or quotation. Mentions holds the IDs of the users it
+// @mentions.
+type Note struct {
+	ID           string    `json:"id"`
+	EntityType   string    `json:"entityType"`
+	EntityID     string    `json:"entityId"`
+	AuthorUserID string    `json:"authorUserId"`
+	Body         string    `json:"body"`
+	Mentions     []string  `json:"mentions"`
+	Revisions    int       `json:"revisions"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// NoteRevision is the body a note had before one of its edits.
+type NoteRevision struct {
+	ID             string    `json:"id"`
+	NoteID         string    `json:"noteId"`
+	Body           string    `json:"body"`
+	EditedByUserID string    `json:"editedByUserId"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// Attachment is an uploaded file linked to a record. SHA256 is the key of
+// its content in the blob store.
+type Attachment struct {
+	ID               string    `json:"id"`
+	EntityType       string    `json:"entityType"`
+	EntityID         string    `json:"entityId"`
+	Filename         string    `json:"filename"`
+	ContentType      string    `json:"contentType"`
+	Size             int64     `json:"size"`
+	SHA256           string    `json:"sha256"`
+	UploadedByUserID string    `json:"uploadedByUserId"`
+	CreatedAt        time.Time `json:"createdAt"`
+}
+
+// SearchHit is one full-text search result. Snippet is HTML with the
+// matched terms wrapped in <mark>.
+type SearchHit struct {
+	EntityType string  `json:"entityType"`
+	EntityID   string  `json:"entityId"`
+	Title      string  `json:"title"`
+	Snippet    string  `json:"snippet"`
+	Score      float64 `json:"score"`
+}
+
+// Label tags organizations, contacts, deals, projects and tasks.
+type Label struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
@@ -0,0 +1,147 @@
+package reports
+
+import (
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+type CashflowOptions struct {
+	Period   Period
+	Periods  int
+	Now      time.Time
+	Currency string
+	Convert  Converter
+}
+
+type CashflowRow struct {
+	Period string `json:"period"`
+	// Planned is the sum of planned payments due in the period. Overdue
+	// payments are carried into the first period and also shown in Overdue.
+	Planned float64 `json:"planned"`
+	Overdue float64 `json:"overdue"`
+	// Pipeline is open deal value not yet covered by payments, weighted by
+	// the deal's probability and placed at its expected close date.
+	Pipeline float64 `json:"pipeline"`
+	Total    float64 `json:"total"`
+}
+
+type CashflowReport struct {
+	Currency    string        `json:"currency"`
+	Group       Period        `json:"group"`
+	Rows        []CashflowRow `json:"rows"`
+	Planned     float64       `json:"planned"`
+	Pipeline    float64       `json:"pipeline"`
+	Total       float64       `json:"total"`
+	Unconverted int           `json:"unconverted"`
+}
+
+// Cashflow forecasts incoming money over the next opts.Periods periods.
+func Cashflow(payments []models.Payment, deals []models.Deal, opts CashflowOptions) CashflowReport {
+	if opts.Periods <= 0 {
+		opts.Periods = 6
+	}
+	report := CashflowReport{Currency: opts.Currency, Group: opts.Period}
+
+	start := opts.Period.Start(opts.Now)
+	report.Rows = make([]CashflowRow, opts.Periods)
+	bounds := make([]time.Time, opts.Periods+1)
+	bounds[0] = start
+	for i := 0; i < opts.Periods; i++ {
+		report.Rows[i].Period = opts.Period.Key(bounds[i])
+		bounds[i+1] = opts.Period.Next(bounds[i])
+	}
+	end := bounds[opts.Periods]
+	index := func(t time.Time) int {
+		if t.Before(start) {
+			return 0
+		}
+		for i := 0; i < opts.Periods; i++ {
+			if t.Before(bounds[i+1]) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	covered := make(map[string]float64)
+	for _, p := range payments {
+		if p.Status == models.PaymentVoid {
+			continue
+		}
+		if p.ReportingCurrency != opts.Currency {
+			report.Unconverted++
+			continue
+		}
+		covered[p.DealID] += p.ReportingAmount
+		if p.Status != models.PaymentPlanned {
+			continue
+		}
+		due := opts.Now
+		if p.DueAt != nil {
+			due = *p.DueAt
+		}
+		i := index(due)
+		if i < 0 {
+			continue
+		}
+		report.Rows[i].Planned += p.ReportingAmount
+		if due.Before(startOfDay(opts.Now)) {
+			report.Rows[i].Overdue += p.ReportingAmount
+		}
+	}
+
+	for _, d := range deals {
+		if d.Status != models.DealOpen || d.Probability <= 0 || d.Value <= 0 {
+			continue
+		}
+		closeAt := opts.Now
+		if d.ExpectedCloseAt != nil {
+			closeAt = *d.ExpectedCloseAt
+		}
+		if !closeAt.Before(end) {
+			continue
+		}
+		value, ok := opts.Convert(d.Value, d.Currency, closeAt)
+		if !ok {
+			report.Unconverted++
+			continue
+		}
+		remaining := value - covered[d.ID]
+		if remaining <= 0 {
+			continue
+		}
+		prob := d.Probability
+		if prob > 100 {
+			prob = 100
+		}
+		report.Rows[index(closeAt)].Pipeline += remaining * float64(prob) / 100
+	}
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		row.Planned = round(row.Planned)
+		row.Overdue = round(row.Overdue)
+		row.Pipeline = round(row.Pipeline)
+		row.Total = round(row.Planned + row.Pipeline)
+		report.Planned += row.Planned
+		report.Pipeline += row.Pipeline
+	}
+	report.Planned = round(report.Planned)
+	report.Pipeline = round(report.Pipeline)
+	report.Total = round(report.Planned + report.Pipeline)
+	return report
+}
+
+func (r CashflowReport) Table() Table {
+	t := Table{Header: []string{"period", "planned", "overdue", "pipeline", "total", "currency"}}
+	for _, row := range r.Rows {
+		t.Rows = append(t.Rows, []string{row.Period, money(row.Planned), money(row.Overdue), money(row.Pipeline), money(row.Total), r.Currency})
+	}
+	return t
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
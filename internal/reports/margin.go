@@ -0,0 +1,163 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+type MarginOptions struct {
+	From     *time.Time
+	To       *time.Time
+	Now      time.Time
+	Currency string
+	Convert  Converter
+}
+
+type MarginRow struct {
+	DealID       string     `json:"dealId"`
+	Title        string     `json:"title"`
+	Organization string     `json:"organization"`
+	WorkType     string     `json:"workType"`
+	ClosedAt     *time.Time `json:"closedAt,omitempty"`
+	Revenue      float64    `json:"revenue"`
+	Costs        float64    `json:"costs"`
+	Taxes        float64    `json:"taxes"`
+	DomainCost   float64    `json:"domainCost"`
+	Net          float64    `json:"net"`
+	// MarginPct is Net as a percentage of Revenue; 0 without revenue.
+	MarginPct float64 `json:"marginPct"`
+}
+
+type MarginReport struct {
+	Currency    string      `json:"currency"`
+	Rows        []MarginRow `json:"rows"`
+	Revenue     float64     `json:"revenue"`
+	Costs       float64     `json:"costs"`
+	Taxes       float64     `json:"taxes"`
+	DomainCost  float64     `json:"domainCost"`
+	Net         float64     `json:"net"`
+	MarginPct   float64     `json:"marginPct"`
+	Unconverted int         `json:"unconverted"`
+}
+
+// Margin computes net margin per won deal (and any other deal that has
+// received money): paid revenue minus the deal's costs, taxes and domain
+// cost. The date range applies to WorkClosedAt, or to the last payment date
+// for deals without one.
+func Margin(payments []models.Payment, deals []models.Deal, orgs []models.Organization, opts MarginOptions) MarginReport {
+	orgName := make(map[string]string, len(orgs))
+	for _, o := range orgs {
+		orgName[o.ID] = o.Name
+	}
+	revenue := make(map[string]float64)
+	lastPaid := make(map[string]time.Time)
+	report := MarginReport{Currency: opts.Currency, Rows: make([]MarginRow, 0)}
+	for _, p := range payments {
+		if p.Status != models.PaymentPaid || p.PaidAt == nil {
+			continue
+		}
+		if p.ReportingCurrency != opts.Currency {
+			report.Unconverted++
+			continue
+		}
+		revenue[p.DealID] += p.ReportingAmount
+		if p.PaidAt.After(lastPaid[p.DealID]) {
+			lastPaid[p.DealID] = *p.PaidAt
+		}
+	}
+
+	for _, d := range deals {
+		rev, paid := revenue[d.ID]
+		if d.Status != models.DealWon && !paid {
+			continue
+		}
+		var closedAt *time.Time
+		if d.WorkClosedAt != nil {
+			t := *d.WorkClosedAt
+			closedAt = &t
+		} else if t, ok := lastPaid[d.ID]; ok {
+			closedAt = &t
+		}
+		if opts.From != nil || opts.To != nil {
+			if closedAt == nil {
+				continue
+			}
+			if opts.From != nil && closedAt.Before(*opts.From) {
+				continue
+			}
+			if opts.To != nil && !closedAt.Before(*opts.To) {
+				continue
+			}
+		}
+
+		at := opts.Now
+		if closedAt != nil {
+			at = *closedAt
+		}
+		costs, ok1 := opts.Convert(d.Costs, d.Currency, at)
+		taxes, ok2 := opts.Convert(d.Taxes, d.Currency, at)
+		domain, ok3 := opts.Convert(d.DomainCost, d.Currency, at)
+		if !ok1 || !ok2 || !ok3 {
+			report.Unconverted++
+			continue
+		}
+		row := MarginRow{
+			DealID:       d.ID,
+			Title:        d.Title,
+			Organization: orgName[d.OrganizationID],
+			WorkType:     d.WorkType,
+			ClosedAt:     closedAt,
+			Revenue:      round(rev),
+			Costs:        round(costs),
+			Taxes:        round(taxes),
+			DomainCost:   round(domain),
+		}
+		row.Net = round(row.Revenue - row.Costs - row.Taxes - row.DomainCost)
+		row.MarginPct = pct(row.Net, row.Revenue)
+		report.Rows = append(report.Rows, row)
+
+		report.Revenue += row.Revenue
+		report.Costs += row.Costs
+		report.Taxes += row.Taxes
+		report.DomainCost += row.DomainCost
+	}
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Net != b.Net {
+			return a.Net > b.Net
+		}
+		return a.DealID < b.DealID
+	})
+	report.Revenue = round(report.Revenue)
+	report.Costs = round(report.Costs)
+	report.Taxes = round(report.Taxes)
+	report.DomainCost = round(report.DomainCost)
+	report.Net = round(report.Revenue - report.Costs - report.Taxes - report.DomainCost)
+	report.MarginPct = pct(report.Net, report.Revenue)
+	return report
+}
+
+func (r MarginReport) Table() Table {
+	t := Table{Header: []string{"deal_id", "title", "organization", "work_type", "closed_at", "revenue", "costs", "taxes", "domain_cost", "net", "margin_pct", "currency"}}
+	for _, row := range r.Rows {
+		closed := ""
+		if row.ClosedAt != nil {
+			closed = row.ClosedAt.Format("2006-01-02")
+		}
+		t.Rows = append(t.Rows, []string{
+			row.DealID, row.Title, row.Organization, row.WorkType, closed,
+			money(row.Revenue), money(row.Costs), money(row.Taxes), money(row.DomainCost), money(row.Net), money(row.MarginPct), r.Currency,
+		})
+	}
+	return t
+}
+
+func pct(part, whole float64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return round(part / whole * 100)
+}
@@ -0,0 +1,378 @@
+package reports
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+type PipelineOptions struct {
+	PipelineID string
+	Now        time.Time
+	Currency   string
+	Convert    Converter
+}
+
+type StageRow struct {
+	StageID     string  `json:"stageId"`
+	PipelineID  string  `json:"pipelineId"`
+	Name        string  `json:"name"`
+	Position    int     `json:"position"`
+	Probability float64 `json:"probability"`
+	Deals       int     `json:"deals"`
+	Value       float64 `json:"value"`
+	Weighted    float64 `json:"weighted"`
+	// AvgDays is the mean time deals spent in the stage before moving on,
+	// over Samples completed stays.
+	AvgDays float64 `json:"avgDays"`
+	Samples int     `json:"samples"`
+}
+
+type CloseMonthRow struct {
+	// Month is YYYY-MM, or "unscheduled" for deals without ExpectedCloseAt.
+	Month    string  `json:"month"`
+	Deals    int     `json:"deals"`
+	Value    float64 `json:"value"`
+	Weighted float64 `json:"weighted"`
+}
+
+type ConversionRow struct {
+	FromStageID string  `json:"fromStageId"`
+	FromName    string  `json:"fromName"`
+	ToStageID   string  `json:"toStageId"`
+	ToName      string  `json:"toName"`
+	Entered     int     `json:"entered"`
+	Advanced    int     `json:"advanced"`
+	Rate        float64 `json:"rate"`
+}
+
+type SourceRow struct {
+	Source  string  `json:"source"`
+	Won     int     `json:"won"`
+	Lost    int     `json:"lost"`
+	Open    int     `json:"open"`
+	WinRate float64 `json:"winRate"`
+}
+
+type LostReasonRow struct {
+	Reason string  `json:"reason"`
+	Deals  int     `json:"deals"`
+	Value  float64 `json:"value"`
+}
+
+type PipelineReport struct {
+	Currency    string          `json:"currency"`
+	Stages      []StageRow      `json:"stages"`
+	ByMonth     []CloseMonthRow `json:"byMonth"`
+	Conversions []ConversionRow `json:"conversions"`
+	Sources     []SourceRow     `json:"sources"`
+	LostReasons []LostReasonRow `json:"lostReasons"`
+	Weighted    float64         `json:"weighted"`
+	Unconverted int             `json:"unconverted"`
+}
+
+// Pipeline analyses deals together with their recorded pipelineStageId
+// history. Open deals are weighted by their own probability, falling back to
+// the stage's. Deals without history are assumed to have entered their
+// current stage when created and to have passed through every earlier stage.
+// Stages with zero probability are treated as lost/closed stages and left out
+// of the conversion funnel.
+func Pipeline(deals []models.Deal, stages []models.PipelineStage, history []models.HistoryEntry, opts PipelineOptions) PipelineReport {
+	report := PipelineReport{Currency: opts.Currency}
+
+	stageByID := make(map[string]models.PipelineStage, len(stages))
+	ordered := make([]models.PipelineStage, 0, len(stages))
+	for _, st := range stages {
+		if opts.PipelineID != "" && st.PipelineID != opts.PipelineID {
+			continue
+		}
+		stageByID[st.ID] = st
+		ordered = append(ordered, st)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].PipelineID != ordered[j].PipelineID {
+			return ordered[i].PipelineID < ordered[j].PipelineID
+		}
+		return ordered[i].Position < ordered[j].Position
+	})
+
+	inScope := make([]models.Deal, 0, len(deals))
+	for _, d := range deals {
+		if opts.PipelineID != "" {
+			if _, ok := stageByID[d.PipelineStageID]; !ok {
+				continue
+			}
+		}
+		inScope = append(inScope, d)
+	}
+
+	changes := make(map[string][]models.HistoryEntry)
+	for _, h := range history {
+		changes[h.EntityID] = append(changes[h.EntityID], h)
+	}
+
+	stageRows := make(map[string]*StageRow, len(ordered))
+	report.Stages = make([]StageRow, 0, len(ordered))
+	for _, st := range ordered {
+		report.Stages = append(report.Stages, StageRow{
+			StageID:     st.ID,
+			PipelineID:  st.PipelineID,
+			Name:        st.Name,
+			Position:    st.Position,
+			Probability: st.Probability,
+		})
+	}
+	for i := range report.Stages {
+		stageRows[report.Stages[i].StageID] = &report.Stages[i]
+	}
+
+	months := make(map[string]*CloseMonthRow)
+	sources := make(map[string]*SourceRow)
+	reasons := make(map[string]*LostReasonRow)
+	stayDays := make(map[string]float64)
+	reached := make(map[string]map[string]bool)
+
+	for _, d := range inScope {
+		value, ok := opts.Convert(d.Value, d.Currency, opts.Now)
+		if !ok {
+			report.Unconverted++
+			value = 0
+		}
+
+		source := strings.TrimSpace(d.Source)
+		if source == "" {
+			source = "(none)"
+		}
+		sr, ok := sources[strings.ToLower(source)]
+		if !ok {
+			sr = &SourceRow{Source: source}
+			sources[strings.ToLower(source)] = sr
+		}
+		switch d.Status {
+		case models.DealWon:
+			sr.Won++
+		case models.DealLost:
+			sr.Lost++
+			reason := strings.TrimSpace(d.LostReason)
+			if reason == "" {
+				reason = "(none)"
+			}
+			key := strings.ToLower(reason)
+			lr, ok := reasons[key]
+			if !ok {
+				lr = &LostReasonRow{Reason: reason}
+				reasons[key] = lr
+			}
+			lr.Deals++
+			lr.Value += value
+		default:
+			sr.Open++
+			prob := float64(d.Probability)
+			st, hasStage := stageByID[d.PipelineStageID]
+			if prob <= 0 && hasStage {
+				prob = st.Probability
+			}
+			if prob > 100 {
+				prob = 100
+			}
+			weighted := value * prob / 100
+			if row, ok := stageRows[d.PipelineStageID]; ok {
+				row.Deals++
+				row.Value += value
+				row.Weighted += weighted
+			}
+			month := "unscheduled"
+			if d.ExpectedCloseAt != nil {
+				month = d.ExpectedCloseAt.Format("2006-01")
+			}
+			mr, ok := months[month]
+			if !ok {
+				mr = &CloseMonthRow{Month: month}
+				months[month] = mr
+			}
+			mr.Deals++
+			mr.Value += value
+			mr.Weighted += weighted
+			report.Weighted += weighted
+		}
+
+		// Walk the stage history: each change closes the stay in the old
+		// stage, which began at the previous change (or at creation).
+		visited := map[string]bool{}
+		enteredAt := d.CreatedAt
+		for _, h := range changes[d.ID] {
+			if h.Field != "pipelineStageId" {
+				continue
+			}
+			if h.OldValue != "" {
+				visited[h.OldValue] = true
+				if row, ok := stageRows[h.OldValue]; ok && !h.CreatedAt.Before(enteredAt) {
+					stayDays[h.OldValue] += h.CreatedAt.Sub(enteredAt).Hours() / 24
+					row.Samples++
+				}
+			}
+			enteredAt = h.CreatedAt
+		}
+		if d.PipelineStageID != "" {
+			visited[d.PipelineStageID] = true
+		}
+		reached[d.ID] = visited
+	}
+
+	for id, row := range stageRows {
+		if row.Samples > 0 {
+			row.AvgDays = round(stayDays[id] / float64(row.Samples))
+		}
+		row.Value = round(row.Value)
+		row.Weighted = round(row.Weighted)
+	}
+
+	report.Conversions = conversions(ordered, inScope, reached)
+
+	report.ByMonth = make([]CloseMonthRow, 0, len(months))
+	for _, mr := range months {
+		mr.Value = round(mr.Value)
+		mr.Weighted = round(mr.Weighted)
+		report.ByMonth = append(report.ByMonth, *mr)
+	}
+	sort.Slice(report.ByMonth, func(i, j int) bool {
+		// "unscheduled" sorts after every YYYY-MM key.
+		return report.ByMonth[i].Month < report.ByMonth[j].Month
+	})
+
+	report.Sources = make([]SourceRow, 0, len(sources))
+	for _, sr := range sources {
+		if closed := sr.Won + sr.Lost; closed > 0 {
+			sr.WinRate = pct(float64(sr.Won), float64(closed))
+		}
+		report.Sources = append(report.Sources, *sr)
+	}
+	sort.Slice(report.Sources, func(i, j int) bool {
+		a, b := report.Sources[i], report.Sources[j]
+		if a.Won+a.Lost+a.Open != b.Won+b.Lost+b.Open {
+			return a.Won+a.Lost+a.Open > b.Won+b.Lost+b.Open
+		}
+		return a.Source < b.Source
+	})
+
+	report.LostReasons = make([]LostReasonRow, 0, len(reasons))
+	for _, lr := range reasons {
+		lr.Value = round(lr.Value)
+		report.LostReasons = append(report.LostReasons, *lr)
+	}
+	sort.Slice(report.LostReasons, func(i, j int) bool {
+		a, b := report.LostReasons[i], report.LostReasons[j]
+		if a.Deals != b.Deals {
+			return a.Deals > b.Deals
+		}
+		return a.Reason < b.Reason
+	})
+
+	report.Weighted = round(report.Weighted)
+	return report
+}
+
+// conversions computes, for each pair of consecutive funnel stages, how many
+// deals that reached the first also reached the second. Reaching a stage
+// implies having passed every earlier funnel stage of the same pipeline.
+func conversions(ordered []models.PipelineStage, deals []models.Deal, reached map[string]map[string]bool) []ConversionRow {
+	funnel := make(map[string][]models.PipelineStage)
+	pipelines := make([]string, 0)
+	for _, st := range ordered {
+		if st.Probability <= 0 {
+			continue
+		}
+		if _, ok := funnel[st.PipelineID]; !ok {
+			pipelines = append(pipelines, st.PipelineID)
+		}
+		funnel[st.PipelineID] = append(funnel[st.PipelineID], st)
+	}
+
+	out := make([]ConversionRow, 0)
+	for _, pid := range pipelines {
+		chain := funnel[pid]
+		inPipeline := make(map[string]bool)
+		for _, st := range ordered {
+			if st.PipelineID == pid {
+				inPipeline[st.ID] = true
+			}
+		}
+		depth := make(map[string]int)
+		for _, d := range deals {
+			// Every deal in the pipeline entered its first stage, even one
+			// that went straight to a lost stage.
+			if inPipeline[d.PipelineStageID] {
+				depth[d.ID] = 1
+			}
+			for i := len(chain) - 1; i >= 0; i-- {
+				if reached[d.ID][chain[i].ID] {
+					depth[d.ID] = i + 1
+					break
+				}
+			}
+		}
+		for i := 0; i+1 < len(chain); i++ {
+			row := ConversionRow{
+				FromStageID: chain[i].ID,
+				FromName:    chain[i].Name,
+				ToStageID:   chain[i+1].ID,
+				ToName:      chain[i+1].Name,
+			}
+			for _, n := range depth {
+				if n >= i+1 {
+					row.Entered++
+				}
+				if n >= i+2 {
+					row.Advanced++
+				}
+			}
+			if row.Entered > 0 {
+				row.Rate = pct(float64(row.Advanced), float64(row.Entered))
+			}
+			out = append(out, row)
+		}
+	}
+	return out
+}
+
+// Table renders one section of the report: "stages" (the default),
+// "months", "conversions", "sources" or "lost_reasons".
+func (r PipelineReport) Table(section string) (Table, bool) {
+	var t Table
+	switch section {
+	case "", "stages":
+		t.Header = []string{"stage_id", "stage", "position", "probability", "deals", "value", "weighted", "avg_days", "currency"}
+		for _, row := range r.Stages {
+			t.Rows = append(t.Rows, []string{
+				row.StageID, row.Name, strconv.Itoa(row.Position), money(row.Probability), strconv.Itoa(row.Deals),
+				money(row.Value), money(row.Weighted), money(row.AvgDays), r.Currency,
+			})
+		}
+	case "months":
+		t.Header = []string{"month", "deals", "value", "weighted", "currency"}
+		for _, row := range r.ByMonth {
+			t.Rows = append(t.Rows, []string{row.Month, strconv.Itoa(row.Deals), money(row.Value), money(row.Weighted), r.Currency})
+		}
+	case "conversions":
+		t.Header = []string{"from_stage", "to_stage", "entered", "advanced", "rate_pct"}
+		for _, row := range r.Conversions {
+			t.Rows = append(t.Rows, []string{row.FromName, row.ToName, strconv.Itoa(row.Entered), strconv.Itoa(row.Advanced), money(row.Rate)})
+		}
+	case "sources":
+		t.Header = []string{"source", "won", "lost", "open", "win_rate_pct"}
+		for _, row := range r.Sources {
+			t.Rows = append(t.Rows, []string{row.Source, strconv.Itoa(row.Won), strconv.Itoa(row.Lost), strconv.Itoa(row.Open), money(row.WinRate)})
+		}
+	case "lost_reasons":
+		t.Header = []string{"reason", "deals", "value", "currency"}
+		for _, row := range r.LostReasons {
+			t.Rows = append(t.Rows, []string{row.Reason, strconv.Itoa(row.Deals), money(row.Value), r.Currency})
+		}
+	default:
+		return Table{}, false
+	}
+	return t, true
+}
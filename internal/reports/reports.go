@@ -0,0 +1,100 @@
+// Package reports aggregates payments and deals into revenue, cash-flow and
+// margin figures. All amounts are in a single reporting currency; callers
+// supply the conversion.
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Period is the bucket size used to group amounts over time.
+type Period string
+
+const (
+	Month   Period = "month"
+	Quarter Period = "quarter"
+	Year    Period = "year"
+)
+
+func ParsePeriod(v string) (Period, error) {
+	switch Period(v) {
+	case "":
+		return Month, nil
+	case Month, Quarter, Year:
+		return Period(v), nil
+	default:
+		return "", fmt.Errorf("group must be month, quarter or year")
+	}
+}
+
+// Key labels the bucket containing t, e.g. "2026-10", "2026-Q4" or "2026".
+func (p Period) Key(t time.Time) string {
+	switch p {
+	case Year:
+		return strconv.Itoa(t.Year())
+	case Quarter:
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	default:
+		return t.Format("2006-01")
+	}
+}
+
+// Start returns the first instant of the bucket containing t.
+func (p Period) Start(t time.Time) time.Time {
+	switch p {
+	case Year:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	case Quarter:
+		m := time.Month((int(t.Month())-1)/3*3 + 1)
+		return time.Date(t.Year(), m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// Next returns the start of the bucket after the one starting at start.
+func (p Period) Next(start time.Time) time.Time {
+	switch p {
+	case Year:
+		return start.AddDate(1, 0, 0)
+	case Quarter:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// Converter turns an amount in currency into the reporting currency as of at.
+// It reports false when no rate is known.
+type Converter func(amount float64, currency string, at time.Time) (float64, bool)
+
+// Table is the tabular form of a report, used for CSV output.
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+func (t Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func money(v float64) string {
+	return strconv.FormatFloat(round(v), 'f', 2, 64)
+}
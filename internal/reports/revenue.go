@@ -0,0 +1,165 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// Dimension splits revenue beyond the time period.
+type Dimension string
+
+const (
+	ByNone         Dimension = ""
+	ByOrganization Dimension = "organization"
+	ByWorkType     Dimension = "work_type"
+	ByPartner      Dimension = "partner"
+)
+
+func ParseDimension(v string) (Dimension, error) {
+	switch Dimension(v) {
+	case ByNone, ByOrganization, ByWorkType, ByPartner:
+		return Dimension(v), nil
+	default:
+		return "", fmt.Errorf("by must be organization, work_type or partner")
+	}
+}
+
+type RevenueOptions struct {
+	Period   Period
+	By       Dimension
+	From     *time.Time
+	To       *time.Time
+	Currency string
+}
+
+type RevenueRow struct {
+	Period string  `json:"period"`
+	Key    string  `json:"key,omitempty"`
+	Label  string  `json:"label,omitempty"`
+	Amount float64 `json:"amount"`
+	Count  int     `json:"count"`
+}
+
+type RevenueReport struct {
+	Currency string       `json:"currency"`
+	Group    Period       `json:"group"`
+	By       Dimension    `json:"by,omitempty"`
+	Rows     []RevenueRow `json:"rows"`
+	Total    float64      `json:"total"`
+	// Unconverted counts paid payments left out because no exchange rate
+	// into the reporting currency was known.
+	Unconverted int `json:"unconverted"`
+}
+
+// Revenue totals paid payments by period and, optionally, by organization,
+// deal work type or partner. Partner rows split each payment by its Gil/Ric
+// amounts, with any remainder under "unassigned".
+func Revenue(payments []models.Payment, deals []models.Deal, orgs []models.Organization, opts RevenueOptions) RevenueReport {
+	dealByID := make(map[string]models.Deal, len(deals))
+	for _, d := range deals {
+		dealByID[d.ID] = d
+	}
+	orgName := make(map[string]string, len(orgs))
+	for _, o := range orgs {
+		orgName[o.ID] = o.Name
+	}
+
+	type bucket struct{ period, key string }
+	rows := make(map[bucket]*RevenueRow)
+	add := func(period, key, label string, amount float64) {
+		b := bucket{period, key}
+		row, ok := rows[b]
+		if !ok {
+			row = &RevenueRow{Period: period, Key: key, Label: label}
+			rows[b] = row
+		}
+		row.Amount += amount
+		row.Count++
+	}
+
+	report := RevenueReport{Currency: opts.Currency, Group: opts.Period, By: opts.By}
+	for _, p := range payments {
+		if p.Status != models.PaymentPaid || p.PaidAt == nil {
+			continue
+		}
+		if opts.From != nil && p.PaidAt.Before(*opts.From) {
+			continue
+		}
+		if opts.To != nil && !p.PaidAt.Before(*opts.To) {
+			continue
+		}
+		if p.ReportingCurrency != opts.Currency {
+			report.Unconverted++
+			continue
+		}
+		period := opts.Period.Key(*p.PaidAt)
+		deal := dealByID[p.DealID]
+		switch opts.By {
+		case ByOrganization:
+			add(period, deal.OrganizationID, orgName[deal.OrganizationID], p.ReportingAmount)
+		case ByWorkType:
+			wt := strings.TrimSpace(deal.WorkType)
+			add(period, wt, wt, p.ReportingAmount)
+		case ByPartner:
+			// Gil/Ric amounts are in the payment currency; scale them the
+			// same way the payment was converted.
+			ratio := 0.0
+			if p.Amount > 0 {
+				ratio = p.ReportingAmount / p.Amount
+			}
+			gil, ric := p.GilAmount*ratio, p.RicAmount*ratio
+			if gil > 0 {
+				add(period, "gil", "Gil", gil)
+			}
+			if ric > 0 {
+				add(period, "ric", "Ric", ric)
+			}
+			if rest := p.ReportingAmount - gil - ric; rest > 0.005 {
+				add(period, "unassigned", "Unassigned", rest)
+			}
+		default:
+			add(period, "", "", p.ReportingAmount)
+		}
+		report.Total += p.ReportingAmount
+	}
+
+	report.Rows = make([]RevenueRow, 0, len(rows))
+	for _, row := range rows {
+		row.Amount = round(row.Amount)
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+		if a.Amount != b.Amount {
+			return a.Amount > b.Amount
+		}
+		return a.Key < b.Key
+	})
+	report.Total = round(report.Total)
+	return report
+}
+
+func (r RevenueReport) Table() Table {
+	t := Table{Header: []string{"period"}}
+	if r.By != ByNone {
+		t.Header = append(t.Header, string(r.By), "label")
+	}
+	t.Header = append(t.Header, "amount", "currency", "payments")
+	for _, row := range r.Rows {
+		rec := []string{row.Period}
+		if r.By != ByNone {
+			rec = append(rec, row.Key, row.Label)
+		}
+		rec = append(rec, money(row.Amount), r.Currency, strconv.Itoa(row.Count))
+		t.Rows = append(t.Rows, rec)
+	}
+	return t
+}
@@ -0,0 +1,558 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/addressbook"
+	"wemadeit/internal/dedupe"
+	"wemadeit/internal/models"
+)
+
+const maxAddressBookBytes = 20 << 20
+
+// importRow reports what happened, or would happen on a dry run, to one
+// imported card or CSV row.
+type importRow struct {
+	Row            int    `json:"row"`
+	Action         string `json:"action"` // created, updated, unchanged, duplicate, skipped
+	Name           string `json:"name"`
+	Email          string `json:"email,omitempty"`
+	ID             string `json:"id,omitempty"`
+	OrganizationID string `json:"organizationId,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+type importResult struct {
+	DryRun               bool        `json:"dryRun"`
+	Created              int         `json:"created"`
+	Updated              int         `json:"updated"`
+	Unchanged            int         `json:"unchanged"`
+	Duplicates           int         `json:"duplicates"`
+	Skipped              int         `json:"skipped"`
+	OrganizationsCreated int         `json:"organizationsCreated"`
+	Rows                 []importRow `json:"rows"`
+}
+
+func (res *importResult) add(row importRow) {
+	switch row.Action {
+	case "created":
+		res.Created++
+	case "updated":
+		res.Updated++
+	case "unchanged":
+		res.Unchanged++
+	case "duplicate":
+		res.Duplicates++
+	case "skipped":
+		res.Skipped++
+	}
+	res.Rows = append(res.Rows, row)
+}
+
+// readAddressBook parses an uploaded vCard or CSV file. For CSV with
+// ?preview=1 it returns the mapping preview instead, and ok is false once a
+// response has been written.
+func readAddressBook(w http.ResponseWriter, r *http.Request, kind string) ([]addressbook.Entry, bool) {
+	data, filename, err := readUpload(r, maxAddressBookBytes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return nil, false
+	}
+	format := strings.ToLower(strings.TrimSpace(r.FormValue("format")))
+	if format == "" {
+		head := bytes.ToUpper(bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+		if bytes.HasPrefix(head, []byte("BEGIN:VCARD")) || strings.HasSuffix(strings.ToLower(filename), ".vcf") {
+			format = "vcard"
+		} else {
+			format = "csv"
+		}
+	}
+	switch format {
+	case "vcard", "vcf":
+		entries, err := addressbook.ParseVCard(bytes.NewReader(data))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return nil, false
+		}
+		return entries, true
+	case "csv":
+		var mapping addressbook.CSVMapping
+		if raw := strings.TrimSpace(r.FormValue("mapping")); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse("invalid mapping: "+err.Error()))
+				return nil, false
+			}
+		}
+		if isTrue(r.FormValue("preview")) {
+			preview, err := addressbook.PreviewCSV(data, mapping, 10)
+			if err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+				return nil, false
+			}
+			writeJSON(w, http.StatusOK, preview)
+			return nil, false
+		}
+		entries, err := addressbook.ReadCSV(data, mapping, kind)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return nil, false
+		}
+		return entries, true
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse("format must be vcard or csv"))
+		return nil, false
+	}
+}
+
+// orgIndex finds organizations by tax ID or normalized name, and creates
+// missing ones when not on a dry run.
+type orgIndex struct {
+	byName  map[string]models.Organization
+	byTaxID map[string]models.Organization
+	created int
+}
+
+func newOrgIndex(orgs []models.Organization) *orgIndex {
+	idx := &orgIndex{
+		byName:  make(map[string]models.Organization, len(orgs)),
+		byTaxID: make(map[string]models.Organization),
+	}
+	for _, o := range orgs {
+		idx.put(o)
+	}
+	return idx
+}
+
+func (idx *orgIndex) put(o models.Organization) {
+	if n := dedupe.Name(o.Name); n != "" {
+		idx.byName[n] = o
+	}
+	if t := dedupe.TaxID(o.TaxID); t != "" {
+		idx.byTaxID[t] = o
+	}
+}
+
+func (idx *orgIndex) find(name, taxID string) (models.Organization, bool) {
+	if t := dedupe.TaxID(taxID); t != "" {
+		if o, ok := idx.byTaxID[t]; ok {
+			return o, true
+		}
+	}
+	o, ok := idx.byName[dedupe.Name(name)]
+	return o, ok && dedupe.Name(name) != ""
+}
+
+// ensureOrganization returns the organization called name, creating it
+// on the fly.
+func (s *Server) ensureOrganization(idx *orgIndex, name string, now time.Time, dryRun bool) (models.Organization, error) {
+	if o, ok := idx.find(name, ""); ok {
+		return o, nil
+	}
+	o := models.Organization{ID: newID(), Name: strings.TrimSpace(name), CreatedAt: now, UpdatedAt: now}
+	if !dryRun {
+		if err := s.store.SaveOrganization(o); err != nil {
+			return o, err
+		}
+	}
+	idx.put(o)
+	idx.created++
+	return o, nil
+}
+
+// handleContactImport imports vCard or CSV address books. Organizations are
+// created from the company field; ?organizationId= is used for cards
+// without one. Existing contacts are matched by e-mail and updated with the
+// non-empty imported fields. A card repeating an e-mail earlier in the file,
+// or sharing the name of another contact in the same organization, is
+// reported as a duplicate and left alone. ?dry_run=1 reports without saving.
+func (s *Server) handleContactImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	entries, ok := readAddressBook(w, r, addressbook.KindIndividual)
+	if !ok {
+		return
+	}
+	dryRun := isTrue(r.FormValue("dry_run"))
+	defaultOrg := strings.TrimSpace(r.FormValue("organizationId"))
+
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if defaultOrg != "" && !slices.ContainsFunc(orgs, func(o models.Organization) bool { return o.ID == defaultOrg }) {
+		writeJSON(w, http.StatusBadRequest, errorResponse("organization not found"))
+		return
+	}
+	orgIdx := newOrgIndex(orgs)
+	byEmail := make(map[string]models.Contact, len(contacts))
+	byName := make(map[string]models.Contact, len(contacts))
+	for _, c := range contacts {
+		if e := dedupe.Email(c.Email); e != "" {
+			byEmail[e] = c
+		}
+		byName[c.OrganizationID+"|"+dedupe.Name(c.FirstName+" "+c.LastName)] = c
+	}
+
+	now := time.Now()
+	res := importResult{DryRun: dryRun, Rows: make([]importRow, 0, len(entries))}
+	seen := make(map[string]int)
+	for n, e := range entries {
+		row := importRow{Row: n + 1, Name: e.Name(), Email: e.Email}
+		if e.Kind == addressbook.KindOrganization {
+			o, err := s.ensureOrganization(orgIdx, e.Company, now, dryRun)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			row.Action, row.Name, row.OrganizationID, row.Reason = "skipped", e.Company, o.ID, "organization card"
+			res.add(row)
+			continue
+		}
+		email := dedupe.Email(e.Email)
+		if first, ok := seen[email]; ok && email != "" {
+			row.Action, row.Reason = "duplicate", "same e-mail as row "+strconv.Itoa(first)
+			res.add(row)
+			continue
+		}
+		seen[email] = row.Row
+
+		existing, found := byEmail[email]
+		found = found && email != ""
+		orgID := existing.OrganizationID
+		if strings.TrimSpace(e.Company) != "" {
+			o, err := s.ensureOrganization(orgIdx, e.Company, now, dryRun)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			orgID = o.ID
+		}
+		if orgID == "" {
+			orgID = defaultOrg
+		}
+		if orgID == "" {
+			row.Action, row.Reason = "skipped", "no company and no organizationId given"
+			res.add(row)
+			continue
+		}
+		row.OrganizationID = orgID
+
+		if !found {
+			if other, ok := byName[orgID+"|"+dedupe.Name(e.Name())]; ok && dedupe.Name(e.Name()) != "" {
+				row.Action, row.ID, row.Reason = "duplicate", other.ID, "same name in the same organization"
+				res.add(row)
+				continue
+			}
+		}
+
+		c := existing
+		if !found {
+			c = models.Contact{ID: newID(), CreatedAt: now}
+		}
+		changed := c.OrganizationID != orgID
+		c.OrganizationID = orgID
+		setIfGiven(&changed, &c.FirstName, e.FirstName)
+		setIfGiven(&changed, &c.LastName, e.LastName)
+		setIfGiven(&changed, &c.JobTitle, e.JobTitle)
+		setIfGiven(&changed, &c.Email, e.Email)
+		setIfGiven(&changed, &c.Phone, e.Phone)
+		setIfGiven(&changed, &c.Mobile, e.Mobile)
+		setIfGiven(&changed, &c.LinkedInURL, e.LinkedInURL)
+		setIfGiven(&changed, &c.Notes, e.Notes)
+		row.ID = c.ID
+		switch {
+		case !found:
+			row.Action = "created"
+		case !changed:
+			row.Action = "unchanged"
+			res.add(row)
+			continue
+		default:
+			row.Action = "updated"
+		}
+		c.UpdatedAt = now
+		if !dryRun {
+			if err := s.store.SaveContact(c); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		if email != "" {
+			byEmail[email] = c
+		}
+		byName[c.OrganizationID+"|"+dedupe.Name(c.FirstName+" "+c.LastName)] = c
+		res.add(row)
+	}
+	res.OrganizationsCreated = orgIdx.created
+	writeJSON(w, http.StatusOK, res)
+}
+
+// handleOrganizationImport imports organizations from CSV or vCard
+// (KIND:org or company cards). Existing ones are matched by tax ID, then by
+// normalized name, and updated with the non-empty imported fields.
+func (s *Server) handleOrganizationImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	entries, ok := readAddressBook(w, r, addressbook.KindOrganization)
+	if !ok {
+		return
+	}
+	dryRun := isTrue(r.FormValue("dry_run"))
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	idx := newOrgIndex(orgs)
+	now := time.Now()
+	res := importResult{DryRun: dryRun, Rows: make([]importRow, 0, len(entries))}
+	seen := make(map[string]int)
+	for n, e := range entries {
+		name := e.Company
+		row := importRow{Row: n + 1, Name: name, Email: e.Email}
+		if strings.TrimSpace(name) == "" {
+			row.Action, row.Reason = "skipped", "no name"
+			res.add(row)
+			continue
+		}
+		key := dedupe.TaxID(e.TaxID)
+		if key == "" {
+			key = dedupe.Name(name)
+		}
+		if first, ok := seen[key]; ok {
+			row.Action, row.Reason = "duplicate", "same organization as row "+strconv.Itoa(first)
+			res.add(row)
+			continue
+		}
+		seen[key] = row.Row
+
+		o, found := idx.find(name, e.TaxID)
+		if !found {
+			o = models.Organization{ID: newID(), Name: strings.TrimSpace(name), CreatedAt: now}
+		}
+		changed := false
+		setIfGiven(&changed, &o.Industry, e.Industry)
+		setIfGiven(&changed, &o.Website, e.Website)
+		setIfGiven(&changed, &o.Email, e.Email)
+		setIfGiven(&changed, &o.Phone, e.Phone)
+		setIfGiven(&changed, &o.TaxID, e.TaxID)
+		setIfGiven(&changed, &o.Address, e.Address)
+		setIfGiven(&changed, &o.City, e.City)
+		setIfGiven(&changed, &o.Country, e.Country)
+		setIfGiven(&changed, &o.Notes, e.Notes)
+		row.ID, row.OrganizationID = o.ID, o.ID
+		switch {
+		case !found:
+			row.Action = "created"
+		case !changed:
+			row.Action = "unchanged"
+			res.add(row)
+			continue
+		default:
+			row.Action = "updated"
+		}
+		o.UpdatedAt = now
+		if !dryRun {
+			if err := s.store.SaveOrganization(o); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		idx.put(o)
+		res.add(row)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// contactFilter is the query-string filter shared by the contact list and
+// its export: organizationId, ids (comma separated) and q, a
+// case-insensitive match on name, e-mail, phone and company.
+type contactFilter struct {
+	organizationID string
+	ids            []string
+	q              string
+}
+
+func parseContactFilter(r *http.Request) contactFilter {
+	q := r.URL.Query()
+	f := contactFilter{
+		organizationID: strings.TrimSpace(q.Get("organizationId")),
+		q:              strings.ToLower(strings.TrimSpace(q.Get("q"))),
+	}
+	for _, id := range strings.Split(q.Get("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			f.ids = append(f.ids, id)
+		}
+	}
+	return f
+}
+
+func (f contactFilter) apply(contacts []models.Contact, orgNames map[string]string) []models.Contact {
+	out := make([]models.Contact, 0, len(contacts))
+	for _, c := range contacts {
+		if f.organizationID != "" && c.OrganizationID != f.organizationID {
+			continue
+		}
+		if len(f.ids) > 0 && !slices.Contains(f.ids, c.ID) {
+			continue
+		}
+		if f.q != "" {
+			hay := strings.ToLower(strings.Join([]string{c.FirstName, c.LastName, c.Email, c.Phone, c.Mobile, orgNames[c.OrganizationID]}, " "))
+			if !strings.Contains(hay, f.q) {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// filteredContacts loads the contacts matching the request's filters, with
+// their custom fields and labels, and organization names by ID; ok is false once an
+// error response has been written.
+func (s *Server) filteredContacts(w http.ResponseWriter, r *http.Request) ([]models.Contact, map[string]string, bool) {
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return nil, nil, false
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return nil, nil, false
+	}
+	attrs, ok := s.listFilter(w, r, "contact")
+	if !ok {
+		return nil, nil, false
+	}
+	orgNames := make(map[string]string, len(orgs))
+	for _, o := range orgs {
+		orgNames[o.ID] = o.Name
+	}
+	out := make([]models.Contact, 0, len(contacts))
+	for _, c := range parseContactFilter(r).apply(contacts, orgNames) {
+		c.CustomFields, c.LabelIDs = attrs.customFields(c.ID), attrs.labelIDs(c.ID)
+		if attrs.match(c.ID) {
+			out = append(out, c)
+		}
+	}
+	return out, orgNames, true
+}
+
+// handleContactExport writes the filtered contacts as vCard or CSV.
+func (s *Server) handleContactExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	contacts, orgNames, ok := s.filteredContacts(w, r)
+	if !ok {
+		return
+	}
+	entries := make([]addressbook.Entry, 0, len(contacts))
+	for _, c := range contacts {
+		entries = append(entries, addressbook.Entry{
+			Kind:        addressbook.KindIndividual,
+			UID:         "contact-" + c.ID + "@wemadeit",
+			FirstName:   c.FirstName,
+			LastName:    c.LastName,
+			Company:     orgNames[c.OrganizationID],
+			JobTitle:    c.JobTitle,
+			Email:       c.Email,
+			Phone:       c.Phone,
+			Mobile:      c.Mobile,
+			LinkedInURL: c.LinkedInURL,
+			Notes:       c.Notes,
+		})
+	}
+	writeAddressBook(w, r, "contacts", entries, addressbook.KindIndividual)
+}
+
+// handleOrganizationExport writes organizations, optionally filtered by q
+// and ids, as vCard or CSV.
+func (s *Server) handleOrganizationExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	f := parseContactFilter(r)
+	entries := make([]addressbook.Entry, 0, len(orgs))
+	for _, o := range orgs {
+		if len(f.ids) > 0 && !slices.Contains(f.ids, o.ID) {
+			continue
+		}
+		if f.q != "" && !strings.Contains(strings.ToLower(o.Name+" "+o.Website+" "+o.Email+" "+o.City), f.q) {
+			continue
+		}
+		entries = append(entries, addressbook.Entry{
+			Kind:     addressbook.KindOrganization,
+			UID:      "organization-" + o.ID + "@wemadeit",
+			Company:  o.Name,
+			Industry: o.Industry,
+			Website:  o.Website,
+			Email:    o.Email,
+			Phone:    o.Phone,
+			TaxID:    o.TaxID,
+			Address:  o.Address,
+			City:     o.City,
+			Country:  o.Country,
+			Notes:    o.Notes,
+		})
+	}
+	writeAddressBook(w, r, "organizations", entries, addressbook.KindOrganization)
+}
+
+func writeAddressBook(w http.ResponseWriter, r *http.Request, name string, entries []addressbook.Entry, kind string) {
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))) {
+	case "", "vcard", "vcf":
+		w.Header().Set("Content-Type", "text/vcard; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.vcf"`)
+		w.WriteHeader(http.StatusOK)
+		_ = addressbook.WriteVCard(w, entries)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		_ = addressbook.WriteCSV(w, entries, kind)
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse("format must be vcard or csv"))
+	}
+}
+
+// setIfGiven overwrites dst with a non-blank v, noting in changed whether
+// that altered it.
+func setIfGiven(changed *bool, dst *string, v string) {
+	if v = strings.TrimSpace(v); v != "" && v != *dst {
+		*dst = v
+		*changed = true
+	}
+}
+
+func isTrue(v string) bool {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
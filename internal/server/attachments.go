@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/blob"
+	"wemadeit/internal/models"
+)
+
+// Attachments can go wherever notes can.
+var attachmentEntityTypes = noteEntityTypes
+
+// inlineContentTypes are safe to display in the browser; anything else is
+// always downloaded.
+var inlineContentTypes = []string{"application/pdf", "image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// SetBlobStore sets where attachment contents are kept; without one the
+// attachment endpoints answer 503.
+func (s *Server) SetBlobStore(b blob.Store) {
+	s.mu.Lock()
+	s.blobs = b
+	s.mu.Unlock()
+}
+
+func (s *Server) blobStore() blob.Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.blobs
+}
+
+func (s *Server) handleAttachments(w http.ResponseWriter, r *http.Request) {
+	user := mustAuth(r).User
+	blobs := s.blobStore()
+	if blobs == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse("attachment storage is not configured"))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		entityType := strings.TrimSpace(r.URL.Query().Get("entityType"))
+		entityID := strings.TrimSpace(r.URL.Query().Get("entityId"))
+		if !slices.Contains(attachmentEntityTypes, entityType) || entityID == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("entityType (one of "+strings.Join(attachmentEntityTypes, ", ")+") and entityId are required"))
+			return
+		}
+		if !s.canReadEntity(w, r, entityType, entityID) {
+			return
+		}
+		attachments, err := s.store.LoadAttachments(entityType, entityID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, attachments)
+	case http.MethodPost:
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			writeJSON(w, http.StatusBadRequest, errorResponse("upload the file as multipart/form-data"))
+			return
+		}
+		maxBytes := int64(s.settings.AttachmentMaxMB) << 20
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes+1<<20)
+		data, filename, err := readUpload(r, maxBytes)
+		if err != nil {
+			status := http.StatusBadRequest
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) || err.Error() == "upload too large" {
+				status = http.StatusRequestEntityTooLarge
+				err = errors.New("files are limited to " + strconv.Itoa(s.settings.AttachmentMaxMB) + " MB")
+			}
+			writeJSON(w, status, errorResponse(err.Error()))
+			return
+		}
+		a := models.Attachment{
+			EntityType:       strings.TrimSpace(r.FormValue("entityType")),
+			EntityID:         strings.TrimSpace(r.FormValue("entityId")),
+			Filename:         cleanFilename(filename),
+			UploadedByUserID: user.ID,
+		}
+		if !slices.Contains(attachmentEntityTypes, a.EntityType) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("entityType must be one of "+strings.Join(attachmentEntityTypes, ", ")))
+			return
+		}
+		missing, err := s.store.MissingEntities(a.EntityType, []string{a.EntityID})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if len(missing) > 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse(a.EntityType+" not found"))
+			return
+		}
+		if !s.canReadEntity(w, r, a.EntityType, a.EntityID) {
+			return
+		}
+		a.ContentType = sniffContentType(data, a.Filename)
+
+		// Storing the blob and registering it happen under blobMu so the
+		// sweep never deletes content an upload is about to reference.
+		s.blobMu.Lock()
+		a.SHA256, a.Size, err = blobs.Put(r.Context(), bytes.NewReader(data))
+		if err == nil {
+			a.ID = newID()
+			a.CreatedAt = time.Now()
+			err = s.store.SaveAttachment(a)
+		}
+		s.blobMu.Unlock()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			a, ok, err := s.store.FindAttachmentByID(id)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			if ok && !s.canReadEntity(w, r, a.EntityType, a.EntityID) {
+				return
+			}
+			if ok && a.UploadedByUserID != user.ID && user.Role != models.RoleAdmin {
+				writeJSON(w, http.StatusForbidden, errorResponse("only the uploader can delete an attachment"))
+				return
+			}
+		}
+		for _, id := range ids {
+			if err := s.store.DeleteAttachment(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		if err := s.sweepBlobs(r.Context()); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleAttachmentDownload serves the content of ?id=. PDFs and images are
+// shown inline with ?inline=1; everything else is a download.
+func (s *Server) handleAttachmentDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	blobs := s.blobStore()
+	if blobs == nil {
+		writeJSON(w, http.StatusServiceUnavailable, errorResponse("attachment storage is not configured"))
+		return
+	}
+	a, ok, err := s.store.FindAttachmentByID(strings.TrimSpace(r.URL.Query().Get("id")))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse("attachment not found"))
+		return
+	}
+	if !s.canReadEntity(w, r, a.EntityType, a.EntityID) {
+		return
+	}
+	etag := `"` + a.SHA256 + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	content, err := blobs.Open(r.Context(), a.SHA256)
+	if errors.Is(err, blob.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, errorResponse("attachment content is missing"))
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	defer content.Close()
+
+	disposition := "attachment"
+	if isTrue(r.URL.Query().Get("inline")) && slices.Contains(inlineContentTypes, a.ContentType) {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, content)
+}
+
+// sweepBlobs deletes the blobs no attachment references any more, e.g.
+// after their records were deleted.
+func (s *Server) sweepBlobs(ctx context.Context) error {
+	blobs := s.blobStore()
+	if blobs == nil {
+		return nil
+	}
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+	keys, err := s.store.UnreferencedBlobs()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := blobs.Delete(ctx, key); err != nil {
+			return err
+		}
+		if err := s.store.ForgetBlob(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sniffContentType detects the type from the content, trusting the file
+// extension only to refine generic results (e.g. a .docx sniffs as zip).
+func sniffContentType(data []byte, filename string) string {
+	sniffed := http.DetectContentType(data)
+	base, _, _ := mime.ParseMediaType(sniffed)
+	switch base {
+	case "application/octet-stream", "application/zip", "text/plain":
+		byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(filename)))
+		extBase, _, _ := mime.ParseMediaType(byExt)
+		if byExt != "" && extBase != "text/html" && extBase != "image/svg+xml" && !strings.Contains(extBase, "javascript") {
+			return byExt
+		}
+	}
+	return sniffed
+}
+
+// cleanFilename keeps the base name of an uploaded file, without path parts
+// or control characters.
+func cleanFilename(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, filepath.Base(name))
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
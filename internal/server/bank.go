@@ -0,0 +1,350 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/bankimport"
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+const maxStatementBytes = 20 << 20
+
+type bankInboxEntry struct {
+	models.BankTransaction
+	Suggestions []bankimport.Suggestion `json:"suggestions"`
+}
+
+func (s *Server) handleBankImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	data, filename, err := readUpload(r, maxStatementBytes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(r.FormValue("format")))
+	if format == "" {
+		trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+		if bytes.HasPrefix(trimmed, []byte("<")) || strings.HasSuffix(strings.ToLower(filename), ".xml") {
+			format = "camt053"
+		} else {
+			format = "csv"
+		}
+	}
+
+	var txs []bankimport.Transaction
+	switch format {
+	case "csv":
+		var mapping bankimport.CSVMapping
+		raw := strings.TrimSpace(r.FormValue("mapping"))
+		if raw == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("mapping is required for csv imports"))
+			return
+		}
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse("invalid mapping: "+err.Error()))
+			return
+		}
+		txs, err = bankimport.ParseCSV(bytes.NewReader(data), mapping)
+	case "camt053", "camt.053", "camt":
+		format = "camt053"
+		txs, err = bankimport.ParseCAMT053(bytes.NewReader(data))
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse("format must be csv or camt053"))
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	imported := make([]models.BankTransaction, 0, len(txs))
+	duplicates := 0
+	fingerprints := bankimport.Fingerprints(txs)
+	for i, t := range txs {
+		bt := models.BankTransaction{
+			ID:           newID(),
+			ExternalID:   fingerprints[i],
+			Source:       format,
+			BookedAt:     t.BookedAt,
+			Amount:       t.Amount,
+			Currency:     t.Currency,
+			Counterparty: t.Counterparty,
+			Reference:    t.Reference,
+			Description:  t.Description,
+			Status:       models.BankTransactionUnmatched,
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}
+		if bt.Currency == "" {
+			bt.Currency = "EUR"
+		}
+		inserted, err := s.store.InsertBankTransaction(bt)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if !inserted {
+			duplicates++
+			continue
+		}
+		imported = append(imported, bt)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"imported":     len(imported),
+		"duplicates":   duplicates,
+		"transactions": imported,
+	})
+}
+
+func (s *Server) handleBankTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		status := models.BankTransactionStatus(strings.TrimSpace(r.URL.Query().Get("status")))
+		switch status {
+		case "":
+			status = models.BankTransactionUnmatched
+		case "all":
+			status = ""
+		case models.BankTransactionUnmatched, models.BankTransactionMatched, models.BankTransactionIgnored:
+			// ok
+		default:
+			writeJSON(w, http.StatusBadRequest, errorResponse("invalid status"))
+			return
+		}
+		windowDays := 30
+		if v := strings.TrimSpace(r.URL.Query().Get("window_days")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("window_days must be a non-negative integer"))
+				return
+			}
+			windowDays = n
+		}
+
+		txs, err := s.store.LoadBankTransactions(status)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		candidates, err := s.reconciliationCandidates()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		window := time.Duration(windowDays) * 24 * time.Hour
+
+		out := make([]bankInboxEntry, 0, len(txs))
+		for _, t := range txs {
+			entry := bankInboxEntry{BankTransaction: t, Suggestions: []bankimport.Suggestion{}}
+			if t.Status == models.BankTransactionUnmatched {
+				entry.Suggestions = bankimport.Suggest(bankimport.Transaction{
+					BookedAt:     t.BookedAt,
+					Amount:       t.Amount,
+					Currency:     t.Currency,
+					Counterparty: t.Counterparty,
+					Reference:    t.Reference,
+					Description:  t.Description,
+				}, candidates, window, 5)
+			}
+			out = append(out, entry)
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		var payload struct {
+			ID     string                       `json:"id"`
+			Status models.BankTransactionStatus `json:"status"`
+		}
+		if err := readJSON(r, &payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		switch payload.Status {
+		case models.BankTransactionUnmatched, models.BankTransactionIgnored:
+			// ok
+		default:
+			writeJSON(w, http.StatusBadRequest, errorResponse("status must be unmatched or ignored"))
+			return
+		}
+		t, ok, err := s.store.FindBankTransactionByID(strings.TrimSpace(payload.ID))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, errorResponse("transaction not found"))
+			return
+		}
+		if t.Status == models.BankTransactionMatched {
+			writeJSON(w, http.StatusConflict, errorResponse("transaction is already matched"))
+			return
+		}
+		if err := s.store.UpdateBankTransactionStatus(t.ID, payload.Status); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		t.Status = payload.Status
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			if err := s.store.DeleteBankTransaction(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+func (s *Server) handleBankReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		TransactionID string `json:"transactionId"`
+		PaymentID     string `json:"paymentId"`
+		Method        string `json:"method"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+
+	t, ok, err := s.store.FindBankTransactionByID(strings.TrimSpace(payload.TransactionID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse("transaction not found"))
+		return
+	}
+	if t.Status == models.BankTransactionMatched {
+		writeJSON(w, http.StatusConflict, errorResponse("transaction is already matched"))
+		return
+	}
+	p, ok, err := s.store.FindPaymentByID(strings.TrimSpace(payload.PaymentID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse("payment not found"))
+		return
+	}
+	if p.Status != models.PaymentPlanned {
+		writeJSON(w, http.StatusConflict, errorResponse("payment is not planned"))
+		return
+	}
+
+	method := strings.TrimSpace(payload.Method)
+	if method == "" {
+		method = "bank_transfer"
+	}
+	if err := s.store.ReconcileBankTransaction(t.ID, p.ID, method, t.BookedAt); errors.Is(err, db.ErrPaymentNotPlanned) || errors.Is(err, db.ErrTransactionMatched) {
+		writeJSON(w, http.StatusConflict, errorResponse(err.Error()))
+		return
+	} else if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+
+	p, _, err = s.store.FindPaymentByID(p.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	// The paid date moved to the booking date, so the rate may have too.
+	if err := s.updatePaymentReporting(&p); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	t, _, err = s.store.FindBankTransactionByID(t.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"transaction": t, "payment": p})
+}
+
+// reconciliationCandidates builds the match candidates for every planned
+// payment, with the quotation numbers and names a payer is likely to quote.
+func (s *Server) reconciliationCandidates() ([]bankimport.Candidate, error) {
+	payments, err := s.store.LoadPayments()
+	if err != nil {
+		return nil, err
+	}
+	deals, err := s.store.LoadDeals()
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		return nil, err
+	}
+	quotes, err := s.store.LoadQuotations()
+	if err != nil {
+		return nil, err
+	}
+
+	dealByID := make(map[string]models.Deal, len(deals))
+	for _, d := range deals {
+		dealByID[d.ID] = d
+	}
+	orgByID := make(map[string]models.Organization, len(orgs))
+	for _, o := range orgs {
+		orgByID[o.ID] = o
+	}
+	quotesByDeal := make(map[string][]models.Quotation)
+	for _, q := range quotes {
+		quotesByDeal[q.DealID] = append(quotesByDeal[q.DealID], q)
+	}
+
+	out := make([]bankimport.Candidate, 0)
+	for _, p := range payments {
+		if p.Status != models.PaymentPlanned {
+			continue
+		}
+		c := bankimport.Candidate{
+			PaymentID: p.ID,
+			Amount:    p.Amount,
+			Currency:  p.Currency,
+			DueAt:     p.DueAt,
+			Keywords:  []bankimport.Keyword{{Text: p.Title, Label: "payment", Weight: 10}},
+		}
+		if d, ok := dealByID[p.DealID]; ok {
+			c.Keywords = append(c.Keywords, bankimport.Keyword{Text: d.Title, Label: "deal", Weight: 10})
+			if o, ok := orgByID[d.OrganizationID]; ok {
+				c.Keywords = append(c.Keywords, bankimport.Keyword{Text: o.Name, Label: "organization", Weight: 20})
+			}
+		}
+		for _, q := range quotesByDeal[p.DealID] {
+			c.Keywords = append(c.Keywords, bankimport.Keyword{Text: q.Number, Label: "quotation", Weight: 40})
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
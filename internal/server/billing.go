@@ -0,0 +1,322 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"wemadeit/internal/billing"
+	"wemadeit/internal/models"
+)
+
+func (s *Server) handleBillingPlans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		plans, err := s.store.LoadBillingPlansFor(viewer(r))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		dealID := strings.TrimSpace(r.URL.Query().Get("dealId"))
+		projectID := strings.TrimSpace(r.URL.Query().Get("projectId"))
+		if dealID != "" || projectID != "" {
+			filtered := make([]models.BillingPlan, 0, len(plans))
+			for _, p := range plans {
+				if dealID != "" && p.DealID != dealID {
+					continue
+				}
+				if projectID != "" && p.ProjectID != projectID {
+					continue
+				}
+				filtered = append(filtered, p)
+			}
+			plans = filtered
+		}
+		writeJSON(w, http.StatusOK, plans)
+	case http.MethodPost:
+		// Active is a pointer so an update that leaves it out keeps billing.
+		var payload struct {
+			models.BillingPlan
+			Active *bool `json:"active"`
+		}
+		if err := readJSON(r, &payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		p := payload.BillingPlan
+		now := time.Now()
+		p.Active = true
+		if strings.TrimSpace(p.ID) == "" {
+			p.ID = newID()
+		} else {
+			if !s.canSeeStoredDeal(w, r, "billing_plan", p.ID) {
+				return
+			}
+			plans, err := s.store.LoadBillingPlans()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			if i := slices.IndexFunc(plans, func(o models.BillingPlan) bool { return o.ID == p.ID }); i >= 0 {
+				p.Active = plans[i].Active
+			}
+		}
+		if payload.Active != nil {
+			p.Active = *payload.Active
+		}
+		if p.CreatedAt.IsZero() {
+			p.CreatedAt = now
+		}
+		p.UpdatedAt = now
+
+		if strings.TrimSpace(p.ProjectID) != "" {
+			projects, err := s.store.LoadProjects()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			found := false
+			for _, pr := range projects {
+				if pr.ID == p.ProjectID {
+					found = true
+					if strings.TrimSpace(p.DealID) == "" {
+						p.DealID = pr.DealID
+					} else if p.DealID != pr.DealID {
+						writeJSON(w, http.StatusBadRequest, errorResponse("projectId does not belong to dealId"))
+						return
+					}
+					break
+				}
+			}
+			if !found {
+				writeJSON(w, http.StatusBadRequest, errorResponse("projectId not found"))
+				return
+			}
+		}
+		if strings.TrimSpace(p.DealID) == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("dealId or projectId is required"))
+			return
+		}
+		if _, ok, err := s.store.FindDealByID(p.DealID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		} else if !ok {
+			writeJSON(w, http.StatusBadRequest, errorResponse("dealId not found"))
+			return
+		}
+		if !s.canSee(w, r, "deal", p.DealID) {
+			return
+		}
+		if strings.TrimSpace(p.Title) == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("title is required"))
+			return
+		}
+		if p.Interval == "" {
+			p.Interval = models.BillingMonthly
+		}
+		if billing.Months(p.Interval) == 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("interval must be monthly, quarterly or yearly"))
+			return
+		}
+		if p.Amount < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("amount must be >= 0"))
+			return
+		}
+		if p.StartAt.IsZero() {
+			writeJSON(w, http.StatusBadRequest, errorResponse("startAt is required"))
+			return
+		}
+		if p.EndAt != nil && p.EndAt.Before(p.StartAt) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("endAt must be after startAt"))
+			return
+		}
+		if strings.TrimSpace(p.Currency) == "" {
+			p.Currency = "EUR"
+		}
+
+		if err := s.store.SaveBillingPlan(p); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, p)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			if !s.canSeeStoredDeal(w, r, "billing_plan", id) {
+				return
+			}
+			if err := s.store.DeleteBillingPlan(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleBillingPlansRun triggers the recurring billing jobs immediately
+// instead of waiting for the next scheduler tick.
+func (s *Server) handleBillingPlansRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	now := time.Now()
+	recurring, err := s.generateBillingPayments(now)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	renewals, err := s.generateDomainRenewals(now)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"payments": recurring,
+		"renewals": renewals,
+	})
+}
+
+// generateBillingPayments creates the planned payments of every active plan
+// falling due between today and the configured billing horizon.
+func (s *Server) generateBillingPayments(now time.Time) ([]models.Payment, error) {
+	s.mu.RLock()
+	horizonDays := s.settings.BillingHorizonDays
+	s.mu.RUnlock()
+
+	plans, err := s.store.LoadBillingPlans()
+	if err != nil {
+		return nil, err
+	}
+	from := startOfDay(now)
+	until := from.AddDate(0, 0, horizonDays)
+
+	conv, reporting, err := s.reportingConverter()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]models.Payment, 0)
+	for _, plan := range plans {
+		if !plan.Active {
+			continue
+		}
+		for _, due := range billing.DueDates(plan, from, until) {
+			dueAt := due
+			p := models.Payment{
+				ID:        newID(),
+				DealID:    plan.DealID,
+				Title:     fmt.Sprintf("%s (%s)", plan.Title, billing.PeriodLabel(plan.Interval, due)),
+				Amount:    plan.Amount,
+				Currency:  plan.Currency,
+				Status:    models.PaymentPlanned,
+				DueAt:     &dueAt,
+				Notes:     "Generated by billing plan " + plan.ID,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			applyReporting(conv, reporting, &p)
+			ok, err := s.store.CreateScheduledPayment("billing_plan", plan.ID, due, p)
+			if err != nil {
+				return created, err
+			}
+			if ok {
+				created = append(created, p)
+			}
+		}
+	}
+	return created, nil
+}
+
+// generateDomainRenewals plans a renewal payment ahead of each tracked
+// domain's expiry, once per expiry date. Domains not linked to a deal are
+// billed against the organization's most recent deal.
+func (s *Server) generateDomainRenewals(now time.Time) ([]models.Payment, error) {
+	s.mu.RLock()
+	leadDays := s.settings.DomainRenewalLeadDays
+	s.mu.RUnlock()
+
+	from := startOfDay(now)
+	until := from.AddDate(0, 0, leadDays)
+	domains, err := s.store.LoadDomainsExpiringBefore(from, until)
+	if err != nil {
+		return nil, err
+	}
+	if len(domains) == 0 {
+		return []models.Payment{}, nil
+	}
+	deals, err := s.store.LoadDeals()
+	if err != nil {
+		return nil, err
+	}
+	dealByID := make(map[string]models.Deal, len(deals))
+	latestByOrg := make(map[string]models.Deal)
+	for _, d := range deals {
+		dealByID[d.ID] = d
+		if d.Status == models.DealLost {
+			continue
+		}
+		if cur, ok := latestByOrg[d.OrganizationID]; !ok || d.CreatedAt.After(cur.CreatedAt) {
+			latestByOrg[d.OrganizationID] = d
+		}
+	}
+
+	conv, reporting, err := s.reportingConverter()
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]models.Payment, 0)
+	for _, dom := range domains {
+		deal, ok := dealByID[dom.DealID]
+		if !ok {
+			deal, ok = latestByOrg[dom.OrganizationID]
+		}
+		if !ok || deal.Status == models.DealLost {
+			continue
+		}
+		expires := *dom.ExpiresAt
+		currency := dom.Currency
+		if currency == "" {
+			currency = "EUR"
+		}
+		p := models.Payment{
+			ID:        newID(),
+			DealID:    deal.ID,
+			Title:     "Domain renewal " + dom.Name,
+			Amount:    dom.RenewalPrice,
+			Currency:  currency,
+			Status:    models.PaymentPlanned,
+			DueAt:     &expires,
+			Notes:     "Generated ahead of domain expiry",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		applyReporting(conv, reporting, &p)
+		ok, err := s.store.CreateScheduledPayment("domain_renewal", dom.ID, expires, p)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created = append(created, p)
+		}
+	}
+	return created, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
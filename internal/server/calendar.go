@@ -0,0 +1,344 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"wemadeit/internal/auth"
+	"wemadeit/internal/db"
+	"wemadeit/internal/domaininfo"
+	"wemadeit/internal/ical"
+	"wemadeit/internal/models"
+)
+
+// calendarCategories lists what a feed can publish, in feed order.
+var calendarCategories = []string{
+	models.CalendarFollowUps,
+	models.CalendarTasks,
+	models.CalendarPayments,
+	models.CalendarQuotations,
+	models.CalendarDomains,
+}
+
+// calendarHistory is how far back a feed reaches; older deadlines only add
+// noise to calendar apps.
+const calendarHistory = 90 * 24 * time.Hour
+
+type calendarFeedResponse struct {
+	models.CalendarFeed
+	URL string `json:"url"`
+}
+
+// handleCalendarFeed manages the caller's own feed. POST creates it or
+// changes its categories, DELETE turns it off.
+func (s *Server) handleCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	userID := mustAuth(r).User.ID
+	switch r.Method {
+	case http.MethodGet:
+		feed, ok, err := s.store.FindCalendarFeedByUser(userID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusOK, map[string]any{"enabled": false, "categories": calendarCategories})
+			return
+		}
+		writeJSON(w, http.StatusOK, calendarFeedResponse{CalendarFeed: feed, URL: calendarFeedURL(r, feed.Token)})
+	case http.MethodPost:
+		var payload struct {
+			Categories []string `json:"categories"`
+		}
+		if err := readJSON(r, &payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		categories, err := normalizeCalendarCategories(payload.Categories)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		feed, ok, err := s.store.FindCalendarFeedByUser(userID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		now := time.Now()
+		if !ok {
+			feed = models.CalendarFeed{UserID: userID, CreatedAt: now}
+			if feed.Token, err = auth.NewToken(24); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		feed.Categories = categories
+		feed.UpdatedAt = now
+		if err := s.store.SaveCalendarFeed(feed); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, calendarFeedResponse{CalendarFeed: feed, URL: calendarFeedURL(r, feed.Token)})
+	case http.MethodDelete:
+		if err := s.store.DeleteCalendarFeed(userID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleCalendarFeedRotate replaces the feed token, cutting off every
+// existing subscription.
+func (s *Server) handleCalendarFeedRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	feed, ok, err := s.store.FindCalendarFeedByUser(mustAuth(r).User.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse("calendar feed is not enabled"))
+		return
+	}
+	if feed.Token, err = auth.NewToken(24); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	feed.UpdatedAt = time.Now()
+	if err := s.store.SaveCalendarFeed(feed); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, calendarFeedResponse{CalendarFeed: feed, URL: calendarFeedURL(r, feed.Token)})
+}
+
+// handleCalendar serves /api/calendar/{token}.ics without a session; the
+// token is the credential.
+func (s *Server) handleCalendar(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSuffix(r.PathValue("file"), ".ics")
+	if token == "" {
+		http.NotFound(w, r)
+		return
+	}
+	feed, ok, err := s.store.FindCalendarFeedByToken(token)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	user, ok, err := s.store.FindUserByID(feed.UserID)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	cal, err := s.buildCalendar(user, feed.Categories, time.Now())
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="wemadeit.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	_, _ = cal.WriteTo(w)
+}
+
+// buildCalendar collects the deadlines in the chosen categories, as the
+// user would see them: follow-ups, tasks and quotations are their own,
+// payments those on deals they can see, and categories their role may not
+// read are left out. UIDs are derived from record IDs so calendar apps
+// update entries in place.
+func (s *Server) buildCalendar(user models.User, categories []string, now time.Time) (ical.Calendar, error) {
+	cal := ical.Calendar{Name: "WeMadeIt - " + firstNonBlank(user.Name, user.Username)}
+	cal.Items = make([]ical.Item, 0)
+	since := startOfDay(now.Add(-calendarHistory))
+	v := db.ViewerFor(user)
+	// Each category is named after the resource it publishes.
+	categories = slices.DeleteFunc(slices.Clone(categories), func(c string) bool {
+		return !allowed(user.Role, c, actionRead)
+	})
+
+	if slices.Contains(categories, models.CalendarFollowUps) {
+		interactions, err := s.store.LoadInteractionsFor(v)
+		if err != nil {
+			return cal, err
+		}
+		for _, it := range interactions {
+			if it.UserID != user.ID || it.FollowUpCompleted || it.FollowUpDate == nil || it.FollowUpDate.Before(since) {
+				continue
+			}
+			cal.Items = append(cal.Items, ical.Item{
+				Component:   ical.Event,
+				UID:         "follow-up-" + it.ID + "@wemadeit",
+				Summary:     "Follow up: " + firstNonBlank(it.Subject, string(it.InteractionType)),
+				Description: it.FollowUpNotes,
+				Categories:  []string{"Follow-up"},
+				Start:       *it.FollowUpDate,
+				Stamp:       it.UpdatedAt,
+			})
+		}
+	}
+
+	if slices.Contains(categories, models.CalendarTasks) {
+		tasks, err := s.store.LoadTasksFor(v)
+		if err != nil {
+			return cal, err
+		}
+		for _, t := range tasks {
+			if t.OwnerUserID != user.ID || t.DueDate == nil || t.DueDate.Before(since) {
+				continue
+			}
+			cal.Items = append(cal.Items, ical.Item{
+				Component:   ical.Todo,
+				UID:         "task-" + t.ID + "@wemadeit",
+				Summary:     t.Title,
+				Description: t.Description,
+				Categories:  []string{"Task"},
+				Start:       *t.DueDate,
+				AllDay:      true,
+				Completed:   t.Status == models.TaskDone,
+				Stamp:       t.UpdatedAt,
+			})
+		}
+	}
+
+	if slices.Contains(categories, models.CalendarPayments) {
+		payments, err := s.store.LoadPaymentsFor(v)
+		if err != nil {
+			return cal, err
+		}
+		for _, p := range payments {
+			if p.Status != models.PaymentPlanned || p.DueAt == nil || p.DueAt.Before(since) {
+				continue
+			}
+			cal.Items = append(cal.Items, ical.Item{
+				Component:   ical.Event,
+				UID:         "payment-" + p.ID + "@wemadeit",
+				Summary:     fmt.Sprintf("Payment due: %s (%.2f %s)", p.Title, p.Amount, p.Currency),
+				Description: p.Notes,
+				Categories:  []string{"Payment"},
+				Start:       *p.DueAt,
+				AllDay:      true,
+				Stamp:       p.UpdatedAt,
+			})
+		}
+	}
+
+	if slices.Contains(categories, models.CalendarQuotations) {
+		quotes, err := s.store.LoadQuotationsFor(v)
+		if err != nil {
+			return cal, err
+		}
+		for _, q := range quotes {
+			if q.CreatedByUserID != user.ID || q.ValidUntil == nil || q.ValidUntil.Before(since) {
+				continue
+			}
+			switch q.Status {
+			case models.QuotationDraft, models.QuotationSent, models.QuotationViewed:
+			default:
+				continue
+			}
+			cal.Items = append(cal.Items, ical.Item{
+				Component:  ical.Event,
+				UID:        "quotation-" + q.ID + "@wemadeit",
+				Summary:    "Quote expires: " + strings.TrimSpace(q.Number+" "+q.Title),
+				Categories: []string{"Quotation"},
+				Start:      *q.ValidUntil,
+				AllDay:     true,
+				Stamp:      q.UpdatedAt,
+			})
+		}
+	}
+
+	if slices.Contains(categories, models.CalendarDomains) {
+		domains, err := s.store.LoadDomainsFor(v)
+		if err != nil {
+			return cal, err
+		}
+		tracked := make(map[string]bool, len(domains))
+		for _, d := range domains {
+			tracked[d.Name] = true
+			if d.ExpiresAt == nil || d.ExpiresAt.Before(since) {
+				continue
+			}
+			cal.Items = append(cal.Items, ical.Item{
+				Component:  ical.Event,
+				UID:        "domain-" + d.ID + "@wemadeit",
+				Summary:    "Domain expires: " + d.Name,
+				Categories: []string{"Domain"},
+				Start:      *d.ExpiresAt,
+				AllDay:     true,
+				Stamp:      d.UpdatedAt,
+			})
+		}
+		// Deals whose domain predates the domains table.
+		deals, err := s.store.LoadDealsFor(v)
+		if err != nil {
+			return cal, err
+		}
+		for _, d := range deals {
+			name := domaininfo.Normalize(d.Domain)
+			if name == "" || tracked[name] || d.DomainExpiresAt == nil || d.DomainExpiresAt.Before(since) {
+				continue
+			}
+			cal.Items = append(cal.Items, ical.Item{
+				Component:  ical.Event,
+				UID:        "deal-domain-" + d.ID + "@wemadeit",
+				Summary:    "Domain expires: " + name,
+				Categories: []string{"Domain"},
+				Start:      *d.DomainExpiresAt,
+				AllDay:     true,
+				Stamp:      d.UpdatedAt,
+			})
+		}
+	}
+	return cal, nil
+}
+
+// normalizeCalendarCategories validates the chosen categories; none chosen
+// means all of them.
+func normalizeCalendarCategories(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return slices.Clone(calendarCategories), nil
+	}
+	out := make([]string, 0, len(in))
+	for _, c := range calendarCategories {
+		if slices.Contains(in, c) {
+			out = append(out, c)
+		}
+	}
+	for _, c := range in {
+		if !slices.Contains(calendarCategories, c) {
+			return nil, fmt.Errorf("unknown category %q (want %s)", c, strings.Join(calendarCategories, ", "))
+		}
+	}
+	return out, nil
+}
+
+// calendarFeedURL builds the subscription URL as seen by the client, which
+// may sit behind the nginx proxy.
+func calendarFeedURL(r *http.Request, token string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if v := strings.TrimSpace(r.Header.Get("X-Forwarded-Proto")); v != "" {
+		scheme = v
+	}
+	return scheme + "://" + r.Host + "/api/calendar/" + token + ".ics"
+}
@@ -0,0 +1,528 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// customFieldEntityTypes are the entity types that can carry custom fields,
+// named as in history entries.
+var customFieldEntityTypes = []string{"organization", "contact", "deal", "project"}
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+func (s *Server) handleCustomFields(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		entityType := strings.TrimSpace(r.URL.Query().Get("entityType"))
+		if entityType != "" && !slices.Contains(customFieldEntityTypes, entityType) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("unknown entityType"))
+			return
+		}
+		fields, err := s.store.LoadCustomFields(entityType)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, fields)
+	case http.MethodPost:
+		if mustAuth(r).User.Role != models.RoleAdmin {
+			writeJSON(w, http.StatusForbidden, errorResponse("forbidden"))
+			return
+		}
+		var f models.CustomField
+		if err := readJSON(r, &f); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		f.Label = strings.TrimSpace(f.Label)
+		if f.Label == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("label is required"))
+			return
+		}
+		fields, err := s.store.LoadCustomFields("")
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+
+		now := time.Now()
+		prev, existed := models.CustomField{}, false
+		if f.ID != "" {
+			prev, existed, err = s.store.FindCustomFieldByID(f.ID)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		if existed {
+			// Stored values depend on these, so they are fixed at creation.
+			if (f.EntityType != "" && f.EntityType != prev.EntityType) ||
+				(f.Key != "" && f.Key != prev.Key) ||
+				(f.Type != "" && f.Type != prev.Type) {
+				writeJSON(w, http.StatusBadRequest, errorResponse("entityType, key and type cannot be changed"))
+				return
+			}
+			f.EntityType, f.Key, f.Type, f.CreatedAt = prev.EntityType, prev.Key, prev.Type, prev.CreatedAt
+		} else {
+			if f.ID == "" {
+				f.ID = newID()
+			}
+			f.CreatedAt = now
+			if !slices.Contains(customFieldEntityTypes, f.EntityType) {
+				writeJSON(w, http.StatusBadRequest, errorResponse("entityType must be one of "+strings.Join(customFieldEntityTypes, ", ")))
+				return
+			}
+			if f.Key == "" {
+				f.Key = customFieldKey(f.Label)
+			}
+			if !customFieldKeyPattern.MatchString(f.Key) {
+				writeJSON(w, http.StatusBadRequest, errorResponse("key must be lowercase letters, digits and underscores, starting with a letter"))
+				return
+			}
+			switch f.Type {
+			case models.CustomFieldText, models.CustomFieldNumber, models.CustomFieldMoney,
+				models.CustomFieldDate, models.CustomFieldSelect, models.CustomFieldBoolean:
+			default:
+				writeJSON(w, http.StatusBadRequest, errorResponse("type must be text, number, money, date, select or boolean"))
+				return
+			}
+			for _, other := range fields {
+				if other.EntityType == f.EntityType && other.Key == f.Key {
+					writeJSON(w, http.StatusConflict, errorResponse("a "+f.EntityType+" field with key "+f.Key+" already exists"))
+					return
+				}
+			}
+		}
+
+		options := make([]string, 0, len(f.Options))
+		for _, o := range f.Options {
+			if o = strings.TrimSpace(o); o != "" && !slices.Contains(options, o) {
+				options = append(options, o)
+			}
+		}
+		f.Options = options
+		if f.Type == models.CustomFieldSelect && len(f.Options) == 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("select fields need options"))
+			return
+		}
+		if f.Type != models.CustomFieldSelect {
+			f.Options = []string{}
+		}
+		if f.Position <= 0 && existed {
+			f.Position = prev.Position
+		}
+		if f.Position <= 0 {
+			for _, other := range fields {
+				if other.EntityType == f.EntityType && other.Position >= f.Position {
+					f.Position = other.Position + 1
+				}
+			}
+			f.Position = max(f.Position, 1)
+		}
+		f.UpdatedAt = now
+
+		if err := s.store.SaveCustomField(f); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, f)
+	case http.MethodDelete:
+		if mustAuth(r).User.Role != models.RoleAdmin {
+			writeJSON(w, http.StatusForbidden, errorResponse("forbidden"))
+			return
+		}
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if err := s.store.DeleteCustomField(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleCustomFieldsReorder sets the display order of one entity type's
+// fields from the given list of IDs.
+func (s *Server) handleCustomFieldsReorder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	if mustAuth(r).User.Role != models.RoleAdmin {
+		writeJSON(w, http.StatusForbidden, errorResponse("forbidden"))
+		return
+	}
+	var payload struct {
+		EntityType string   `json:"entityType"`
+		IDs        []string `json:"ids"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	if !slices.Contains(customFieldEntityTypes, payload.EntityType) {
+		writeJSON(w, http.StatusBadRequest, errorResponse("unknown entityType"))
+		return
+	}
+	fields, err := s.store.LoadCustomFields(payload.EntityType)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if len(payload.IDs) != len(fields) {
+		writeJSON(w, http.StatusBadRequest, errorResponse("ids must list every field of the entity type"))
+		return
+	}
+	for _, f := range fields {
+		if !slices.Contains(payload.IDs, f.ID) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("ids must list every field of the entity type"))
+			return
+		}
+	}
+	if err := s.store.ReorderCustomFields(payload.EntityType, payload.IDs); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	fields, err = s.store.LoadCustomFields(payload.EntityType)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, fields)
+}
+
+// customFieldKey derives a key from a label: "Share Gil %" -> "share_gil".
+func customFieldKey(label string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(label) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9' && b.Len() > 0:
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// customValues are the custom fields of one entity type with their stored
+// values, decoded for JSON by entity ID.
+type customValues struct {
+	fields []models.CustomField
+	stored map[string]map[string]string
+}
+
+func (s *Server) loadCustomValues(entityType string) (customValues, error) {
+	fields, err := s.store.LoadCustomFields(entityType)
+	if err != nil {
+		return customValues{}, err
+	}
+	stored, err := s.store.LoadCustomFieldValues(entityType)
+	if err != nil {
+		return customValues{}, err
+	}
+	return customValues{fields: fields, stored: stored}, nil
+}
+
+// of returns the entity's values by field key, or nil when it has none.
+func (cv customValues) of(entityID string) models.CustomValues {
+	stored := cv.stored[entityID]
+	if len(stored) == 0 {
+		return nil
+	}
+	out := make(models.CustomValues, len(stored))
+	for _, f := range cv.fields {
+		if raw, ok := stored[f.ID]; ok {
+			out[f.Key] = decodeCustomValue(f, raw)
+		}
+	}
+	return out
+}
+
+// customUpdate is a validated change to one entity's custom field values.
+type customUpdate struct {
+	changes    map[string]string // by field ID; "" clears
+	prev, next models.CustomValues
+}
+
+// customValuesFromRequest validates the customFields sent for an entity
+// against the definitions and what is already stored; ok is false once an
+// error response has been written. Keys missing from in keep their stored
+// value and null clears a field. Required fields must be set on new
+// entities and cannot be cleared, but existing entities saved before a
+// field became required can still be edited.
+func (s *Server) customValuesFromRequest(w http.ResponseWriter, entityType, entityID string, isNew bool, in models.CustomValues) (customUpdate, bool) {
+	cv, err := s.loadCustomValues(entityType)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return customUpdate{}, false
+	}
+	stored := cv.stored[entityID]
+	upd := customUpdate{changes: make(map[string]string), prev: cv.of(entityID)}
+	for key, v := range in {
+		i := slices.IndexFunc(cv.fields, func(f models.CustomField) bool { return f.Key == key })
+		if i < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("unknown custom field "+key))
+			return customUpdate{}, false
+		}
+		f := cv.fields[i]
+		raw, err := encodeCustomValue(f, v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(f.Label+": "+err.Error()))
+			return customUpdate{}, false
+		}
+		if raw != stored[f.ID] {
+			upd.changes[f.ID] = raw
+		}
+	}
+
+	merged := make(map[string]string, len(stored)+len(upd.changes))
+	for id, raw := range stored {
+		merged[id] = raw
+	}
+	for id, raw := range upd.changes {
+		if raw == "" {
+			delete(merged, id)
+		} else {
+			merged[id] = raw
+		}
+	}
+	for _, f := range cv.fields {
+		if _, cleared := upd.changes[f.ID]; f.Required && merged[f.ID] == "" && (isNew || cleared) {
+			writeJSON(w, http.StatusBadRequest, errorResponse(f.Label+" is required"))
+			return customUpdate{}, false
+		}
+	}
+	cv.stored = map[string]map[string]string{entityID: merged}
+	upd.next = cv.of(entityID)
+	return upd, true
+}
+
+// saveCustomValues stores a customUpdate and records it in the entity's
+// history as "cf.<key>" changes.
+func (s *Server) saveCustomValues(entityType, entityID, userID string, upd customUpdate) error {
+	if len(upd.changes) == 0 {
+		return nil
+	}
+	if err := s.store.SaveCustomFieldValues(entityType, entityID, upd.changes); err != nil {
+		return err
+	}
+	var history []fieldChange
+	for key, v := range upd.next {
+		history = append(history, fieldChange{field: "cf." + key, oldValue: customValueString(upd.prev[key]), newValue: customValueString(v)})
+	}
+	for key, v := range upd.prev {
+		if _, ok := upd.next[key]; !ok {
+			history = append(history, fieldChange{field: "cf." + key, oldValue: customValueString(v)})
+		}
+	}
+	return s.recordHistory(entityType, entityID, userID, history...)
+}
+
+// encodeCustomValue validates v for f and returns its stored form; nil and
+// blank strings clear the field.
+func encodeCustomValue(f models.CustomField, v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	if str, ok := v.(string); ok {
+		v = strings.TrimSpace(str)
+		if v == "" {
+			return "", nil
+		}
+	}
+	switch f.Type {
+	case models.CustomFieldText:
+		str, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("must be text")
+		}
+		return str, nil
+	case models.CustomFieldNumber, models.CustomFieldMoney:
+		var n float64
+		switch x := v.(type) {
+		case float64:
+			n = x
+		case string:
+			parsed, err := strconv.ParseFloat(strings.ReplaceAll(x, ",", "."), 64)
+			if err != nil {
+				return "", fmt.Errorf("must be a number")
+			}
+			n = parsed
+		default:
+			return "", fmt.Errorf("must be a number")
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", fmt.Errorf("must be a number")
+		}
+		if f.Type == models.CustomFieldMoney {
+			return strconv.FormatFloat(math.Round(n*100)/100, 'f', 2, 64), nil
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case models.CustomFieldDate:
+		str, ok := v.(string)
+		if !ok {
+			return "", fmt.Errorf("must be a date (YYYY-MM-DD)")
+		}
+		if t, err := time.Parse(time.RFC3339, str); err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+		t, err := time.Parse(time.DateOnly, str)
+		if err != nil {
+			return "", fmt.Errorf("must be a date (YYYY-MM-DD)")
+		}
+		return t.Format(time.DateOnly), nil
+	case models.CustomFieldSelect:
+		str, ok := v.(string)
+		if !ok || !slices.Contains(f.Options, str) {
+			return "", fmt.Errorf("must be one of %s", strings.Join(f.Options, ", "))
+		}
+		return str, nil
+	case models.CustomFieldBoolean:
+		switch x := v.(type) {
+		case bool:
+			return strconv.FormatBool(x), nil
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return "", fmt.Errorf("must be true or false")
+			}
+			return strconv.FormatBool(b), nil
+		}
+		return "", fmt.Errorf("must be true or false")
+	}
+	return "", fmt.Errorf("unsupported field type %s", f.Type)
+}
+
+func decodeCustomValue(f models.CustomField, raw string) any {
+	switch f.Type {
+	case models.CustomFieldNumber, models.CustomFieldMoney:
+		n, _ := strconv.ParseFloat(raw, 64)
+		return n
+	case models.CustomFieldBoolean:
+		return raw == "true"
+	}
+	return raw
+}
+
+func customValueString(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	case string:
+		return x
+	}
+	return fmt.Sprint(v)
+}
+
+// customFilter matches list entries against cf.<key> query parameters:
+// cf.<key>=v is a case-insensitive substring match for text and an exact
+// match otherwise, and cf.<key>.min / cf.<key>.max bound number, money and
+// date fields. cf.<key>=- matches entities without a value.
+type customFilter []func(models.CustomValues) bool
+
+func parseCustomFilter(r *http.Request, fields []models.CustomField) (customFilter, error) {
+	var out customFilter
+	for param, vals := range r.URL.Query() {
+		name, ok := strings.CutPrefix(param, "cf.")
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		want := strings.TrimSpace(vals[0])
+		key, bound, _ := strings.Cut(name, ".")
+		i := slices.IndexFunc(fields, func(f models.CustomField) bool { return f.Key == key })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown custom field %s", key)
+		}
+		f := fields[i]
+		if want == "-" && bound == "" {
+			out = append(out, func(v models.CustomValues) bool { return v[key] == nil })
+			continue
+		}
+		switch bound {
+		case "":
+			if f.Type == models.CustomFieldText {
+				want = strings.ToLower(want)
+				out = append(out, func(v models.CustomValues) bool {
+					return v[key] != nil && strings.Contains(strings.ToLower(customValueString(v[key])), want)
+				})
+				continue
+			}
+			raw, err := encodeCustomValue(f, want)
+			if err != nil {
+				return nil, fmt.Errorf("cf.%s: %w", key, err)
+			}
+			target := customValueString(decodeCustomValue(f, raw))
+			out = append(out, func(v models.CustomValues) bool {
+				return v[key] != nil && customValueString(v[key]) == target
+			})
+		case "min", "max":
+			if f.Type != models.CustomFieldNumber && f.Type != models.CustomFieldMoney && f.Type != models.CustomFieldDate {
+				return nil, fmt.Errorf("cf.%s.%s needs a number, money or date field", key, bound)
+			}
+			raw, err := encodeCustomValue(f, want)
+			if err != nil {
+				return nil, fmt.Errorf("cf.%s.%s: %w", key, bound, err)
+			}
+			limit := decodeCustomValue(f, raw)
+			out = append(out, func(v models.CustomValues) bool {
+				if v[key] == nil {
+					return false
+				}
+				c := compareCustomValues(v[key], limit)
+				if bound == "min" {
+					return c >= 0
+				}
+				return c <= 0
+			})
+		default:
+			return nil, fmt.Errorf("unknown filter cf.%s", name)
+		}
+	}
+	return out, nil
+}
+
+func (cf customFilter) match(v models.CustomValues) bool {
+	for _, m := range cf {
+		if !m(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareCustomValues orders two decoded values of the same field; dates
+// compare as YYYY-MM-DD strings.
+func compareCustomValues(a, b any) int {
+	if x, ok := a.(float64); ok {
+		y, _ := b.(float64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(customValueString(a), customValueString(b))
+}
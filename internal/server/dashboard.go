@@ -0,0 +1,300 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/fx"
+	"wemadeit/internal/models"
+)
+
+// dashboardListLimit caps each widget's item list; counts stay exact.
+const dashboardListLimit = 10
+
+type dashboardFollowUp struct {
+	InteractionID  string     `json:"interactionId"`
+	Subject        string     `json:"subject"`
+	FollowUpDate   *time.Time `json:"followUpDate,omitempty"`
+	FollowUpNotes  string     `json:"followUpNotes"`
+	OrganizationID string     `json:"organizationId"`
+	ContactID      string     `json:"contactId"`
+	DealID         string     `json:"dealId"`
+}
+
+type dashboardQuote struct {
+	QuotationID string                 `json:"quotationId"`
+	Number      string                 `json:"number"`
+	Title       string                 `json:"title"`
+	DealID      string                 `json:"dealId"`
+	Status      models.QuotationStatus `json:"status"`
+	Total       float64                `json:"total"`
+	Currency    string                 `json:"currency"`
+	ValidUntil  *time.Time             `json:"validUntil,omitempty"`
+	DaysWaiting int                    `json:"daysWaiting"`
+}
+
+// dashboardResponse holds one widget per resource; a widget is nil when the
+// caller's role may not read its resource.
+type dashboardResponse struct {
+	Currency  string              `json:"currency"`
+	Deals     *dashboardDeals     `json:"deals,omitempty"`
+	FollowUps *dashboardFollowUps `json:"followUps,omitempty"`
+	Tasks     *dashboardTasks     `json:"tasks,omitempty"`
+	Quotes    *dashboardQuotes    `json:"quotes,omitempty"`
+	Payments  *dashboardPayments  `json:"payments,omitempty"`
+	Domains   *dashboardDomains   `json:"domains,omitempty"`
+}
+
+type dashboardDeals struct {
+	Open     int     `json:"open"`
+	Value    float64 `json:"value"`
+	Weighted float64 `json:"weighted"`
+}
+
+type dashboardFollowUps struct {
+	DueToday     int                 `json:"dueToday"`
+	Overdue      int                 `json:"overdue"`
+	Items        []dashboardFollowUp `json:"items"`
+	OverdueItems []dashboardFollowUp `json:"overdueItems"`
+}
+
+type dashboardTasks struct {
+	ByStatus map[models.TaskStatus]int `json:"byStatus"`
+	Overdue  int                       `json:"overdue"`
+	Items    []models.Task             `json:"items"`
+}
+
+type dashboardQuotes struct {
+	Awaiting int              `json:"awaiting"`
+	Total    float64          `json:"total"`
+	Items    []dashboardQuote `json:"items"`
+}
+
+type dashboardPayments struct {
+	Due   int              `json:"due"`
+	Total float64          `json:"total"`
+	Items []models.Payment `json:"items"`
+}
+
+type dashboardDomains struct {
+	Expiring int              `json:"expiring"`
+	Items    []expiringDomain `json:"items"`
+}
+
+// handleDashboard returns every home-screen widget in one response. Follow-ups,
+// tasks and quotes are the caller's own; deals and payments are those on the
+// deals the caller can see. Widgets on resources the caller's role may not
+// read are left out.
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	out, err := s.buildDashboard(mustAuth(r).User, time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) buildDashboard(user models.User, now time.Time) (dashboardResponse, error) {
+	var out dashboardResponse
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	conv, reporting, err := s.reportingConverter()
+	if err != nil {
+		return out, err
+	}
+	out.Currency = reporting
+	s.mu.RLock()
+	leadDays := s.settings.DomainRenewalLeadDays
+	s.mu.RUnlock()
+	v := db.ViewerFor(user)
+
+	// Open deals, weighted by the deal's probability or else its stage's.
+	if allowed(user.Role, "deals", actionRead) {
+		out.Deals = &dashboardDeals{}
+		deals, err := s.store.LoadDealsFor(v)
+		if err != nil {
+			return out, err
+		}
+		stages, err := s.store.LoadPipelineStages()
+		if err != nil {
+			return out, err
+		}
+		stageProb := make(map[string]float64, len(stages))
+		for _, st := range stages {
+			stageProb[st.ID] = st.Probability
+		}
+		for _, d := range deals {
+			if d.Status != models.DealOpen {
+				continue
+			}
+			out.Deals.Open++
+			value, err := conv.Convert(d.Value, d.Currency, reporting, now)
+			if err != nil {
+				continue
+			}
+			prob := float64(d.Probability)
+			if prob <= 0 {
+				prob = stageProb[d.PipelineStageID]
+			}
+			out.Deals.Value += value
+			out.Deals.Weighted += value * min(prob, 100) / 100
+		}
+		out.Deals.Value = fx.Round(out.Deals.Value)
+		out.Deals.Weighted = fx.Round(out.Deals.Weighted)
+	}
+
+	// Follow-ups on the caller's interactions.
+	if allowed(user.Role, "follow_ups", actionRead) {
+		out.FollowUps = &dashboardFollowUps{}
+		interactions, err := s.store.LoadInteractionsFor(v)
+		if err != nil {
+			return out, err
+		}
+		out.FollowUps.Items = make([]dashboardFollowUp, 0)
+		out.FollowUps.OverdueItems = make([]dashboardFollowUp, 0)
+		sort.Slice(interactions, func(i, j int) bool {
+			a, b := interactions[i].FollowUpDate, interactions[j].FollowUpDate
+			return a != nil && (b == nil || a.Before(*b))
+		})
+		for _, it := range interactions {
+			if it.UserID != user.ID || it.FollowUpCompleted || it.FollowUpDate == nil {
+				continue
+			}
+			item := dashboardFollowUp{
+				InteractionID:  it.ID,
+				Subject:        it.Subject,
+				FollowUpDate:   it.FollowUpDate,
+				FollowUpNotes:  it.FollowUpNotes,
+				OrganizationID: it.OrganizationID,
+				ContactID:      it.ContactID,
+				DealID:         it.DealID,
+			}
+			switch {
+			case it.FollowUpDate.Before(today):
+				out.FollowUps.Overdue++
+				if len(out.FollowUps.OverdueItems) < dashboardListLimit {
+					out.FollowUps.OverdueItems = append(out.FollowUps.OverdueItems, item)
+				}
+			case it.FollowUpDate.Before(tomorrow):
+				out.FollowUps.DueToday++
+				if len(out.FollowUps.Items) < dashboardListLimit {
+					out.FollowUps.Items = append(out.FollowUps.Items, item)
+				}
+			}
+		}
+	}
+
+	// Tasks assigned to the caller; open ones listed by due date.
+	if allowed(user.Role, "tasks", actionRead) {
+		out.Tasks = &dashboardTasks{}
+		tasks, err := s.store.LoadTasksFor(v)
+		if err != nil {
+			return out, err
+		}
+		out.Tasks.ByStatus = map[models.TaskStatus]int{
+			models.TaskTodo:       0,
+			models.TaskInProgress: 0,
+			models.TaskBlocked:    0,
+			models.TaskDone:       0,
+		}
+		mine := make([]models.Task, 0)
+		for _, t := range tasks {
+			if t.OwnerUserID != user.ID {
+				continue
+			}
+			out.Tasks.ByStatus[t.Status]++
+			if t.Status == models.TaskDone {
+				continue
+			}
+			if t.DueDate != nil && t.DueDate.Before(today) {
+				out.Tasks.Overdue++
+			}
+			mine = append(mine, t)
+		}
+		sort.SliceStable(mine, func(i, j int) bool {
+			a, b := mine[i].DueDate, mine[j].DueDate
+			return a != nil && (b == nil || a.Before(*b))
+		})
+		out.Tasks.Items = mine[:min(len(mine), dashboardListLimit)]
+	}
+
+	// Quotes the caller sent that the client has not answered yet.
+	if allowed(user.Role, "quotations", actionRead) {
+		out.Quotes = &dashboardQuotes{}
+		quotes, err := s.store.LoadQuotationsFor(v)
+		if err != nil {
+			return out, err
+		}
+		out.Quotes.Items = make([]dashboardQuote, 0)
+		for _, q := range quotes {
+			if q.CreatedByUserID != user.ID {
+				continue
+			}
+			if q.Status != models.QuotationSent && q.Status != models.QuotationViewed {
+				continue
+			}
+			out.Quotes.Awaiting++
+			if total, err := conv.Convert(q.Total, q.Currency, reporting, now); err == nil {
+				out.Quotes.Total += total
+			}
+			if len(out.Quotes.Items) < dashboardListLimit {
+				out.Quotes.Items = append(out.Quotes.Items, dashboardQuote{
+					QuotationID: q.ID,
+					Number:      q.Number,
+					Title:       q.Title,
+					DealID:      q.DealID,
+					Status:      q.Status,
+					Total:       q.Total,
+					Currency:    q.Currency,
+					ValidUntil:  q.ValidUntil,
+					DaysWaiting: int(today.Sub(startOfDay(q.UpdatedAt)).Hours() / 24),
+				})
+			}
+		}
+		out.Quotes.Total = fx.Round(out.Quotes.Total)
+	}
+
+	// Planned payments due within 14 days, overdue ones included.
+	if allowed(user.Role, "payments", actionRead) {
+		payments, err := s.store.LoadPaymentsFor(v)
+		if err != nil {
+			return out, err
+		}
+		horizon := today.AddDate(0, 0, 15)
+		due := make([]models.Payment, 0)
+		out.Payments = &dashboardPayments{}
+		for _, p := range payments {
+			if p.Status != models.PaymentPlanned || p.DueAt == nil || !p.DueAt.Before(horizon) {
+				continue
+			}
+			out.Payments.Due++
+			if p.ReportingCurrency == reporting {
+				out.Payments.Total += p.ReportingAmount
+			}
+			due = append(due, p)
+		}
+		sort.Slice(due, func(i, j int) bool { return due[i].DueAt.Before(*due[j].DueAt) })
+		out.Payments.Items = due[:min(len(due), dashboardListLimit)]
+		out.Payments.Total = fx.Round(out.Payments.Total)
+	}
+
+	// Domains expiring within the renewal lead time.
+	if allowed(user.Role, "domains", actionRead) {
+		domains, err := s.expiringDomains(v, now, time.Duration(leadDays)*24*time.Hour)
+		if err != nil {
+			return out, err
+		}
+		out.Domains = &dashboardDomains{
+			Expiring: len(domains),
+			Items:    domains[:min(len(domains), dashboardListLimit)],
+		}
+	}
+	return out, nil
+}
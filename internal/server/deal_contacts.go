@@ -0,0 +1,83 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"wemadeit/internal/models"
+)
+
+var dealContactRoles = []models.DealContactRole{
+	models.DealContactDecisionMaker,
+	models.DealContactBilling,
+	models.DealContactTechnical,
+	models.DealContactInfluencer,
+	models.DealContactOther,
+}
+
+// validDealContacts normalizes d.Contacts and writes a 400 and returns false
+// when a role is unknown or a contact does not exist. A deal without a main
+// contact gets its decision maker, else its first contact.
+func (s *Server) validDealContacts(w http.ResponseWriter, d *models.Deal) bool {
+	if d.Contacts == nil {
+		return true
+	}
+	contacts := make([]models.DealContact, 0, len(d.Contacts))
+	ids := make([]string, 0, len(d.Contacts))
+	for _, dc := range d.Contacts {
+		dc.DealID = d.ID
+		dc.ContactID = strings.TrimSpace(dc.ContactID)
+		dc.Role = models.DealContactRole(strings.ToLower(strings.TrimSpace(string(dc.Role))))
+		if dc.Role == "" {
+			dc.Role = models.DealContactOther
+		}
+		if dc.ContactID == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("contacts need a contactId"))
+			return false
+		}
+		if !slices.Contains(dealContactRoles, dc.Role) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("contact role must be decision_maker, billing, technical, influencer or other"))
+			return false
+		}
+		if slices.Contains(contacts, dc) {
+			continue
+		}
+		contacts = append(contacts, dc)
+		ids = append(ids, dc.ContactID)
+	}
+	missing, err := s.store.MissingEntities("contact", ids)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	if len(missing) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("contact not found: "+strings.Join(missing, ", ")))
+		return false
+	}
+	d.Contacts = contacts
+	if d.ContactID == "" {
+		if dc, ok := dealContactWithRole(contacts, models.DealContactDecisionMaker); ok {
+			d.ContactID = dc.ContactID
+		} else if len(contacts) > 0 {
+			d.ContactID = contacts[0].ContactID
+		}
+	}
+	return true
+}
+
+func dealContactWithRole(contacts []models.DealContact, role models.DealContactRole) (models.DealContact, bool) {
+	i := slices.IndexFunc(contacts, func(dc models.DealContact) bool { return dc.Role == role })
+	if i < 0 {
+		return models.DealContact{}, false
+	}
+	return contacts[i], true
+}
+
+// dealInvolves reports whether contactID is the deal's main contact or has
+// a role on it.
+func dealInvolves(d models.Deal, contactID string) bool {
+	return d.ContactID == contactID || slices.ContainsFunc(d.Contacts, func(dc models.DealContact) bool {
+		return dc.ContactID == contactID
+	})
+}
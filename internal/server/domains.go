@@ -0,0 +1,385 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/domaininfo"
+	"wemadeit/internal/models"
+)
+
+type expiringDomain struct {
+	models.Domain
+	DaysLeft int `json:"daysLeft"`
+}
+
+// SetDomainLookup replaces the registry lookup built from settings, e.g. with
+// a stub when embedding the server.
+func (s *Server) SetDomainLookup(l domaininfo.Lookup) {
+	s.mu.Lock()
+	s.lookup = l
+	s.mu.Unlock()
+}
+
+func (s *Server) domainLookup() domaininfo.Lookup {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.lookup != nil {
+		return s.lookup
+	}
+	chain := domaininfo.Chain{domaininfo.RDAPClient{BaseURL: s.settings.RDAPBaseURL}}
+	if strings.TrimSpace(s.settings.WHOISServer) != "" {
+		chain = append(chain, domaininfo.WHOISClient{Addr: s.settings.WHOISServer})
+	}
+	return chain
+}
+
+func (s *Server) handleDomains(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		domains, err := s.store.LoadDomainsFor(viewer(r))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		orgID := strings.TrimSpace(r.URL.Query().Get("organizationId"))
+		dealID := strings.TrimSpace(r.URL.Query().Get("dealId"))
+		if orgID != "" || dealID != "" {
+			filtered := make([]models.Domain, 0, len(domains))
+			for _, d := range domains {
+				if orgID != "" && d.OrganizationID != orgID {
+					continue
+				}
+				if dealID != "" && d.DealID != dealID {
+					continue
+				}
+				filtered = append(filtered, d)
+			}
+			domains = filtered
+		}
+		writeJSON(w, http.StatusOK, domains)
+	case http.MethodPost:
+		var d models.Domain
+		if err := readJSON(r, &d); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		now := time.Now()
+		if d.ID == "" {
+			d.ID = newID()
+		}
+		if d.CreatedAt.IsZero() {
+			d.CreatedAt = now
+		}
+		d.UpdatedAt = now
+
+		d.Name = domaininfo.Normalize(d.Name)
+		if d.Name == "" || !strings.Contains(d.Name, ".") {
+			writeJSON(w, http.StatusBadRequest, errorResponse("a valid name is required"))
+			return
+		}
+		if !s.canSeeStoredDeal(w, r, "domain", d.ID) {
+			return
+		}
+		if existing, ok, err := s.store.FindDomainByName(d.Name); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		} else if ok && existing.ID != d.ID {
+			writeJSON(w, http.StatusConflict, errorResponse("domain already exists"))
+			return
+		}
+		if strings.TrimSpace(d.DealID) != "" {
+			deals, err := s.store.LoadDealsFor(viewer(r))
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			found := false
+			for _, deal := range deals {
+				if deal.ID == d.DealID {
+					found = true
+					if d.OrganizationID == "" {
+						d.OrganizationID = deal.OrganizationID
+					}
+					break
+				}
+			}
+			if !found {
+				writeJSON(w, http.StatusBadRequest, errorResponse("dealId not found"))
+				return
+			}
+		}
+		if strings.TrimSpace(d.OrganizationID) == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("organizationId is required"))
+			return
+		}
+		if d.RenewalPrice < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("renewalPrice must be >= 0"))
+			return
+		}
+		if strings.TrimSpace(d.Currency) == "" {
+			d.Currency = "EUR"
+		}
+
+		if err := s.store.SaveDomain(d); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, d)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			if !s.canSeeStoredDeal(w, r, "domain", id) {
+				return
+			}
+			if err := s.store.DeleteDomain(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+func (s *Server) handleDomainsExpiring(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	within := 60 * 24 * time.Hour
+	if v := strings.TrimSpace(r.URL.Query().Get("within")); v != "" {
+		d, err := parseWithin(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		within = d
+	}
+	out, err := s.expiringDomains(viewer(r), time.Now(), within)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) expiringDomains(v db.Viewer, now time.Time, within time.Duration) ([]expiringDomain, error) {
+	from := startOfDay(now)
+	domains, err := s.store.LoadDomainsExpiringFor(v, from, now.Add(within))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]expiringDomain, 0, len(domains))
+	for _, d := range domains {
+		out = append(out, expiringDomain{
+			Domain:   d,
+			DaysLeft: int(startOfDay(*d.ExpiresAt).Sub(from).Hours() / 24),
+		})
+	}
+	return out, nil
+}
+
+func (s *Server) handleDomainsRefresh(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		ID  string   `json:"id"`
+		IDs []string `json:"ids"`
+	}
+	if r.ContentLength != 0 {
+		if err := readJSON(r, &payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+	}
+	ids := payload.IDs
+	if strings.TrimSpace(payload.ID) != "" {
+		ids = append(ids, payload.ID)
+	}
+
+	// Domains on deals the caller cannot see are left out, and asking for
+	// only those answers 404.
+	all, err := s.store.LoadDomainsFor(viewer(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	targets := all
+	if len(ids) > 0 {
+		want := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			want[strings.TrimSpace(id)] = struct{}{}
+		}
+		targets = make([]models.Domain, 0, len(ids))
+		for _, d := range all {
+			if _, ok := want[d.ID]; ok {
+				targets = append(targets, d)
+			}
+		}
+		if len(targets) == 0 {
+			writeJSON(w, http.StatusNotFound, errorResponse("domain not found"))
+			return
+		}
+	}
+
+	updated, failures := s.refreshDomains(r.Context(), targets)
+	writeJSON(w, http.StatusOK, map[string]any{"updated": updated, "errors": failures})
+}
+
+// refreshDomains looks up each domain and stores the registry's expiry date,
+// creation date and registrar. Failures are reported per domain name.
+func (s *Server) refreshDomains(ctx context.Context, domains []models.Domain) ([]models.Domain, map[string]string) {
+	lookup := s.domainLookup()
+	updated := make([]models.Domain, 0, len(domains))
+	failures := make(map[string]string)
+	for _, d := range domains {
+		lctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+		info, err := lookup.Lookup(lctx, d.Name)
+		cancel()
+		if err != nil {
+			if errors.Is(err, domaininfo.ErrNotFound) {
+				failures[d.Name] = "not registered"
+			} else {
+				failures[d.Name] = err.Error()
+			}
+			continue
+		}
+		now := time.Now()
+		if info.ExpiresAt != nil {
+			t := *info.ExpiresAt
+			d.ExpiresAt = &t
+		}
+		if info.CreatedAt != nil && d.AcquiredAt == nil {
+			t := *info.CreatedAt
+			d.AcquiredAt = &t
+		}
+		if info.Registrar != "" {
+			d.Registrar = info.Registrar
+		}
+		d.LastCheckedAt = &now
+		d.UpdatedAt = now
+		if err := s.store.SaveDomain(d); err != nil {
+			failures[d.Name] = err.Error()
+			continue
+		}
+		updated = append(updated, d)
+	}
+	return updated, failures
+}
+
+// refreshStaleDomains is the scheduler job behind domain_refresh_days; it is
+// off unless that setting is positive.
+func (s *Server) refreshStaleDomains(now time.Time) error {
+	s.mu.RLock()
+	days := s.settings.DomainRefreshDays
+	s.mu.RUnlock()
+	if days <= 0 {
+		return nil
+	}
+	domains, err := s.store.LoadDomains()
+	if err != nil {
+		return err
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	stale := make([]models.Domain, 0)
+	for _, d := range domains {
+		if d.LastCheckedAt == nil || d.LastCheckedAt.Before(cutoff) {
+			stale = append(stale, d)
+		}
+	}
+	if _, failures := s.refreshDomains(context.Background(), stale); len(failures) > 0 {
+		return fmt.Errorf("%d domain(s) failed to refresh", len(failures))
+	}
+	return nil
+}
+
+// syncDealDomain keeps the domains table in step with the single Domain
+// column on deals. Expiry dates only move forward so a stale spreadsheet value
+// never overwrites a refreshed registry date.
+func (s *Server) syncDealDomain(d models.Deal) error {
+	name := domaininfo.Normalize(d.Domain)
+	if name == "" {
+		return nil
+	}
+	now := time.Now()
+	dom, ok, err := s.store.FindDomainByName(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		currency := d.Currency
+		if currency == "" {
+			currency = "EUR"
+		}
+		dom = models.Domain{
+			ID:        newID(),
+			Name:      name,
+			Currency:  currency,
+			CreatedAt: now,
+		}
+	} else if dom.DealID != "" && dom.DealID != d.ID {
+		return nil
+	}
+
+	dom.DealID = d.ID
+	if dom.OrganizationID == "" {
+		dom.OrganizationID = d.OrganizationID
+	}
+	if dom.AcquiredAt == nil && d.DomainAcquiredAt != nil {
+		t := *d.DomainAcquiredAt
+		dom.AcquiredAt = &t
+	}
+	if d.DomainExpiresAt != nil && (dom.ExpiresAt == nil || d.DomainExpiresAt.After(*dom.ExpiresAt)) {
+		t := *d.DomainExpiresAt
+		dom.ExpiresAt = &t
+	}
+	if dom.RenewalPrice == 0 && d.DomainCost > 0 {
+		dom.RenewalPrice = d.DomainCost
+	}
+	dom.UpdatedAt = now
+	return s.store.SaveDomain(dom)
+}
+
+// parseWithin accepts "60d", "8w", "720h" or a bare number of days.
+func parseWithin(v string) (time.Duration, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	unit := 24 * time.Hour
+	switch {
+	case strings.HasSuffix(v, "d"):
+		v = strings.TrimSuffix(v, "d")
+	case strings.HasSuffix(v, "w"):
+		v = strings.TrimSuffix(v, "w")
+		unit = 7 * 24 * time.Hour
+	default:
+		if n, err := strconv.Atoi(v); err == nil {
+			return time.Duration(n) * unit, nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, errors.New("within must look like 60d, 8w or 720h")
+		}
+		return d, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.New("within must look like 60d, 8w or 720h")
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,360 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/domaininfo"
+	"wemadeit/internal/mailparse"
+	"wemadeit/internal/models"
+)
+
+const maxMailBytes = 50 << 20
+
+// freeMailDomains never identify an organization.
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "outlook.com": true, "hotmail.com": true,
+	"hotmail.it": true, "live.com": true, "libero.it": true, "yahoo.com": true, "yahoo.it": true,
+	"icloud.com": true, "me.com": true, "gmx.de": true, "gmx.net": true, "proton.me": true,
+	"protonmail.com": true, "tiscali.it": true, "virgilio.it": true, "alice.it": true,
+}
+
+type emailImportResult struct {
+	Created   int                  `json:"created"`
+	Duplicate int                  `json:"duplicate"`
+	Unmatched []string             `json:"unmatched"`
+	Failed    []string             `json:"failed"`
+	Items     []models.Interaction `json:"items"`
+}
+
+// handleEmailImport logs raw RFC 5322 messages as email interactions. The
+// upload is a single .eml or an mbox batch.
+func (s *Server) handleEmailImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	data, _, err := readUpload(r, maxMailBytes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	raws := [][]byte{data}
+	if mailparse.IsMbox(data) {
+		if raws, err = mailparse.SplitMbox(bytes.NewReader(data)); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+	}
+	res, err := s.ingestEmails(raws, viewer(r), mustAuth(r).User.ID, time.Now())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// pollMaildir is the scheduler job for maildir_path: messages in new/ are
+// ingested and then moved to cur/ marked as seen, as a mail client would.
+// Messages that fail to parse are moved to failed/ instead, so they are
+// neither retried on every tick nor lost among the seen ones, and each
+// failure is logged.
+func (s *Server) pollMaildir(now time.Time) error {
+	s.mu.RLock()
+	dir := s.settings.MaildirPath
+	s.mu.RUnlock()
+	if dir == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "cur"), 0o755); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		src := filepath.Join(dir, "new", e.Name())
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		// The mailbox is shared, so its messages may link to any deal.
+		res, err := s.ingestEmails([][]byte{data}, db.Viewer{Role: models.RoleAdmin}, "", now)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(dir, "cur", e.Name())
+		if !strings.Contains(e.Name(), ":2,") {
+			dest += ":2,S"
+		}
+		if len(res.Failed) > 0 {
+			for _, msg := range res.Failed {
+				fmt.Println("Maildir:", e.Name()+":", msg)
+			}
+			if err := os.MkdirAll(filepath.Join(dir, "failed"), 0o755); err != nil {
+				return err
+			}
+			dest = filepath.Join(dir, "failed", e.Name())
+		}
+		if err := os.Rename(src, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emailMatcher resolves addresses to contacts, organizations and users.
+type emailMatcher struct {
+	contacts map[string]models.Contact
+	orgs     map[string]string // domain -> organization
+	users    map[string]string // address -> user
+	deals    []models.Deal
+}
+
+func (s *Server) newEmailMatcher(v db.Viewer) (*emailMatcher, error) {
+	m := &emailMatcher{
+		contacts: make(map[string]models.Contact),
+		orgs:     make(map[string]string),
+		users:    make(map[string]string),
+	}
+	users, err := s.store.LoadUsers()
+	if err != nil {
+		return nil, err
+	}
+	internal := make(map[string]bool)
+	for _, u := range users {
+		if e := strings.ToLower(strings.TrimSpace(u.EmailAddress)); e != "" {
+			m.users[e] = u.ID
+			internal[emailDomain(e)] = true
+		}
+	}
+	addDomain := func(domain, orgID string) {
+		domain = domaininfo.Normalize(domain)
+		if domain == "" || orgID == "" || freeMailDomains[domain] || internal[domain] {
+			return
+		}
+		if _, taken := m.orgs[domain]; !taken {
+			m.orgs[domain] = orgID
+		}
+	}
+
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range orgs {
+		addDomain(emailDomain(o.Email), o.ID)
+		addDomain(emailDomain(o.BillingEmail), o.ID)
+		addDomain(o.Website, o.ID)
+	}
+	domains, err := s.store.LoadDomains()
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range domains {
+		addDomain(d.Name, d.OrganizationID)
+	}
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contacts {
+		if e := strings.ToLower(strings.TrimSpace(c.Email)); e != "" {
+			m.contacts[e] = c
+			addDomain(emailDomain(e), c.OrganizationID)
+		}
+	}
+	if m.deals, err = s.store.LoadDealsFor(v); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ingestEmails stores each parsable message as an email interaction. A
+// message is threaded under the interaction of the message it replies to and
+// inherits its links when none of its own addresses match. Messages already
+// logged (same Message-ID) are counted as duplicates. Messages are only
+// linked to deals v may see.
+func (s *Server) ingestEmails(raws [][]byte, v db.Viewer, userID string, now time.Time) (emailImportResult, error) {
+	res := emailImportResult{
+		Unmatched: make([]string, 0),
+		Failed:    make([]string, 0),
+		Items:     make([]models.Interaction, 0),
+	}
+	matcher, err := s.newEmailMatcher(v)
+	if err != nil {
+		return res, err
+	}
+
+	type parsed struct {
+		msg mailparse.Message
+		raw []byte
+	}
+	messages := make([]parsed, 0, len(raws))
+	for n, raw := range raws {
+		msg, err := mailparse.Parse(bytes.NewReader(raw))
+		if err != nil {
+			res.Failed = append(res.Failed, fmt.Sprintf("message %d: %v", n+1, err))
+			continue
+		}
+		messages = append(messages, parsed{msg, raw})
+	}
+	// Oldest first, so parents exist before their replies.
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].msg.Date.Before(messages[j].msg.Date) })
+
+	for _, p := range messages {
+		msg := p.msg
+		externalID := "email:" + msg.MessageID
+		if msg.MessageID == "" {
+			sum := sha256.Sum256(p.raw)
+			externalID = "email-sha256:" + hex.EncodeToString(sum[:])
+		}
+		if _, found, err := s.store.FindInteractionByExternalID(externalID); err != nil {
+			return res, err
+		} else if found {
+			res.Duplicate++
+			continue
+		}
+
+		var parent models.Interaction
+		hasParent := false
+		// In-Reply-To first, then References from the nearest ancestor up.
+		refs := slices.Clone(msg.References)
+		slices.Reverse(refs)
+		candidates := append([]string{msg.InReplyTo}, refs...)
+		for _, id := range candidates {
+			if id == "" {
+				continue
+			}
+			if parent, hasParent, err = s.store.FindInteractionByExternalID("email:" + id); err != nil {
+				return res, err
+			}
+			if hasParent {
+				break
+			}
+		}
+
+		i := models.Interaction{
+			ID:                    newID(),
+			InteractionType:       models.InteractionEmail,
+			Subject:               firstNonBlank(msg.Subject, "(no subject)"),
+			Body:                  emailBody(msg),
+			OccurredAt:            msg.Date,
+			ExternalID:            externalID,
+			TranscriptionLanguage: "it",
+			TranscriptionStatus:   "pending",
+			CreatedAt:             now,
+			UpdatedAt:             now,
+		}
+		if i.OccurredAt.IsZero() {
+			i.OccurredAt = now
+		}
+		i.ThreadID = i.ID
+		if hasParent {
+			i.ThreadID = firstNonBlank(parent.ThreadID, parent.ID)
+		}
+
+		for _, a := range msg.Addresses() {
+			if c, ok := matcher.contacts[a.Email]; ok && i.ContactID == "" {
+				i.ContactID = c.ID
+				i.OrganizationID = c.OrganizationID
+			}
+			if id, ok := matcher.users[a.Email]; ok && i.UserID == "" {
+				i.UserID = id
+			}
+		}
+		if i.OrganizationID == "" {
+			for _, a := range msg.Addresses() {
+				if org, ok := matcher.orgs[emailDomain(a.Email)]; ok {
+					i.OrganizationID = org
+					break
+				}
+			}
+		}
+		if hasParent {
+			if i.OrganizationID == "" {
+				i.OrganizationID, i.ContactID = parent.OrganizationID, parent.ContactID
+			}
+			visible := slices.ContainsFunc(matcher.deals, func(d models.Deal) bool { return d.ID == parent.DealID })
+			if i.OrganizationID == parent.OrganizationID && visible {
+				i.DealID = parent.DealID
+			}
+		}
+		if i.OrganizationID == "" && i.ContactID == "" {
+			res.Unmatched = append(res.Unmatched, firstNonBlank(msg.Subject, msg.MessageID))
+			continue
+		}
+		if i.DealID == "" {
+			i.DealID = openDealFor(matcher.deals, i.ContactID, i.OrganizationID)
+		}
+		if userID != "" {
+			i.UserID = userID
+		}
+
+		if err := s.store.SaveInteraction(i); err != nil {
+			return res, err
+		}
+		res.Created++
+		res.Items = append(res.Items, i)
+	}
+	return res, nil
+}
+
+// emailBody renders the headers worth keeping above the text body and lists
+// attachments by name.
+func emailBody(msg mailparse.Message) string {
+	var b strings.Builder
+	b.WriteString("From: " + formatAddresses([]mailparse.Address{msg.From}) + "\n")
+	if len(msg.To) > 0 {
+		b.WriteString("To: " + formatAddresses(msg.To) + "\n")
+	}
+	if len(msg.Cc) > 0 {
+		b.WriteString("Cc: " + formatAddresses(msg.Cc) + "\n")
+	}
+	b.WriteString("\n" + msg.Body())
+	if len(msg.Attachments) > 0 {
+		b.WriteString("\n\nAttachments:")
+		for _, a := range msg.Attachments {
+			fmt.Fprintf(&b, "\n- %s (%s, %d bytes)", firstNonBlank(a.Filename, "unnamed"), a.ContentType, a.Size)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func formatAddresses(list []mailparse.Address) string {
+	parts := make([]string, 0, len(list))
+	for _, a := range list {
+		if a.Name != "" {
+			parts = append(parts, a.Name+" <"+a.Email+">")
+		} else {
+			parts = append(parts, a.Email)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// emailDomain returns the domain of an address, normalized like domain
+// names so it matches organization websites and tracked domains.
+func emailDomain(addr string) string {
+	if !strings.Contains(addr, "@") {
+		return ""
+	}
+	return domaininfo.Normalize(addr)
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"wemadeit/internal/fx"
+	"wemadeit/internal/models"
+)
+
+const maxRatesBytes = 50 << 20
+
+func (s *Server) handleExchangeRates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rates, err := s.store.LoadExchangeRates()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if c := fx.Code(r.URL.Query().Get("currency")); c != "" {
+			filtered := make([]models.ExchangeRate, 0)
+			for _, rate := range rates {
+				if rate.Base == c || rate.Quote == c {
+					filtered = append(filtered, rate)
+				}
+			}
+			rates = filtered
+		}
+		writeJSON(w, http.StatusOK, rates)
+	case http.MethodPost:
+		var rate models.ExchangeRate
+		if err := readJSON(r, &rate); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		rate.Base = fx.Code(rate.Base)
+		rate.Quote = fx.Code(rate.Quote)
+		if len(rate.Base) != 3 || len(rate.Quote) != 3 || rate.Base == rate.Quote {
+			writeJSON(w, http.StatusBadRequest, errorResponse("base and quote must be two different ISO currency codes"))
+			return
+		}
+		if rate.Rate <= 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("rate must be > 0"))
+			return
+		}
+		if rate.Date.IsZero() {
+			rate.Date = time.Now()
+		}
+		rate.Date = fx.Day(rate.Date)
+		if rate.ID != "" && rate.ID != exchangeRateID(rate) {
+			// Editing the day or pair of an existing rate moves it.
+			if err := s.store.DeleteExchangeRate(rate.ID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		rate.ID = exchangeRateID(rate)
+		rate.Source = "manual"
+		rate.CreatedAt = time.Now()
+
+		if err := s.store.SaveExchangeRate(rate); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if _, err := s.recomputeReportingAmounts(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, rate)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			if err := s.store.DeleteExchangeRate(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		if _, err := s.recomputeReportingAmounts(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleExchangeRatesImport loads an ECB reference-rate XML file, either
+// uploaded or, with ?source=ecb, downloaded from the configured feed.
+func (s *Server) handleExchangeRatesImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+
+	var data []byte
+	var err error
+	if strings.EqualFold(r.URL.Query().Get("source"), "ecb") {
+		s.mu.RLock()
+		feed := s.settings.ECBRatesURL
+		s.mu.RUnlock()
+		data, err = fetchRates(r.Context(), feed)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, errorResponse(err.Error()))
+			return
+		}
+	} else {
+		data, _, err = readUpload(r, maxRatesBytes)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+	}
+
+	parsed, err := fx.ParseECB(bytes.NewReader(data))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	now := time.Now()
+	rates := make([]models.ExchangeRate, 0, len(parsed))
+	for _, p := range parsed {
+		rate := models.ExchangeRate{
+			Date:      fx.Day(p.Date),
+			Base:      p.Base,
+			Quote:     p.Quote,
+			Rate:      p.Rate,
+			Source:    "ecb",
+			CreatedAt: now,
+		}
+		rate.ID = exchangeRateID(rate)
+		rates = append(rates, rate)
+	}
+	n, err := s.store.ImportExchangeRates(rates)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	updated, err := s.recomputeReportingAmounts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"imported": n, "paymentsUpdated": updated})
+}
+
+func (s *Server) handleExchangeRatesRecompute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	updated, err := s.recomputeReportingAmounts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"paymentsUpdated": updated})
+}
+
+// handlePaymentsSummary totals payments in the reporting currency. Payments
+// whose currency could not be converted are counted separately, never mixed in.
+func (s *Server) handlePaymentsSummary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	payments, err := s.store.LoadPaymentsFor(viewer(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	s.mu.RLock()
+	reporting := s.settings.ReportingCurrency
+	s.mu.RUnlock()
+
+	today := startOfDay(time.Now())
+	var paid, planned, overdue float64
+	unconverted := 0
+	for _, p := range payments {
+		if p.Status == models.PaymentVoid {
+			continue
+		}
+		if p.ReportingCurrency != reporting {
+			unconverted++
+			continue
+		}
+		switch p.Status {
+		case models.PaymentPaid:
+			paid += p.ReportingAmount
+		case models.PaymentPlanned:
+			planned += p.ReportingAmount
+			if p.DueAt != nil && p.DueAt.Before(today) {
+				overdue += p.ReportingAmount
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"currency":    reporting,
+		"paid":        fx.Round(paid),
+		"planned":     fx.Round(planned),
+		"overdue":     fx.Round(overdue),
+		"unconverted": unconverted,
+	})
+}
+
+// reportingConverter returns a converter over all stored rates together with
+// the configured reporting currency.
+func (s *Server) reportingConverter() (*fx.Converter, string, error) {
+	s.mu.RLock()
+	reporting := s.settings.ReportingCurrency
+	s.mu.RUnlock()
+
+	stored, err := s.store.LoadExchangeRates()
+	if err != nil {
+		return nil, "", err
+	}
+	rates := make([]fx.Rate, 0, len(stored))
+	for _, r := range stored {
+		rates = append(rates, fx.Rate{Date: r.Date, Base: r.Base, Quote: r.Quote, Rate: r.Rate})
+	}
+	return fx.NewConverter(rates), reporting, nil
+}
+
+// applyReporting fills in the payment's reporting amount using the rate on
+// PaidAt, or on DueAt for payments not yet received.
+func applyReporting(conv *fx.Converter, reporting string, p *models.Payment) {
+	at := time.Now()
+	if p.PaidAt != nil {
+		at = *p.PaidAt
+	} else if p.DueAt != nil {
+		at = *p.DueAt
+	}
+	amount, err := conv.Convert(p.Amount, p.Currency, reporting, at)
+	if err != nil {
+		p.ReportingAmount = 0
+		p.ReportingCurrency = ""
+		return
+	}
+	p.ReportingAmount = amount
+	p.ReportingCurrency = reporting
+}
+
+func (s *Server) updatePaymentReporting(p *models.Payment) error {
+	conv, reporting, err := s.reportingConverter()
+	if err != nil {
+		return err
+	}
+	applyReporting(conv, reporting, p)
+	return s.store.UpdatePaymentReporting(p.ID, p.ReportingAmount, p.ReportingCurrency)
+}
+
+// recomputeReportingAmounts refreshes every payment whose converted amount
+// changed, e.g. after new rates arrive or the reporting currency moves.
+func (s *Server) recomputeReportingAmounts() (int, error) {
+	conv, reporting, err := s.reportingConverter()
+	if err != nil {
+		return 0, err
+	}
+	payments, err := s.store.LoadPayments()
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, p := range payments {
+		before := p
+		applyReporting(conv, reporting, &p)
+		if p.ReportingAmount == before.ReportingAmount && p.ReportingCurrency == before.ReportingCurrency {
+			continue
+		}
+		if err := s.store.UpdatePaymentReporting(p.ID, p.ReportingAmount, p.ReportingCurrency); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+func exchangeRateID(r models.ExchangeRate) string {
+	return r.Date.Format("2006-01-02") + "-" + r.Base + "-" + r.Quote
+}
+
+func fetchRates(ctx context.Context, feed string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rates feed returned status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxRatesBytes))
+}
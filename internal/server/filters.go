@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"wemadeit/internal/models"
+)
+
+// entityAttrs are the custom field values and labels of one entity type,
+// which list endpoints attach to each entity and filter on.
+type entityAttrs struct {
+	custom customValues
+	tags   map[string][]string
+
+	cf     customFilter
+	labels []string // every one must be present
+}
+
+func (s *Server) loadEntityAttrs(entityType string) (entityAttrs, error) {
+	var a entityAttrs
+	var err error
+	if a.custom, err = s.loadCustomValues(entityType); err != nil {
+		return a, err
+	}
+	if a.tags, err = s.store.LoadTaggings(entityType); err != nil {
+		return a, err
+	}
+	return a, nil
+}
+
+// listFilter loads an entity type's attributes together with the request's
+// cf.* and labels filters; ok is false once an error response has been
+// written.
+func (s *Server) listFilter(w http.ResponseWriter, r *http.Request, entityType string) (entityAttrs, bool) {
+	a, err := s.loadEntityAttrs(entityType)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return a, false
+	}
+	if a.cf, err = parseCustomFilter(r, a.custom.fields); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return a, false
+	}
+	labels, ok := s.labelsFromQuery(w, r)
+	if !ok {
+		return a, false
+	}
+	a.labels = labels
+	return a, true
+}
+
+func (a entityAttrs) customFields(id string) models.CustomValues {
+	return a.custom.of(id)
+}
+
+func (a entityAttrs) labelIDs(id string) []string {
+	return a.tags[id]
+}
+
+func (a entityAttrs) match(id string) bool {
+	for _, l := range a.labels {
+		if !slices.Contains(a.tags[id], l) {
+			return false
+		}
+	}
+	return a.cf.match(a.custom.of(id))
+}
@@ -0,0 +1,399 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+type followUpItem struct {
+	models.Interaction
+	OrganizationName string `json:"organizationName"`
+	ContactName      string `json:"contactName"`
+	DealTitle        string `json:"dealTitle"`
+}
+
+type followUpViews struct {
+	Overdue  []followUpItem `json:"overdue"`
+	Due      []followUpItem `json:"due"`
+	Upcoming []followUpItem `json:"upcoming"`
+}
+
+// handleFollowUps lists a user's open follow-ups. Without ?view the response
+// groups them into overdue, due (today) and upcoming (next ?days, default 7);
+// ?view=overdue|due|upcoming returns just that list. Admins may pass ?userId.
+func (s *Server) handleFollowUps(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	q := r.URL.Query()
+	caller := mustAuth(r).User
+	userID := caller.ID
+	if v := strings.TrimSpace(q.Get("userId")); v != "" && v != caller.ID {
+		if caller.Role != models.RoleAdmin {
+			writeJSON(w, http.StatusForbidden, errorResponse("only admins can view other users' follow-ups"))
+			return
+		}
+		userID = v
+	}
+	days := 7
+	if v := strings.TrimSpace(q.Get("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 365 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("days must be between 1 and 365"))
+			return
+		}
+		days = n
+	}
+
+	views, err := s.followUpViews(viewer(r), userID, time.Now(), days)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	switch q.Get("view") {
+	case "":
+		writeJSON(w, http.StatusOK, views)
+	case "overdue":
+		writeJSON(w, http.StatusOK, views.Overdue)
+	case "due":
+		writeJSON(w, http.StatusOK, views.Due)
+	case "upcoming":
+		writeJSON(w, http.StatusOK, views.Upcoming)
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse("view must be overdue, due or upcoming"))
+	}
+}
+
+func (s *Server) followUpViews(v db.Viewer, userID string, now time.Time, days int) (followUpViews, error) {
+	views := followUpViews{
+		Overdue:  make([]followUpItem, 0),
+		Due:      make([]followUpItem, 0),
+		Upcoming: make([]followUpItem, 0),
+	}
+	interactions, err := s.store.LoadInteractionsFor(v)
+	if err != nil {
+		return views, err
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		return views, err
+	}
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		return views, err
+	}
+	deals, err := s.store.LoadDealsFor(v)
+	if err != nil {
+		return views, err
+	}
+	orgName := make(map[string]string, len(orgs))
+	for _, o := range orgs {
+		orgName[o.ID] = o.Name
+	}
+	contactName := make(map[string]string, len(contacts))
+	for _, c := range contacts {
+		contactName[c.ID] = strings.TrimSpace(c.FirstName + " " + c.LastName)
+	}
+	dealTitle := make(map[string]string, len(deals))
+	for _, d := range deals {
+		dealTitle[d.ID] = d.Title
+	}
+
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+	until := tomorrow.AddDate(0, 0, days)
+	for _, it := range interactions {
+		if it.UserID != userID || it.FollowUpCompleted || it.FollowUpDate == nil {
+			continue
+		}
+		item := followUpItem{
+			Interaction:      it,
+			OrganizationName: orgName[it.OrganizationID],
+			ContactName:      contactName[it.ContactID],
+			DealTitle:        dealTitle[it.DealID],
+		}
+		switch due := *it.FollowUpDate; {
+		case due.Before(today):
+			views.Overdue = append(views.Overdue, item)
+		case due.Before(tomorrow):
+			views.Due = append(views.Due, item)
+		case due.Before(until):
+			views.Upcoming = append(views.Upcoming, item)
+		}
+	}
+	for _, list := range [][]followUpItem{views.Overdue, views.Due, views.Upcoming} {
+		sort.Slice(list, func(i, j int) bool { return list[i].FollowUpDate.Before(*list[j].FollowUpDate) })
+	}
+	return views, nil
+}
+
+// handleFollowUpComplete marks follow-ups done: {"id": ...} or {"ids": [...]},
+// with optional closing notes.
+func (s *Server) handleFollowUpComplete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		ID    string   `json:"id"`
+		IDs   []string `json:"ids"`
+		Notes *string  `json:"notes"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	ids := payload.IDs
+	if strings.TrimSpace(payload.ID) != "" {
+		ids = append(ids, payload.ID)
+	}
+	if len(ids) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("id is required"))
+		return
+	}
+
+	// Every ID is checked before anything is written, and all of them are
+	// completed together or not at all.
+	userID := mustAuth(r).User.ID
+	now := time.Now()
+	updated := make([]models.Interaction, 0, len(ids))
+	history := make([]models.HistoryEntry, 0)
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		it, status, msg := s.loadFollowUp(r, id)
+		if status != 0 {
+			writeJSON(w, status, errorResponse(msg))
+			return
+		}
+		notes := it.FollowUpNotes
+		if payload.Notes != nil {
+			notes = *payload.Notes
+		}
+		next, entries := followUpChange(it, it.FollowUpDate, notes, true, userID, now)
+		updated = append(updated, next)
+		history = append(history, entries...)
+	}
+	if err := s.store.UpdateInteractionFollowUps(updated, history); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleFollowUpSnooze pushes a follow-up back by ?days (default 1), counted
+// from today when it is already overdue, or to an explicit "until" time.
+func (s *Server) handleFollowUpSnooze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		ID    string     `json:"id"`
+		Days  int        `json:"days"`
+		Until *time.Time `json:"until"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	it, status, msg := s.loadFollowUp(r, payload.ID)
+	if status != 0 {
+		writeJSON(w, status, errorResponse(msg))
+		return
+	}
+	if it.FollowUpDate == nil {
+		writeJSON(w, http.StatusConflict, errorResponse("interaction has no follow-up"))
+		return
+	}
+
+	now := time.Now()
+	var next time.Time
+	switch {
+	case payload.Until != nil:
+		if !payload.Until.After(now) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("until must be in the future"))
+			return
+		}
+		next = *payload.Until
+	default:
+		days := payload.Days
+		if days == 0 {
+			days = 1
+		}
+		if days < 0 || days > 365 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("days must be between 1 and 365"))
+			return
+		}
+		base := *it.FollowUpDate
+		if base.Before(now) {
+			// Keep the original time of day when snoozing an overdue item.
+			base = time.Date(now.Year(), now.Month(), now.Day(), base.Hour(), base.Minute(), 0, 0, base.Location())
+		}
+		next = base.AddDate(0, 0, days)
+	}
+
+	updated, err := s.updateFollowUp(r, it, &next, it.FollowUpNotes, false)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleFollowUpReschedule sets a new follow-up date (reopening a completed
+// follow-up) and optionally replaces its notes.
+func (s *Server) handleFollowUpReschedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		ID    string     `json:"id"`
+		Date  *time.Time `json:"date"`
+		Notes *string    `json:"notes"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	if payload.Date == nil || payload.Date.IsZero() {
+		writeJSON(w, http.StatusBadRequest, errorResponse("date is required"))
+		return
+	}
+	it, status, msg := s.loadFollowUp(r, payload.ID)
+	if status != 0 {
+		writeJSON(w, status, errorResponse(msg))
+		return
+	}
+	notes := it.FollowUpNotes
+	if payload.Notes != nil {
+		notes = *payload.Notes
+	}
+	updated, err := s.updateFollowUp(r, it, payload.Date, notes, false)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// loadFollowUp fetches an interaction the caller may act on. A non-zero status
+// means the request should fail with msg.
+func (s *Server) loadFollowUp(r *http.Request, id string) (models.Interaction, int, string) {
+	if strings.TrimSpace(id) == "" {
+		return models.Interaction{}, http.StatusBadRequest, "id is required"
+	}
+	it, ok, err := s.store.FindInteractionByID(id)
+	if err != nil {
+		return models.Interaction{}, http.StatusInternalServerError, err.Error()
+	}
+	if !ok {
+		return models.Interaction{}, http.StatusNotFound, "interaction not found"
+	}
+	if it.DealID != "" {
+		if ok, err := s.store.CanSee(viewer(r), "deal", it.DealID); err != nil {
+			return models.Interaction{}, http.StatusInternalServerError, err.Error()
+		} else if !ok {
+			return models.Interaction{}, http.StatusNotFound, "interaction not found"
+		}
+	}
+	caller := mustAuth(r).User
+	if it.UserID != caller.ID && caller.Role != models.RoleAdmin {
+		return models.Interaction{}, http.StatusForbidden, "follow-up belongs to another user"
+	}
+	return it, 0, ""
+}
+
+func (s *Server) updateFollowUp(r *http.Request, it models.Interaction, date *time.Time, notes string, completed bool) (models.Interaction, error) {
+	next, history := followUpChange(it, date, notes, completed, mustAuth(r).User.ID, time.Now())
+	if err := s.store.UpdateInteractionFollowUps([]models.Interaction{next}, history); err != nil {
+		return it, err
+	}
+	return next, nil
+}
+
+// followUpChange returns it with its follow-up fields replaced, and the
+// history entries recording the change.
+func followUpChange(it models.Interaction, date *time.Time, notes string, completed bool, userID string, now time.Time) (models.Interaction, []models.HistoryEntry) {
+	history := historyEntries("interaction", it.ID, userID,
+		fieldChange{field: "followUpDate", oldValue: formatTimePtr(it.FollowUpDate), newValue: formatTimePtr(date)},
+		fieldChange{field: "followUpCompleted", oldValue: strconv.FormatBool(it.FollowUpCompleted), newValue: strconv.FormatBool(completed)},
+	)
+	it.FollowUpDate = date
+	it.FollowUpNotes = notes
+	it.FollowUpCompleted = completed
+	it.UpdatedAt = now
+	return it, history
+}
+
+// sendFollowUpDigests gives every user with overdue or due follow-ups one
+// digest notification per day, once the configured digest hour has passed.
+func (s *Server) sendFollowUpDigests(now time.Time) error {
+	s.mu.RLock()
+	hour := s.settings.FollowUpDigestHour
+	s.mu.RUnlock()
+	if hour < 0 || now.Hour() < hour {
+		return nil
+	}
+	users, err := s.store.LoadUsers()
+	if err != nil {
+		return err
+	}
+	day := now.Format("2006-01-02")
+	for _, u := range users {
+		views, err := s.followUpViews(db.ViewerFor(u), u.ID, now, 1)
+		if err != nil {
+			return err
+		}
+		if len(views.Overdue) == 0 && len(views.Due) == 0 {
+			continue
+		}
+		var body strings.Builder
+		for _, item := range append(views.Overdue, views.Due...) {
+			fmt.Fprintf(&body, "- %s %s", item.FollowUpDate.Format("2006-01-02"), strings.TrimSpace(item.Subject))
+			if who := firstNonBlank(item.ContactName, item.OrganizationName); who != "" {
+				fmt.Fprintf(&body, " (%s)", who)
+			}
+			body.WriteString("\n")
+		}
+		_, err = s.notify(models.Notification{
+			UserID: u.ID,
+			Kind:   "follow_up_digest",
+			Title:  fmt.Sprintf("%d follow-ups due today, %d overdue", len(views.Due), len(views.Overdue)),
+			Body:   strings.TrimRight(body.String(), "\n"),
+			Key:    "follow_up_digest:" + u.ID + ":" + day,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func firstNonBlank(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+type fieldChange struct {
+	field    string
+	oldValue string
+	newValue string
+}
+
+// recordHistory stores the given changes for one entity, skipping fields
+// whose value did not actually change.
+func (s *Server) recordHistory(entityType, entityID, userID string, changes ...fieldChange) error {
+	return s.store.AddHistory(historyEntries(entityType, entityID, userID, changes...)...)
+}
+
+// historyEntries builds the entries recordHistory would store, for callers
+// that write them in their own transaction.
+func historyEntries(entityType, entityID, userID string, changes ...fieldChange) []models.HistoryEntry {
+	now := time.Now()
+	base := newID()
+	entries := make([]models.HistoryEntry, 0, len(changes))
+	for i, c := range changes {
+		if c.oldValue == c.newValue {
+			continue
+		}
+		entries = append(entries, models.HistoryEntry{
+			ID:         base + "-" + strconv.Itoa(i),
+			EntityType: entityType,
+			EntityID:   entityID,
+			Field:      c.field,
+			OldValue:   c.oldValue,
+			NewValue:   c.newValue,
+			UserID:     userID,
+			CreatedAt:  now,
+		})
+	}
+	return entries
+}
+
+// dealChanges lists the tracked deal fields that differ between prev (the
+// stored deal, nil when new) and d.
+func dealChanges(prev *models.Deal, d models.Deal) []fieldChange {
+	var old models.Deal
+	if prev != nil {
+		old = *prev
+	}
+	return []fieldChange{
+		{field: "pipelineStageId", oldValue: old.PipelineStageID, newValue: d.PipelineStageID},
+		{field: "status", oldValue: string(old.Status), newValue: string(d.Status)},
+		{field: "ownerUserId", oldValue: old.OwnerUserID, newValue: d.OwnerUserID},
+	}
+}
+
+// projectStatus returns the stored status of a project, "" when it does not
+// exist yet.
+func (s *Server) projectStatus(id string) (string, error) {
+	projects, err := s.store.LoadProjects()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range projects {
+		if p.ID == id {
+			return string(p.Status), nil
+		}
+	}
+	return "", nil
+}
+
+// quotationStatus returns the stored status of a quotation, "" when it does
+// not exist yet.
+func (s *Server) quotationStatus(id string) (string, error) {
+	quotations, err := s.store.LoadQuotations()
+	if err != nil {
+		return "", err
+	}
+	for _, q := range quotations {
+		if q.ID == id {
+			return string(q.Status), nil
+		}
+	}
+	return "", nil
+}
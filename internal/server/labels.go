@@ -0,0 +1,253 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// labelEntityTypes are the entity types that can be tagged.
+var labelEntityTypes = []string{"organization", "contact", "deal", "project", "task"}
+
+const defaultLabelColor = "#9ca3af"
+
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type labelUsage struct {
+	models.Label
+	Count int `json:"count"`
+}
+
+func (s *Server) handleLabels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		labels, err := s.store.LoadLabels()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		counts, err := s.store.CountTaggings()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		out := make([]labelUsage, 0, len(labels))
+		for _, l := range labels {
+			out = append(out, labelUsage{Label: l, Count: counts[l.ID]})
+		}
+		writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		var l models.Label
+		if err := readJSON(r, &l); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		l.Name = strings.TrimSpace(l.Name)
+		if l.Name == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("name is required"))
+			return
+		}
+		if strings.Contains(l.Name, ",") {
+			writeJSON(w, http.StatusBadRequest, errorResponse("name cannot contain commas"))
+			return
+		}
+		l.Color = strings.TrimSpace(l.Color)
+		if l.Color == "" {
+			l.Color = defaultLabelColor
+		}
+		if !labelColorPattern.MatchString(l.Color) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("color must be #rrggbb"))
+			return
+		}
+		l.Color = strings.ToLower(l.Color)
+
+		labels, err := s.store.LoadLabels()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		now := time.Now()
+		if l.ID == "" {
+			l.ID = newID()
+		}
+		l.CreatedAt = now
+		for _, other := range labels {
+			if other.ID == l.ID {
+				l.CreatedAt = other.CreatedAt
+			} else if strings.EqualFold(other.Name, l.Name) {
+				writeJSON(w, http.StatusConflict, errorResponse("a label named "+other.Name+" already exists"))
+				return
+			}
+		}
+		l.UpdatedAt = now
+		if err := s.store.SaveLabel(l); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, l)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if err := s.store.DeleteLabel(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleLabelsAssign adds and removes labels, by ID or name, on many
+// entities of one type at once.
+func (s *Server) handleLabelsAssign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		EntityType string   `json:"entityType"`
+		EntityIDs  []string `json:"entityIds"`
+		Add        []string `json:"add"`
+		Remove     []string `json:"remove"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	if !slices.Contains(labelEntityTypes, payload.EntityType) {
+		writeJSON(w, http.StatusBadRequest, errorResponse("entityType must be one of "+strings.Join(labelEntityTypes, ", ")))
+		return
+	}
+	if len(payload.EntityIDs) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("entityIds is required"))
+		return
+	}
+	if len(payload.Add) == 0 && len(payload.Remove) == 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("add or remove is required"))
+		return
+	}
+	labels, err := s.store.LoadLabels()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	add, err := resolveLabels(labels, payload.Add)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	remove, err := resolveLabels(labels, payload.Remove)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	missing, err := s.store.MissingEntities(payload.EntityType, payload.EntityIDs)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if len(missing) > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse(payload.EntityType+" not found: "+strings.Join(missing, ", ")))
+		return
+	}
+	if err := s.store.TagEntities(payload.EntityType, payload.EntityIDs, add, remove); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	tags, err := s.store.LoadTaggings(payload.EntityType)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	out := make(map[string][]string, len(payload.EntityIDs))
+	for _, id := range payload.EntityIDs {
+		out[id] = append([]string{}, tags[id]...)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// resolveLabels maps label IDs or names (case-insensitive) to IDs.
+func resolveLabels(labels []models.Label, refs []string) ([]string, error) {
+	out := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ref = strings.TrimSpace(ref)
+		if ref == "" {
+			continue
+		}
+		i := slices.IndexFunc(labels, func(l models.Label) bool { return l.ID == ref })
+		if i < 0 {
+			i = slices.IndexFunc(labels, func(l models.Label) bool { return strings.EqualFold(l.Name, ref) })
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("unknown label %s", ref)
+		}
+		if !slices.Contains(out, labels[i].ID) {
+			out = append(out, labels[i].ID)
+		}
+	}
+	return out, nil
+}
+
+// labelsFromQuery resolves the comma-separated ?labels= filter; ok is false
+// once an error response has been written.
+func (s *Server) labelsFromQuery(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("labels"))
+	if raw == "" {
+		return nil, true
+	}
+	labels, err := s.store.LoadLabels()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return nil, false
+	}
+	ids, err := resolveLabels(labels, strings.Split(raw, ","))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return nil, false
+	}
+	return ids, true
+}
+
+// saveEntityLabels replaces an entity's labels when the request sent
+// labelIds; nil leaves them alone. It returns the labels the entity ends up
+// with.
+func (s *Server) saveEntityLabels(entityType, entityID string, labelIDs []string) ([]string, error) {
+	if labelIDs != nil {
+		if err := s.store.SetEntityLabels(entityType, entityID, labelIDs); err != nil {
+			return nil, err
+		}
+	}
+	tags, err := s.store.LoadTaggings(entityType)
+	return tags[entityID], err
+}
+
+// validLabelIDs writes a 400 and returns false when ids names a label that
+// does not exist.
+func (s *Server) validLabelIDs(w http.ResponseWriter, ids []string) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	labels, err := s.store.LoadLabels()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	for _, id := range ids {
+		if !slices.ContainsFunc(labels, func(l models.Label) bool { return l.ID == id }) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("unknown label "+id))
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/ical"
+	"wemadeit/internal/models"
+)
+
+const maxCalendarBytes = 10 << 20
+
+type meetingImportResult struct {
+	Created   int                  `json:"created"`
+	Updated   int                  `json:"updated"`
+	Unchanged int                  `json:"unchanged"`
+	Skipped   int                  `json:"skipped"`
+	Unmatched []string             `json:"unmatched"`
+	Items     []models.Interaction `json:"items"`
+	Errors    map[string]string    `json:"errors,omitempty"`
+}
+
+// handleMeetingImport turns calendar events into meeting interactions. The
+// body is an uploaded .ics file, or with ?source=directory the configured
+// meeting_import_dir is scanned.
+func (s *Server) handleMeetingImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	userID := mustAuth(r).User.ID
+	now := time.Now()
+
+	if r.URL.Query().Get("source") == "directory" {
+		s.mu.RLock()
+		dir := s.settings.MeetingImportDir
+		s.mu.RUnlock()
+		if dir == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("meeting_import_dir is not configured"))
+			return
+		}
+		res, err := s.importMeetingDir(dir, viewer(r), userID, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	data, _, err := readUpload(r, maxCalendarBytes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	events, err := ical.Parse(bytes.NewReader(data), time.Local)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	res := newMeetingImportResult()
+	if err := s.importMeetings(events, viewer(r), userID, now, &res); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func newMeetingImportResult() meetingImportResult {
+	return meetingImportResult{
+		Unmatched: make([]string, 0),
+		Items:     make([]models.Interaction, 0),
+	}
+}
+
+// importMeetingDir imports every .ics file below dir. Unreadable files are
+// reported per file rather than failing the whole run.
+func (s *Server) importMeetingDir(dir string, v db.Viewer, userID string, now time.Time) (meetingImportResult, error) {
+	res := newMeetingImportResult()
+	var events []ical.Parsed
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".ics") {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		parsed, err := ical.Parse(f, time.Local)
+		if err != nil {
+			if res.Errors == nil {
+				res.Errors = make(map[string]string)
+			}
+			res.Errors[path] = err.Error()
+			return nil
+		}
+		events = append(events, parsed...)
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+	return res, s.importMeetings(events, v, userID, now, &res)
+}
+
+// importMeetingsFromDir is the scheduler job for meeting_import_dir. Without
+// a caller, meetings belong to the organizer's or an attendee's user account.
+func (s *Server) importMeetingsFromDir(now time.Time) error {
+	s.mu.RLock()
+	dir := s.settings.MeetingImportDir
+	s.mu.RUnlock()
+	if dir == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	res, err := s.importMeetingDir(dir, db.Viewer{Role: models.RoleAdmin}, "", now)
+	if err != nil {
+		return err
+	}
+	for path, msg := range res.Errors {
+		fmt.Println("Meeting import:", path+":", msg)
+	}
+	return nil
+}
+
+// importMeetings creates or updates one meeting interaction per event. Events
+// are matched to contacts through attendee and organizer e-mail addresses;
+// events without a known contact are reported as unmatched and not imported,
+// which keeps private appointments out of the CRM. Re-imports are keyed on
+// the event UID and only refresh calendar-owned fields. Meetings are only
+// linked to deals v may see.
+func (s *Server) importMeetings(events []ical.Parsed, v db.Viewer, userID string, now time.Time, res *meetingImportResult) error {
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		return err
+	}
+	contactsByEmail := make(map[string]models.Contact, len(contacts))
+	for _, c := range contacts {
+		if e := strings.ToLower(strings.TrimSpace(c.Email)); e != "" {
+			contactsByEmail[e] = c
+		}
+	}
+	users, err := s.store.LoadUsers()
+	if err != nil {
+		return err
+	}
+	usersByEmail := make(map[string]string, len(users))
+	for _, u := range users {
+		if e := strings.ToLower(strings.TrimSpace(u.EmailAddress)); e != "" {
+			usersByEmail[e] = u.ID
+		}
+	}
+	deals, err := s.store.LoadDealsFor(v)
+	if err != nil {
+		return err
+	}
+
+	for _, ev := range events {
+		if ev.Status == "CANCELLED" {
+			res.Skipped++
+			continue
+		}
+		addresses := append([]string{ev.Organizer}, ev.Attendees...)
+		var contact models.Contact
+		owner := ""
+		for _, a := range addresses {
+			if c, ok := contactsByEmail[a]; ok && contact.ID == "" {
+				contact = c
+			}
+			if id, ok := usersByEmail[a]; ok && owner == "" {
+				owner = id
+			}
+		}
+		if contact.ID == "" {
+			res.Unmatched = append(res.Unmatched, firstNonBlank(ev.Summary, ev.UID))
+			continue
+		}
+		if id, ok := usersByEmail[ev.Organizer]; ok {
+			owner = id
+		}
+		if userID != "" {
+			owner = userID
+		}
+
+		externalID := "ics:" + ev.Key()
+		existing, found, err := s.store.FindInteractionByExternalID(externalID)
+		if err != nil {
+			return err
+		}
+		i := existing
+		if !found {
+			i = models.Interaction{
+				ID:                    newID(),
+				UserID:                owner,
+				InteractionType:       models.InteractionMeeting,
+				ExternalID:            externalID,
+				TranscriptionLanguage: "it",
+				TranscriptionStatus:   "pending",
+				CreatedAt:             now,
+			}
+		}
+		if i.ContactID == "" {
+			i.ContactID = contact.ID
+		}
+		if i.OrganizationID == "" {
+			i.OrganizationID = contact.OrganizationID
+		}
+		if i.DealID == "" {
+			i.DealID = openDealFor(deals, i.ContactID, i.OrganizationID)
+		}
+		i.Subject = firstNonBlank(ev.Summary, "Meeting")
+		i.Body = meetingBody(ev)
+		i.DurationMinutes = int(ev.Duration() / time.Minute)
+		if found && i.OccurredAt.Equal(ev.Start) {
+			// Compare with the stored times so only real changes count.
+			i.OccurredAt = existing.OccurredAt
+			if i == existing {
+				res.Unchanged++
+				continue
+			}
+		}
+		i.OccurredAt = ev.Start
+		i.UpdatedAt = now
+		if err := s.store.SaveInteraction(i); err != nil {
+			return err
+		}
+		if found {
+			res.Updated++
+		} else {
+			res.Created++
+		}
+		res.Items = append(res.Items, i)
+	}
+	return nil
+}
+
+// openDealFor picks the most recently updated open deal of the contact, else
+// of the organization.
+func openDealFor(deals []models.Deal, contactID, orgID string) string {
+	var best *models.Deal
+	for _, match := range []func(models.Deal) bool{
+		func(d models.Deal) bool { return contactID != "" && d.ContactID == contactID },
+		func(d models.Deal) bool { return orgID != "" && d.OrganizationID == orgID },
+	} {
+		for i := range deals {
+			d := &deals[i]
+			if d.Status != models.DealOpen || !match(*d) {
+				continue
+			}
+			if best == nil || d.UpdatedAt.After(best.UpdatedAt) {
+				best = d
+			}
+		}
+		if best != nil {
+			return best.ID
+		}
+	}
+	return ""
+}
+
+func meetingBody(ev ical.Parsed) string {
+	parts := make([]string, 0, 3)
+	if d := strings.TrimSpace(ev.Description); d != "" {
+		parts = append(parts, d)
+	}
+	if l := strings.TrimSpace(ev.Location); l != "" {
+		parts = append(parts, "Location: "+l)
+	}
+	if len(ev.Attendees) > 0 {
+		parts = append(parts, "Attendees: "+strings.Join(ev.Attendees, ", "))
+	}
+	return strings.Join(parts, "\n\n")
+}
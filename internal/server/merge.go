@@ -0,0 +1,283 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/dedupe"
+	"wemadeit/internal/models"
+)
+
+// defaultCallingCode is assumed for phone numbers stored without one.
+const defaultCallingCode = "39"
+
+type duplicatePair[T any] struct {
+	Score   float64         `json:"score"`
+	Reasons []dedupe.Reason `json:"reasons"`
+	A       T               `json:"a"`
+	B       T               `json:"b"`
+}
+
+type mergeRequest struct {
+	SurvivorID string `json:"survivorId"`
+	MergedID   string `json:"mergedId"`
+}
+
+func (s *Server) handleContactDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	minScore, ok := parseMinScore(w, r)
+	if !ok {
+		return
+	}
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	byID := make(map[string]models.Contact, len(contacts))
+	records := make([]dedupe.Record, 0, len(contacts))
+	for _, c := range contacts {
+		byID[c.ID] = c
+		// Colleagues share an e-mail domain, so it is no evidence here.
+		records = append(records, dedupe.Record{
+			ID:     c.ID,
+			Name:   c.FirstName + " " + c.LastName,
+			Emails: []string{c.Email},
+			Phones: []string{c.Phone, c.Mobile},
+		})
+	}
+	out := make([]duplicatePair[models.Contact], 0)
+	for _, m := range dedupe.Find(records, dedupe.Options{MinScore: minScore, CallingCode: defaultCallingCode}) {
+		out = append(out, duplicatePair[models.Contact]{Score: m.Score, Reasons: m.Reasons, A: byID[m.A], B: byID[m.B]})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleOrganizationDuplicates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	minScore, ok := parseMinScore(w, r)
+	if !ok {
+		return
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	byID := make(map[string]models.Organization, len(orgs))
+	records := make([]dedupe.Record, 0, len(orgs))
+	for _, o := range orgs {
+		byID[o.ID] = o
+		domains := []string{o.Website}
+		for _, e := range []string{o.Email, o.BillingEmail} {
+			if d := emailDomain(e); d != "" && !freeMailDomains[d] {
+				domains = append(domains, d)
+			}
+		}
+		records = append(records, dedupe.Record{
+			ID:      o.ID,
+			Name:    o.Name,
+			Emails:  []string{o.Email, o.BillingEmail},
+			Phones:  []string{o.Phone},
+			Domains: domains,
+			TaxID:   o.TaxID,
+		})
+	}
+	out := make([]duplicatePair[models.Organization], 0)
+	for _, m := range dedupe.Find(records, dedupe.Options{MinScore: minScore, CallingCode: defaultCallingCode}) {
+		out = append(out, duplicatePair[models.Organization]{Score: m.Score, Reasons: m.Reasons, A: byID[m.A], B: byID[m.B]})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleContactMerge folds mergedId into survivorId: blank survivor fields
+// are filled from the merged contact, its deals and interactions move over
+// and the merged contact is deleted.
+func (s *Server) handleContactMerge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	req, ok := readMergeRequest(w, r)
+	if !ok {
+		return
+	}
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	var survivor, merged models.Contact
+	for _, c := range contacts {
+		switch c.ID {
+		case req.SurvivorID:
+			survivor = c
+		case req.MergedID:
+			merged = c
+		}
+	}
+	if survivor.ID == "" || merged.ID == "" {
+		writeJSON(w, http.StatusNotFound, errorResponse("contact not found"))
+		return
+	}
+
+	prev := survivor
+	fillBlank(&survivor.OrganizationID, merged.OrganizationID)
+	fillBlank(&survivor.FirstName, merged.FirstName)
+	fillBlank(&survivor.LastName, merged.LastName)
+	fillBlank(&survivor.JobTitle, merged.JobTitle)
+	fillBlank(&survivor.Email, merged.Email)
+	fillBlank(&survivor.Phone, merged.Phone)
+	fillBlank(&survivor.Mobile, merged.Mobile)
+	fillBlank(&survivor.LinkedInURL, merged.LinkedInURL)
+	survivor.Notes = mergeNotes(survivor.Notes, merged.Notes)
+	survivor.PrimaryContact = survivor.PrimaryContact || merged.PrimaryContact
+	survivor.UpdatedAt = time.Now()
+
+	userID := mustAuth(r).User.ID
+	history := historyEntries("contact", survivor.ID, userID,
+		fieldChange{field: "mergedFrom", newValue: merged.ID},
+		fieldChange{field: "organizationId", oldValue: prev.OrganizationID, newValue: survivor.OrganizationID},
+		fieldChange{field: "email", oldValue: prev.Email, newValue: survivor.Email},
+		fieldChange{field: "phone", oldValue: prev.Phone, newValue: survivor.Phone},
+		fieldChange{field: "mobile", oldValue: prev.Mobile, newValue: survivor.Mobile},
+	)
+	history = append(history, historyEntries("contact", merged.ID, userID,
+		fieldChange{field: "mergedInto", newValue: survivor.ID})...)
+	if err := s.store.MergeContacts(survivor, merged.ID, history); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, survivor)
+}
+
+// handleOrganizationMerge is handleContactMerge for organizations; contacts,
+// deals, interactions and domains move to the survivor.
+func (s *Server) handleOrganizationMerge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	req, ok := readMergeRequest(w, r)
+	if !ok {
+		return
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	var survivor, merged models.Organization
+	parents := make(map[string]string, len(orgs))
+	for _, o := range orgs {
+		parents[o.ID] = o.ParentID
+		switch o.ID {
+		case req.SurvivorID:
+			survivor = o
+		case req.MergedID:
+			merged = o
+		}
+	}
+	if survivor.ID == "" || merged.ID == "" {
+		writeJSON(w, http.StatusNotFound, errorResponse("organization not found"))
+		return
+	}
+
+	prev := survivor
+	fillBlank(&survivor.Industry, merged.Industry)
+	fillBlank(&survivor.Website, merged.Website)
+	fillBlank(&survivor.Email, merged.Email)
+	fillBlank(&survivor.Phone, merged.Phone)
+	fillBlank(&survivor.BillingEmail, merged.BillingEmail)
+	fillBlank(&survivor.TaxID, merged.TaxID)
+	fillBlank(&survivor.Address, merged.Address)
+	fillBlank(&survivor.City, merged.City)
+	fillBlank(&survivor.Country, merged.Country)
+	survivor.Notes = mergeNotes(survivor.Notes, merged.Notes)
+	// A subsidiary merged with one of its ancestors takes the ancestor's
+	// place; the organizations in between end up below it.
+	seen := map[string]bool{}
+	for id := survivor.ParentID; id != "" && !seen[id]; id = parents[id] {
+		if id == merged.ID {
+			survivor.ParentID = merged.ParentID
+			break
+		}
+		seen[id] = true
+	}
+	survivor.UpdatedAt = time.Now()
+
+	userID := mustAuth(r).User.ID
+	history := historyEntries("organization", survivor.ID, userID,
+		fieldChange{field: "mergedFrom", newValue: merged.ID},
+		fieldChange{field: "website", oldValue: prev.Website, newValue: survivor.Website},
+		fieldChange{field: "email", oldValue: prev.Email, newValue: survivor.Email},
+		fieldChange{field: "phone", oldValue: prev.Phone, newValue: survivor.Phone},
+		fieldChange{field: "taxId", oldValue: prev.TaxID, newValue: survivor.TaxID},
+		fieldChange{field: "billingEmail", oldValue: prev.BillingEmail, newValue: survivor.BillingEmail},
+	)
+	history = append(history, historyEntries("organization", merged.ID, userID,
+		fieldChange{field: "mergedInto", newValue: survivor.ID})...)
+	if err := s.store.MergeOrganizations(survivor, merged.ID, history); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, survivor)
+}
+
+func readMergeRequest(w http.ResponseWriter, r *http.Request) (mergeRequest, bool) {
+	var req mergeRequest
+	if err := readJSON(r, &req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return req, false
+	}
+	req.SurvivorID = strings.TrimSpace(req.SurvivorID)
+	req.MergedID = strings.TrimSpace(req.MergedID)
+	if req.SurvivorID == "" || req.MergedID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse("survivorId and mergedId are required"))
+		return req, false
+	}
+	if req.SurvivorID == req.MergedID {
+		writeJSON(w, http.StatusBadRequest, errorResponse("cannot merge a record into itself"))
+		return req, false
+	}
+	return req, true
+}
+
+func parseMinScore(w http.ResponseWriter, r *http.Request) (float64, bool) {
+	v := strings.TrimSpace(r.URL.Query().Get("minScore"))
+	if v == "" {
+		return 0.5, true
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f <= 0 || f > 1 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("minScore must be between 0 and 1"))
+		return 0, false
+	}
+	return f, true
+}
+
+func fillBlank(dst *string, v string) {
+	if strings.TrimSpace(*dst) == "" {
+		*dst = v
+	}
+}
+
+func mergeNotes(a, b string) string {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch {
+	case b == "" || a == b:
+		return a
+	case a == "":
+		return b
+	default:
+		return a + "\n\n" + b
+	}
+}
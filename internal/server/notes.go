@@ -0,0 +1,232 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+// noteEntityTypes are the records notes can be attached to.
+var noteEntityTypes = []string{"organization", "contact", "deal", "project", "task", "quotation"}
+
+var mentionPattern = regexp.MustCompile(`(^|[^\w@.])@([\w.-]+)`)
+
+func (s *Server) handleNotes(w http.ResponseWriter, r *http.Request) {
+	user := mustAuth(r).User
+	switch r.Method {
+	case http.MethodGet:
+		entityType := strings.TrimSpace(r.URL.Query().Get("entityType"))
+		entityID := strings.TrimSpace(r.URL.Query().Get("entityId"))
+		if !slices.Contains(noteEntityTypes, entityType) || entityID == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("entityType (one of "+strings.Join(noteEntityTypes, ", ")+") and entityId are required"))
+			return
+		}
+		if !s.canReadEntity(w, r, entityType, entityID) {
+			return
+		}
+		notes, err := s.store.LoadNotes(entityType, entityID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, notes)
+	case http.MethodPost:
+		var n models.Note
+		if err := readJSON(r, &n); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		n.Body = strings.TrimSpace(n.Body)
+		if n.Body == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("body is required"))
+			return
+		}
+		users, err := s.store.LoadUsers()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		n.Mentions = noteMentions(n.Body, users)
+		now := time.Now()
+
+		var prev *models.Note
+		if n.ID != "" {
+			existing, ok, err := s.store.FindNoteByID(n.ID)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			if ok {
+				prev = &existing
+			}
+		}
+		if prev != nil {
+			if !s.canReadEntity(w, r, prev.EntityType, prev.EntityID) {
+				return
+			}
+			if prev.AuthorUserID != user.ID && user.Role != models.RoleAdmin {
+				writeJSON(w, http.StatusForbidden, errorResponse("only the author can edit a note"))
+				return
+			}
+			// The target and author of a note never change.
+			n.EntityType = prev.EntityType
+			n.EntityID = prev.EntityID
+			n.AuthorUserID = prev.AuthorUserID
+			n.CreatedAt = prev.CreatedAt
+			n.Revisions = prev.Revisions
+			if n.Body == prev.Body {
+				writeJSON(w, http.StatusOK, *prev)
+				return
+			}
+			n.UpdatedAt = now
+			if err := s.store.EditNote(n, *prev, user.ID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			n.Revisions++
+		} else {
+			if !slices.Contains(noteEntityTypes, n.EntityType) {
+				writeJSON(w, http.StatusBadRequest, errorResponse("entityType must be one of "+strings.Join(noteEntityTypes, ", ")))
+				return
+			}
+			missing, err := s.store.MissingEntities(n.EntityType, []string{n.EntityID})
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			if len(missing) > 0 {
+				writeJSON(w, http.StatusBadRequest, errorResponse(n.EntityType+" not found"))
+				return
+			}
+			if !s.canReadEntity(w, r, n.EntityType, n.EntityID) {
+				return
+			}
+			if n.ID == "" {
+				n.ID = newID()
+			}
+			n.AuthorUserID = user.ID
+			n.Revisions = 0
+			n.CreatedAt = now
+			n.UpdatedAt = now
+			if err := s.store.SaveNote(n); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		if err := s.notifyMentions(n, user); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, n)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			n, ok, err := s.store.FindNoteByID(id)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			if ok && !s.canReadEntity(w, r, n.EntityType, n.EntityID) {
+				return
+			}
+			if ok && n.AuthorUserID != user.ID && user.Role != models.RoleAdmin {
+				writeJSON(w, http.StatusForbidden, errorResponse("only the author can delete a note"))
+				return
+			}
+		}
+		for _, id := range ids {
+			if err := s.store.DeleteNote(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// handleNoteRevisions lists the earlier bodies of ?noteId=.
+func (s *Server) handleNoteRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	noteID := strings.TrimSpace(r.URL.Query().Get("noteId"))
+	if noteID == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse("noteId is required"))
+		return
+	}
+	n, ok, err := s.store.FindNoteByID(noteID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse("note not found"))
+		return
+	}
+	if !s.canReadEntity(w, r, n.EntityType, n.EntityID) {
+		return
+	}
+	revisions, err := s.store.LoadNoteRevisions(noteID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, revisions)
+}
+
+// noteMentions returns the IDs of the users whose username is @mentioned in
+// body, in order of first mention.
+func noteMentions(body string, users []models.User) []string {
+	out := make([]string, 0)
+	for _, m := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.TrimRight(m[2], ".-")
+		for _, u := range users {
+			if u.Username != "" && strings.EqualFold(u.Username, name) && !slices.Contains(out, u.ID) {
+				out = append(out, u.ID)
+			}
+		}
+	}
+	return out
+}
+
+// notifyMentions tells every user mentioned in n, other than the author of
+// the change, about it. Notifications are keyed by note and user, so a user
+// hears about a note once however often it is edited.
+func (s *Server) notifyMentions(n models.Note, by models.User) error {
+	name := by.Name
+	if name == "" {
+		name = by.Username
+	}
+	body := n.Body
+	if r := []rune(body); len(r) > 280 {
+		body = string(r[:280]) + "…"
+	}
+	for _, userID := range n.Mentions {
+		if userID == by.ID {
+			continue
+		}
+		if _, err := s.notify(models.Notification{
+			UserID:     userID,
+			Kind:       "note_mention",
+			Title:      name + " mentioned you in a note",
+			Body:       body,
+			EntityType: n.EntityType,
+			EntityID:   n.EntityID,
+			Key:        "note_mention:" + n.ID + ":" + userID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	userID := mustAuth(r).User.ID
+	switch r.Method {
+	case http.MethodGet:
+		limit := 50
+		if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 500 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("limit must be between 1 and 500"))
+				return
+			}
+			limit = n
+		}
+		unreadOnly := r.URL.Query().Get("unread") == "1" || r.URL.Query().Get("unread") == "true"
+		items, err := s.store.LoadNotifications(userID, unreadOnly, limit)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		unread, err := s.store.CountUnreadNotifications(userID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items, "unread": unread})
+	case http.MethodPost:
+		// Marks notifications read: {"ids": [...]} or {"all": true}.
+		var payload struct {
+			IDs []string `json:"ids"`
+			All bool     `json:"all"`
+		}
+		if err := readJSON(r, &payload); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		if len(payload.IDs) == 0 && !payload.All {
+			writeJSON(w, http.StatusBadRequest, errorResponse("ids or all is required"))
+			return
+		}
+		ids := payload.IDs
+		if payload.All {
+			ids = nil
+		}
+		if err := s.store.MarkNotificationsRead(userID, ids, time.Now()); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if strings.TrimSpace(id) == "" {
+				continue
+			}
+			if err := s.store.DeleteNotification(userID, id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// notify stores a notification, filling in its ID and time. It returns false
+// when a notification with the same Key already exists.
+func (s *Server) notify(n models.Notification) (bool, error) {
+	if n.ID == "" {
+		n.ID = newID()
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	return s.store.CreateNotification(n)
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+var addressTypes = []models.AddressType{models.AddressBilling, models.AddressShipping, models.AddressOffice}
+
+// validParentOrganization writes a 400 and returns false when org's parent
+// does not exist or would make the hierarchy circular.
+func (s *Server) validParentOrganization(w http.ResponseWriter, org models.Organization) bool {
+	if org.ParentID == "" {
+		return true
+	}
+	if org.ParentID == org.ID {
+		writeJSON(w, http.StatusBadRequest, errorResponse("an organization cannot be its own parent"))
+		return false
+	}
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	parents := make(map[string]string, len(orgs))
+	for _, o := range orgs {
+		parents[o.ID] = o.ParentID
+	}
+	if _, ok := parents[org.ParentID]; !ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse("parentId not found"))
+		return false
+	}
+	seen := map[string]bool{}
+	for id := org.ParentID; id != "" && !seen[id]; id = parents[id] {
+		if id == org.ID {
+			writeJSON(w, http.StatusBadRequest, errorResponse("parentId would make the organization its own ancestor"))
+			return false
+		}
+		seen[id] = true
+	}
+	return true
+}
+
+func (s *Server) handleAddresses(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		addresses, err := s.store.LoadAddresses(strings.TrimSpace(r.URL.Query().Get("organizationId")))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, addresses)
+	case http.MethodPost:
+		var a models.Address
+		if err := readJSON(r, &a); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		a.Type = models.AddressType(strings.ToLower(strings.TrimSpace(string(a.Type))))
+		if !slices.Contains(addressTypes, a.Type) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("type must be billing, shipping or office"))
+			return
+		}
+		a.Street = strings.TrimSpace(a.Street)
+		a.PostalCode = strings.TrimSpace(a.PostalCode)
+		a.City = strings.TrimSpace(a.City)
+		a.Province = strings.TrimSpace(a.Province)
+		a.Country = strings.TrimSpace(a.Country)
+		if a.Street == "" && a.City == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("street or city is required"))
+			return
+		}
+		missing, err := s.store.MissingEntities("organization", []string{a.OrganizationID})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if len(missing) > 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("organizationId not found"))
+			return
+		}
+		now := time.Now()
+		a.CreatedAt = now
+		if a.ID == "" {
+			a.ID = newID()
+		} else if prev, ok, err := s.store.FindAddressByID(a.ID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		} else if ok {
+			a.CreatedAt = prev.CreatedAt
+		}
+		a.UpdatedAt = now
+		if err := s.store.SaveAddress(a); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, a)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, id := range ids {
+			if err := s.store.DeleteAddress(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// billingAddress is the address documents for org are made out to: its
+// oldest billing address, else the organization's own address fields.
+func billingAddress(org models.Organization, addresses []models.Address) models.Address {
+	for _, a := range addresses {
+		if a.OrganizationID == org.ID && a.Type == models.AddressBilling {
+			return a
+		}
+	}
+	return models.Address{
+		OrganizationID: org.ID,
+		Type:           models.AddressBilling,
+		Street:         org.Address,
+		City:           org.City,
+		Country:        org.Country,
+	}
+}
+
+// groupRollup sums one organization's deals and revenue, in the reporting
+// currency. Revenue stays zero for roles that may not read payments.
+type groupRollup struct {
+	Deals     int     `json:"deals"`
+	OpenDeals int     `json:"openDeals"`
+	OpenValue float64 `json:"openValue"`
+	WonValue  float64 `json:"wonValue"`
+	Revenue   float64 `json:"revenue"`
+}
+
+func (g *groupRollup) add(o groupRollup) {
+	g.Deals += o.Deals
+	g.OpenDeals += o.OpenDeals
+	g.OpenValue += o.OpenValue
+	g.WonValue += o.WonValue
+	g.Revenue += o.Revenue
+}
+
+type groupMember struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ParentID string `json:"parentId"`
+	Depth    int    `json:"depth"`
+	groupRollup
+}
+
+type organizationGroup struct {
+	Organization models.Organization `json:"organization"`
+	// Ancestors lists the parent chain, nearest first.
+	Ancestors []models.Organization `json:"ancestors"`
+	// Members is the organization and all its subsidiaries, depth first.
+	Members  []groupMember `json:"members"`
+	Totals   groupRollup   `json:"totals"`
+	Deals    []models.Deal `json:"deals"`
+	Currency string        `json:"currency"`
+	// Unconverted counts amounts left out for lack of an exchange rate.
+	Unconverted int `json:"unconverted"`
+}
+
+// handleOrganizationGroup serves GET /api/organizations/{id}/group: the
+// organization's subsidiaries with their deals and revenue rolled up.
+func (s *Server) handleOrganizationGroup(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	rd, err := s.loadReportData(viewer(r), nil)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	role := mustAuth(r).User.Role
+	if !canRead(role, "deal") {
+		rd.deals = nil
+	}
+	if !canRead(role, "payment") {
+		rd.payments = nil
+	}
+	byID := make(map[string]models.Organization, len(rd.orgs))
+	children := make(map[string][]models.Organization)
+	for _, o := range rd.orgs {
+		byID[o.ID] = o
+		if o.ParentID != "" {
+			children[o.ParentID] = append(children[o.ParentID], o)
+		}
+	}
+	root, ok := byID[id]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse("organization not found"))
+		return
+	}
+
+	g := organizationGroup{
+		Organization: root,
+		Ancestors:    make([]models.Organization, 0),
+		Members:      make([]groupMember, 0),
+		Deals:        make([]models.Deal, 0),
+		Currency:     rd.currency,
+	}
+	seen := map[string]bool{root.ID: true}
+	for pid := root.ParentID; pid != "" && !seen[pid]; pid = byID[pid].ParentID {
+		seen[pid] = true
+		if p, ok := byID[pid]; ok {
+			g.Ancestors = append(g.Ancestors, p)
+		}
+	}
+
+	memberIndex := make(map[string]int)
+	var walk func(o models.Organization, depth int)
+	walk = func(o models.Organization, depth int) {
+		memberIndex[o.ID] = len(g.Members)
+		g.Members = append(g.Members, groupMember{ID: o.ID, Name: o.Name, ParentID: o.ParentID, Depth: depth})
+		kids := children[o.ID]
+		slices.SortFunc(kids, func(a, b models.Organization) int {
+			return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+		for _, c := range kids {
+			if _, done := memberIndex[c.ID]; !done {
+				walk(c, depth+1)
+			}
+		}
+	}
+	walk(root, 0)
+
+	now := time.Now()
+	dealMember := make(map[string]int)
+	for _, d := range rd.deals {
+		i, ok := memberIndex[d.OrganizationID]
+		if !ok {
+			continue
+		}
+		dealMember[d.ID] = i
+		g.Deals = append(g.Deals, d)
+		m := &g.Members[i]
+		m.Deals++
+		if d.Status != models.DealOpen && d.Status != models.DealWon {
+			continue
+		}
+		value, ok := rd.convert(d.Value, d.Currency, now)
+		if !ok {
+			g.Unconverted++
+			continue
+		}
+		if d.Status == models.DealOpen {
+			m.OpenDeals++
+			m.OpenValue += value
+		} else {
+			m.WonValue += value
+		}
+	}
+	for _, p := range rd.payments {
+		i, ok := dealMember[p.DealID]
+		if !ok || p.Status != models.PaymentPaid {
+			continue
+		}
+		at := p.CreatedAt
+		if p.PaidAt != nil {
+			at = *p.PaidAt
+		}
+		amount, ok := rd.convert(p.Amount, p.Currency, at)
+		if !ok {
+			g.Unconverted++
+			continue
+		}
+		g.Members[i].Revenue += amount
+	}
+	for _, m := range g.Members {
+		g.Totals.add(m.groupRollup)
+	}
+	writeJSON(w, http.StatusOK, g)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+// restrictedEntityTypes are the records not every user may see.
+var restrictedEntityTypes = []string{"deal", "project", "task", "quotation"}
+
+// viewer is the user a request's store queries are narrowed to.
+func viewer(r *http.Request) db.Viewer {
+	return db.ViewerFor(mustAuth(r).User)
+}
+
+// resolveOwner checks the owner of an organization, deal or project about
+// to be saved and returns its stored owner. New records without one are
+// owned by the acting user. It writes a 400 and returns false when the
+// owner does not exist.
+func (s *Server) resolveOwner(w http.ResponseWriter, r *http.Request, entityType, id string, owner *string) (string, bool) {
+	*owner = strings.TrimSpace(*owner)
+	prev, existed, err := s.store.OwnerOf(entityType, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return "", false
+	}
+	if !existed && *owner == "" {
+		*owner = mustAuth(r).User.ID
+	}
+	if *owner == "" || *owner == prev {
+		return prev, true
+	}
+	if _, ok, err := s.store.FindUserByID(*owner); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return "", false
+	} else if !ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse("ownerUserId not found"))
+		return "", false
+	}
+	return prev, true
+}
+
+// notifyAssigned tells the new owner of a record it was handed to them by
+// someone else.
+func (s *Server) notifyAssigned(entityType, id, title, prev, owner string, by models.User) error {
+	if owner == "" || owner == prev || owner == by.ID {
+		return nil
+	}
+	name := by.Name
+	if name == "" {
+		name = by.Username
+	}
+	_, err := s.notify(models.Notification{
+		UserID:     owner,
+		Kind:       "assigned",
+		Title:      name + " assigned a " + entityType + " to you",
+		Body:       title,
+		EntityType: entityType,
+		EntityID:   id,
+		Key:        "assigned:" + entityType + ":" + id + ":" + owner + ":" + strconv.FormatInt(time.Now().UnixNano(), 10),
+	})
+	return err
+}
+
+// canSee writes a 404 and returns false when the requesting user may not
+// see the record; hidden records look the same as missing ones.
+func (s *Server) canSee(w http.ResponseWriter, r *http.Request, entityType, id string) bool {
+	ok, err := s.store.CanSee(viewer(r), entityType, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse(entityType+" not found"))
+		return false
+	}
+	return true
+}
+
+// canSeeStoredDeal is canSee for the deal a stored payment, quotation,
+// quotation item, interaction, billing plan or domain belongs to. Records
+// that do not exist yet, and interactions and domains outside any deal, pass.
+func (s *Server) canSeeStoredDeal(w http.ResponseWriter, r *http.Request, entityType, id string) bool {
+	dealID, ok, err := s.store.DealOf(entityType, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	return !ok || dealID == "" || s.canSee(w, r, "deal", dealID)
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"wemadeit/internal/models"
+)
+
+// action is what a request does to a resource, derived from its method.
+type action string
+
+const (
+	actionRead   action = "read"
+	actionWrite  action = "write"
+	actionDelete action = "delete"
+)
+
+var (
+	readOnly        = []action{actionRead}
+	readWrite       = []action{actionRead, actionWrite}
+	readWriteDelete = []action{actionRead, actionWrite, actionDelete}
+	permissionRoles = []models.UserRole{models.RoleSales, models.RoleProjectManager, models.RoleDeveloper}
+)
+
+// permissionMatrix says which actions each role may take on each resource.
+// Admins may do everything; a role missing from a resource has no access.
+// Record-level rules (owners, visibility, authors) are checked on top of it.
+var permissionMatrix = map[string]map[models.UserRole][]action{
+	// account covers a user's own session, feeds and notifications and the
+	// workspace overviews, which are narrowed to what the user can see.
+	"account": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readWriteDelete,
+	},
+	"organizations": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWrite,
+		models.RoleDeveloper:      readOnly,
+	},
+	"contacts": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWrite,
+		models.RoleDeveloper:      readOnly,
+	},
+	"addresses": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWrite,
+		models.RoleDeveloper:      readOnly,
+	},
+	"deals": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readOnly,
+		models.RoleDeveloper:      readOnly,
+	},
+	"quotations": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readOnly,
+	},
+	"payments": {
+		models.RoleSales:          readWrite,
+		models.RoleProjectManager: readOnly,
+	},
+	"billing_plans": {
+		models.RoleSales:          readWrite,
+		models.RoleProjectManager: readOnly,
+	},
+	"bank": {},
+	"exchange_rates": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readOnly,
+	},
+	"reports": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readOnly,
+	},
+	"domains": {
+		models.RoleSales:          readWrite,
+		models.RoleProjectManager: readOnly,
+		models.RoleDeveloper:      readOnly,
+	},
+	"interactions": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWrite,
+		models.RoleDeveloper:      readOnly,
+	},
+	"follow_ups": {
+		models.RoleSales:          readWrite,
+		models.RoleProjectManager: readWrite,
+		models.RoleDeveloper:      readWrite,
+	},
+	"projects": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readOnly,
+	},
+	"project_members": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readWriteDelete,
+	},
+	"tasks": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readWrite,
+	},
+	"task_boards": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readWriteDelete,
+	},
+	"labels": {
+		models.RoleSales:          readWrite,
+		models.RoleProjectManager: readWrite,
+		models.RoleDeveloper:      readOnly,
+	},
+	"notes": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readWriteDelete,
+	},
+	"attachments": {
+		models.RoleSales:          readWriteDelete,
+		models.RoleProjectManager: readWriteDelete,
+		models.RoleDeveloper:      readWriteDelete,
+	},
+	"custom_fields": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readOnly,
+		models.RoleDeveloper:      readOnly,
+	},
+	"settings": {
+		models.RoleSales:          readOnly,
+		models.RoleProjectManager: readOnly,
+		models.RoleDeveloper:      readOnly,
+	},
+	"users":       {},
+	"permissions": {},
+}
+
+// routePermission records which resource a registered route belongs to.
+type routePermission struct {
+	Pattern  string `json:"pattern"`
+	Resource string `json:"resource"`
+}
+
+func requestAction(r *http.Request) action {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return actionRead
+	case http.MethodDelete:
+		return actionDelete
+	default:
+		return actionWrite
+	}
+}
+
+func allowed(role models.UserRole, resource string, a action) bool {
+	if role == models.RoleAdmin {
+		return true
+	}
+	return slices.Contains(permissionMatrix[resource][role], a)
+}
+
+// entityResources maps the entity types that notes, attachments, search
+// hits and timeline events point at to the resource guarding them.
+var entityResources = map[string]string{
+	"organization": "organizations",
+	"contact":      "contacts",
+	"deal":         "deals",
+	"project":      "projects",
+	"task":         "tasks",
+	"quotation":    "quotations",
+	"interaction":  "interactions",
+	"payment":      "payments",
+}
+
+// canRead reports whether role may read records of entityType.
+func canRead(role models.UserRole, entityType string) bool {
+	resource, ok := entityResources[entityType]
+	return ok && allowed(role, resource, actionRead)
+}
+
+// canReadEntity writes a 403 when the user's role may not read records of
+// entityType, or a 404 when they may not see this one, and returns false.
+func (s *Server) canReadEntity(w http.ResponseWriter, r *http.Request, entityType, id string) bool {
+	if !canRead(mustAuth(r).User.Role, entityType) {
+		writeJSON(w, http.StatusForbidden, errorResponse("your role may not read "+entityResources[entityType]))
+		return false
+	}
+	return !slices.Contains(restrictedEntityTypes, entityType) || s.canSee(w, r, entityType, id)
+}
+
+// authorize wraps an authenticated handler with the permission matrix,
+// answering 403 when the user's role may not take the request's action on
+// resource.
+func (s *Server) authorize(resource string, next http.HandlerFunc) http.HandlerFunc {
+	if _, ok := permissionMatrix[resource]; !ok {
+		panic("server: no permissions declared for resource " + resource)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := requestAction(r)
+		if !allowed(mustAuth(r).User.Role, resource, a) {
+			writeJSON(w, http.StatusForbidden, errorResponse("your role may not "+string(a)+" "+resource))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handlePermissions serves the permission matrix and the resource each
+// route belongs to, for admins to review.
+func (s *Server) handlePermissions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	matrix := make(map[string]map[models.UserRole][]action, len(permissionMatrix))
+	for resource, roles := range permissionMatrix {
+		row := map[models.UserRole][]action{models.RoleAdmin: readWriteDelete}
+		for _, role := range permissionRoles {
+			row[role] = roles[role]
+			if row[role] == nil {
+				row[role] = []action{}
+			}
+		}
+		matrix[resource] = row
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"roles":   append([]models.UserRole{models.RoleAdmin}, permissionRoles...),
+		"actions": readWriteDelete,
+		"matrix":  matrix,
+		"routes":  s.routes,
+	})
+}
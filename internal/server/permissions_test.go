@@ -0,0 +1,275 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"wemadeit/internal/blob"
+	"wemadeit/internal/config"
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+// routeAccess is what salespeople, project managers and developers may do
+// on each route: r(ead), w(rite) and d(elete). Admins may do everything.
+var routeAccess = []struct {
+	pattern                   string
+	sales, manager, developer string
+}{
+	{"/api/me", "rwd", "rwd", "rwd"},
+	{"/api/logout", "rwd", "rwd", "rwd"},
+	{"/api/state", "rwd", "rwd", "rwd"},
+	{"/api/dashboard", "rwd", "rwd", "rwd"},
+	{"/api/organizations", "rwd", "rw", "r"},
+	{"/api/organizations/duplicates", "rwd", "rw", "r"},
+	{"/api/organizations/merge", "rwd", "rw", "r"},
+	{"/api/organizations/import", "rwd", "rw", "r"},
+	{"/api/organizations/export", "rwd", "rw", "r"},
+	{"/api/contacts", "rwd", "rw", "r"},
+	{"/api/contacts/duplicates", "rwd", "rw", "r"},
+	{"/api/contacts/merge", "rwd", "rw", "r"},
+	{"/api/contacts/import", "rwd", "rw", "r"},
+	{"/api/contacts/export", "rwd", "rw", "r"},
+	{"/api/deals", "rwd", "r", "r"},
+	{"/api/payments", "rw", "r", ""},
+	{"/api/payments/summary", "rw", "r", ""},
+	{"/api/projects", "r", "rwd", "r"},
+	{"/api/projects/{id}/members", "r", "rwd", "rwd"},
+	{"/api/projects/{id}/board", "r", "rwd", "rw"},
+	{"/api/projects/{id}/board/columns", "r", "rwd", "rwd"},
+	{"/api/projects/{id}/board/columns/reorder", "r", "rwd", "rwd"},
+	{"/api/tasks", "r", "rwd", "rw"},
+	{"/api/tasks/move", "r", "rwd", "rw"},
+	{"/api/users", "", "", ""},
+	{"/api/quotations", "rwd", "r", ""},
+	{"/api/quotations/document", "rwd", "r", ""},
+	{"/api/quotation_items", "rwd", "r", ""},
+	{"/api/interactions", "rwd", "rw", "r"},
+	{"/api/interactions/import_ics", "rwd", "rw", "r"},
+	{"/api/interactions/import_email", "rwd", "rw", "r"},
+	{"/api/follow_ups", "rw", "rw", "rw"},
+	{"/api/follow_ups/complete", "rw", "rw", "rw"},
+	{"/api/follow_ups/snooze", "rw", "rw", "rw"},
+	{"/api/follow_ups/reschedule", "rw", "rw", "rw"},
+	{"/api/notifications", "rwd", "rwd", "rwd"},
+	{"/api/calendar_feed", "rwd", "rwd", "rwd"},
+	{"/api/calendar_feed/rotate", "rwd", "rwd", "rwd"},
+	{"/api/bank/import", "", "", ""},
+	{"/api/bank/transactions", "", "", ""},
+	{"/api/bank/reconcile", "", "", ""},
+	{"/api/billing_plans", "rw", "r", ""},
+	{"/api/billing_plans/run", "rw", "r", ""},
+	{"/api/domains", "rw", "r", "r"},
+	{"/api/domains/expiring", "rw", "r", "r"},
+	{"/api/domains/refresh", "rw", "r", "r"},
+	{"/api/exchange_rates", "r", "r", ""},
+	{"/api/exchange_rates/import", "r", "r", ""},
+	{"/api/exchange_rates/recompute", "r", "r", ""},
+	{"/api/reports/revenue", "r", "r", ""},
+	{"/api/reports/cashflow", "r", "r", ""},
+	{"/api/reports/margin", "r", "r", ""},
+	{"/api/reports/pipeline", "r", "r", ""},
+	{"/api/labels", "rw", "rw", "r"},
+	{"/api/labels/assign", "rw", "rw", "r"},
+	{"/api/notes", "rwd", "rwd", "rwd"},
+	{"/api/notes/revisions", "rwd", "rwd", "rwd"},
+	{"/api/addresses", "rwd", "rw", "r"},
+	{"/api/attachments", "rwd", "rwd", "rwd"},
+	{"/api/attachments/download", "rwd", "rwd", "rwd"},
+	{"/api/search", "rwd", "rwd", "rwd"},
+	{"GET /api/organizations/{id}/timeline", "r", "r", "r"},
+	{"GET /api/organizations/{id}/group", "r", "r", "r"},
+	{"GET /api/contacts/{id}/timeline", "r", "r", "r"},
+	{"GET /api/deals/{id}/timeline", "r", "r", "r"},
+	{"/api/custom_fields", "r", "r", "r"},
+	{"/api/custom_fields/reorder", "r", "r", "r"},
+	{"/api/settings", "r", "r", "r"},
+	{"/api/permissions", "", "", ""},
+}
+
+var methodLetters = map[string]string{
+	http.MethodGet:    "r",
+	http.MethodPost:   "w",
+	http.MethodPut:    "w",
+	http.MethodDelete: "d",
+}
+
+type testServer struct {
+	t     *testing.T
+	store *db.Store
+	h     http.Handler
+	s     *Server
+	n     int
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	dir := t.TempDir()
+	store, err := db.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s := New(store, config.DefaultSettings(), filepath.Join(dir, "config.json"))
+	s.SetBlobStore(blob.Dir{Root: filepath.Join(dir, "blobs")})
+	ts := &testServer{t: t, store: store, s: s, h: s.Handler()}
+	for _, role := range []models.UserRole{models.RoleAdmin, models.RoleSales, models.RoleProjectManager, models.RoleDeveloper} {
+		ts.user("user-"+string(role), role)
+	}
+	return ts
+}
+
+func (ts *testServer) user(id string, role models.UserRole) {
+	ts.t.Helper()
+	now := time.Now()
+	u := models.User{ID: id, Username: id, EmailAddress: id + "@example.com", Role: role, CreatedAt: now, UpdatedAt: now}
+	if err := ts.store.SaveUser(u); err != nil {
+		ts.t.Fatal(err)
+	}
+}
+
+// do sends a request as userID. Every request gets a session of its own, so
+// logging out does not sign the following requests out.
+func (ts *testServer) do(userID, method, path, body string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	ts.n++
+	token := "token-" + userID + "-" + strconv.Itoa(ts.n)
+	now := time.Now()
+	if err := ts.store.SaveSession(token, userID, now, now.Add(time.Hour), "", ""); err != nil {
+		ts.t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	ts.h.ServeHTTP(rec, req)
+	if rec.Code == http.StatusUnauthorized {
+		ts.t.Fatalf("session was not accepted: %s", rec.Body.String())
+	}
+	return rec
+}
+
+func TestRoutesFollowPermissionMatrix(t *testing.T) {
+	ts := newTestServer(t)
+
+	registered := make(map[string]bool, len(ts.s.routes))
+	for _, rt := range ts.s.routes {
+		registered[rt.Pattern] = true
+	}
+	listed := make(map[string]bool, len(routeAccess))
+	for _, ra := range routeAccess {
+		listed[ra.pattern] = true
+		if !registered[ra.pattern] {
+			t.Errorf("%s is listed but not registered", ra.pattern)
+		}
+	}
+	for pattern := range registered {
+		if !listed[pattern] {
+			t.Errorf("%s has no expected access listed", pattern)
+		}
+	}
+
+	for _, ra := range routeAccess {
+		methods, path := []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}, ra.pattern
+		if method, p, ok := strings.Cut(ra.pattern, " "); ok {
+			methods, path = []string{method}, p
+		}
+		path = strings.NewReplacer("{id}", "missing").Replace(path)
+		access := map[models.UserRole]string{
+			models.RoleAdmin:          "rwd",
+			models.RoleSales:          ra.sales,
+			models.RoleProjectManager: ra.manager,
+			models.RoleDeveloper:      ra.developer,
+		}
+		for role, may := range access {
+			for _, method := range methods {
+				rec := ts.do("user-"+string(role), method, path, "")
+				denied := !strings.Contains(may, methodLetters[method])
+				if got := rec.Code == http.StatusForbidden; got != denied {
+					t.Errorf("%s %s %s: status %d, want forbidden %v: %s", role, method, ra.pattern, rec.Code, denied, rec.Body.String())
+				}
+			}
+		}
+	}
+}
+
+func TestRecordAccess(t *testing.T) {
+	ts := newTestServer(t)
+	ts.user("user-sales-2", models.RoleSales)
+
+	now := time.Now()
+	org := models.Organization{ID: "org", Name: "Acme", CreatedAt: now, UpdatedAt: now}
+	if err := ts.store.SaveOrganization(org); err != nil {
+		t.Fatal(err)
+	}
+	contact := models.Contact{ID: "contact", OrganizationID: org.ID, FirstName: "Ada", CreatedAt: now, UpdatedAt: now}
+	if err := ts.store.SaveContact(contact); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []models.Deal{
+		{ID: "deal-private", Title: "Private", OwnerUserID: "user-sales-2"},
+		{ID: "deal-shared", Title: "Shared", OwnerUserID: "user-sales-2", Shared: true},
+	} {
+		d.OrganizationID, d.ContactID, d.Status, d.CreatedAt, d.UpdatedAt = org.ID, contact.ID, models.DealOpen, now, now
+		if err := ts.store.SaveDeal(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, p := range []models.Project{
+		{ID: "project-mine", DealID: "deal-shared", Name: "Mine", OwnerUserID: "user-developer"},
+		{ID: "project-other", DealID: "deal-private", Name: "Other", OwnerUserID: "user-project_manager"},
+	} {
+		p.Status, p.CreatedAt, p.UpdatedAt = models.ProjectActive, now, now
+		if err := ts.store.SaveProject(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	task := models.Task{ID: "task-other", ProjectID: "project-other", Title: "Hidden", Status: models.TaskTodo, CreatedAt: now, UpdatedAt: now}
+	if err := ts.store.SaveTask(task); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		user         string
+		method, path string
+		body         string
+		want         int
+	}{
+		{"developer deletes an organization", "user-developer", http.MethodDelete, "/api/organizations?id=org", "", http.StatusForbidden},
+		{"developer writes a payment", "user-developer", http.MethodPost, "/api/payments", `{"dealId":"deal-shared","amount":10}`, http.StatusForbidden},
+		{"developer writes settings", "user-developer", http.MethodPost, "/api/settings", `{}`, http.StatusForbidden},
+		{"sales reads another user's unshared deal", "user-sales", http.MethodGet, "/api/deals/deal-private/timeline", "", http.StatusNotFound},
+		{"sales reads a shared deal", "user-sales", http.MethodGet, "/api/deals/deal-shared/timeline", "", http.StatusOK},
+		{"owner reads their unshared deal", "user-sales-2", http.MethodGet, "/api/deals/deal-private/timeline", "", http.StatusOK},
+		{"sales pays on another user's unshared deal", "user-sales", http.MethodPost, "/api/payments", `{"dealId":"deal-private","amount":10}`, http.StatusNotFound},
+		{"sales lists another user's unshared deal's payments", "user-sales", http.MethodGet, "/api/payments?dealId=deal-private", "", http.StatusNotFound},
+		{"developer reads a board they work on", "user-developer", http.MethodGet, "/api/projects/project-mine/board", "", http.StatusOK},
+		{"developer reads another project's board", "user-developer", http.MethodGet, "/api/projects/project-other/board", "", http.StatusNotFound},
+		{"developer moves a hidden task into their project", "user-developer", http.MethodPost, "/api/tasks", `{"id":"task-other","projectId":"project-mine","title":"Mine now"}`, http.StatusNotFound},
+		{"developer reads a deal outside their projects", "user-developer", http.MethodGet, "/api/deals/deal-private/timeline", "", http.StatusNotFound},
+		{"project manager reads any deal", "user-project_manager", http.MethodGet, "/api/deals/deal-private/timeline", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := ts.do(tt.user, tt.method, tt.path, tt.body)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+
+	rec := ts.do("user-sales", http.MethodGet, "/api/deals", "")
+	if strings.Contains(rec.Body.String(), "deal-private") || !strings.Contains(rec.Body.String(), "deal-shared") {
+		t.Errorf("sales deal list: %s", rec.Body.String())
+	}
+	if moved, _, err := ts.store.FindTask("task-other"); err != nil {
+		t.Fatal(err)
+	} else if moved.ProjectID != "project-other" {
+		t.Errorf("hidden task moved to %s", moved.ProjectID)
+	}
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/models"
+)
+
+var projectRoles = []models.ProjectRole{models.ProjectLead, models.ProjectContributor, models.ProjectViewer}
+
+// projectRole returns userID's role on a project, "" when they are not a
+// member. The project's owner always counts as a lead.
+func (s *Server) projectRole(projectID, userID string) (models.ProjectRole, error) {
+	if userID == "" {
+		return "", nil
+	}
+	if owner, ok, err := s.store.OwnerOf("project", projectID); err != nil {
+		return "", err
+	} else if ok && owner == userID {
+		return models.ProjectLead, nil
+	}
+	m, ok, err := s.store.FindProjectMember(projectID, userID)
+	if err != nil || !ok {
+		return "", err
+	}
+	return m.Role, nil
+}
+
+// canWorkOn reports whether userID may be assigned tasks on the project.
+func (s *Server) canWorkOn(projectID, userID string) (bool, error) {
+	role, err := s.projectRole(projectID, userID)
+	return role == models.ProjectLead || role == models.ProjectContributor, err
+}
+
+// ensureProjectLead makes the owner of a project one of its leads.
+func (s *Server) ensureProjectLead(p models.Project) error {
+	if p.OwnerUserID == "" {
+		return nil
+	}
+	m, ok, err := s.store.FindProjectMember(p.ID, p.OwnerUserID)
+	if err != nil || (ok && m.Role == models.ProjectLead) {
+		return err
+	}
+	now := time.Now()
+	return s.store.SaveProjectMember(models.ProjectMember{ProjectID: p.ID, UserID: p.OwnerUserID, Role: models.ProjectLead, CreatedAt: now, UpdatedAt: now})
+}
+
+// notifyProjectMembers sends n to every member of a project but the user
+// who caused it.
+func (s *Server) notifyProjectMembers(projectID string, n models.Notification, by models.User) error {
+	members, err := s.store.LoadProjectMembers(projectID)
+	if err != nil {
+		return err
+	}
+	key := n.Key
+	for _, m := range members {
+		if m.UserID == by.ID {
+			continue
+		}
+		n.ID, n.UserID, n.Key = "", m.UserID, key+":"+m.UserID
+		if _, err := s.notify(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleProjectMembers serves /api/projects/{id}/members. Anyone who can see
+// the project can list its members; admins, project managers and the
+// project's leads can change them.
+func (s *Server) handleProjectMembers(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("id")
+	if !s.canSee(w, r, "project", projectID) {
+		return
+	}
+	if r.Method != http.MethodGet && !s.requireProjectLead(w, r, projectID, "members") {
+		return
+	}
+	user := mustAuth(r).User
+
+	switch r.Method {
+	case http.MethodGet:
+		members, err := s.store.LoadProjectMembers(projectID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, members)
+	case http.MethodPost:
+		var m models.ProjectMember
+		if err := readJSON(r, &m); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		m.ProjectID = projectID
+		m.UserID = strings.TrimSpace(m.UserID)
+		m.Role = models.ProjectRole(strings.ToLower(strings.TrimSpace(string(m.Role))))
+		if m.Role == "" {
+			m.Role = models.ProjectContributor
+		}
+		if !slices.Contains(projectRoles, m.Role) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("role must be lead, contributor or viewer"))
+			return
+		}
+		if _, ok, err := s.store.FindUserByID(m.UserID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		} else if !ok {
+			writeJSON(w, http.StatusBadRequest, errorResponse("userId not found"))
+			return
+		}
+		prev, existed, err := s.store.FindProjectMember(projectID, m.UserID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if m.Role == models.ProjectViewer && (!existed || prev.Role != models.ProjectViewer) {
+			if !s.noOpenTasks(w, projectID, m.UserID) {
+				return
+			}
+		}
+		now := time.Now()
+		m.CreatedAt, m.UpdatedAt = now, now
+		if existed {
+			m.CreatedAt = prev.CreatedAt
+		}
+		if err := s.store.SaveProjectMember(m); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if !existed && m.UserID != user.ID {
+			name := user.Name
+			if name == "" {
+				name = user.Username
+			}
+			projectName := ""
+			if projects, err := s.store.LoadProjects(); err == nil {
+				if i := slices.IndexFunc(projects, func(p models.Project) bool { return p.ID == projectID }); i >= 0 {
+					projectName = projects[i].Name
+				}
+			}
+			if _, err := s.notify(models.Notification{
+				UserID:     m.UserID,
+				Kind:       "project_member",
+				Title:      name + " added you to a project as " + string(m.Role),
+				Body:       projectName,
+				EntityType: "project",
+				EntityID:   projectID,
+				Key:        "project_member:" + projectID + ":" + m.UserID + ":" + strconv.FormatInt(now.UnixNano(), 10),
+			}); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, m)
+	case http.MethodDelete:
+		userIDs, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		for _, userID := range userIDs {
+			if !s.noOpenTasks(w, projectID, userID) {
+				return
+			}
+		}
+		for _, userID := range userIDs {
+			if err := s.store.DeleteProjectMember(projectID, userID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": userIDs})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// noOpenTasks writes a 400 and returns false when userID still has
+// unfinished tasks on the project, which would leave them assigned to
+// someone who may no longer work on it.
+func (s *Server) noOpenTasks(w http.ResponseWriter, projectID, userID string) bool {
+	tasks, err := s.store.LoadTasks()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	open := 0
+	for _, t := range tasks {
+		if t.ProjectID == projectID && t.OwnerUserID == userID && t.Status != models.TaskDone {
+			open++
+		}
+	}
+	if open > 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("reassign the member's "+strconv.Itoa(open)+" open task(s) first"))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"wemadeit/internal/models"
+)
+
+// quotationDocument is everything needed to render and send a quotation,
+// with the customer block taken from the organization's billing address.
+type quotationDocument struct {
+	Quotation models.Quotation       `json:"quotation"`
+	Items     []models.QuotationItem `json:"items"`
+	DealTitle string                 `json:"dealTitle"`
+	BillTo    billTo                 `json:"billTo"`
+	// To is the deal's decision maker, else its main contact; billing
+	// contacts are copied in. MailTo opens a message to them in the user's
+	// mail client.
+	To     []recipient `json:"to"`
+	Cc     []recipient `json:"cc"`
+	MailTo string      `json:"mailto"`
+}
+
+type recipient struct {
+	ContactID string                 `json:"contactId"`
+	Name      string                 `json:"name"`
+	Email     string                 `json:"email"`
+	Role      models.DealContactRole `json:"role,omitempty"`
+}
+
+type billTo struct {
+	OrganizationID string         `json:"organizationId"`
+	Name           string         `json:"name"`
+	TaxID          string         `json:"taxId"`
+	Email          string         `json:"email"`
+	Address        models.Address `json:"address"`
+}
+
+// billToFor builds the customer block of a document for an organization.
+func (s *Server) billToFor(orgID string) (billTo, error) {
+	orgs, err := s.store.LoadOrganizations()
+	if err != nil {
+		return billTo{}, err
+	}
+	addresses, err := s.store.LoadAddresses(orgID)
+	if err != nil {
+		return billTo{}, err
+	}
+	for _, o := range orgs {
+		if o.ID != orgID {
+			continue
+		}
+		email := o.BillingEmail
+		if email == "" {
+			email = o.Email
+		}
+		return billTo{
+			OrganizationID: o.ID,
+			Name:           o.Name,
+			TaxID:          o.TaxID,
+			Email:          email,
+			Address:        billingAddress(o, addresses),
+		}, nil
+	}
+	return billTo{OrganizationID: orgID}, nil
+}
+
+// quotationRecipients picks who a quotation for deal is sent to. Contacts
+// without an email address are left out.
+func (s *Server) quotationRecipients(deal models.Deal) (to, cc []recipient, err error) {
+	contacts, err := s.store.LoadContacts()
+	if err != nil {
+		return nil, nil, err
+	}
+	dealContacts, err := s.store.LoadDealContacts()
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]models.Contact, len(contacts))
+	for _, c := range contacts {
+		byID[c.ID] = c
+	}
+	seen := map[string]bool{}
+	add := func(list []recipient, contactID string, role models.DealContactRole) []recipient {
+		c, ok := byID[contactID]
+		if !ok || c.Email == "" || seen[c.ID] {
+			return list
+		}
+		seen[c.ID] = true
+		return append(list, recipient{
+			ContactID: c.ID,
+			Name:      strings.TrimSpace(c.FirstName + " " + c.LastName),
+			Email:     c.Email,
+			Role:      role,
+		})
+	}
+	to, cc = make([]recipient, 0), make([]recipient, 0)
+	for _, dc := range dealContacts[deal.ID] {
+		if dc.Role == models.DealContactDecisionMaker {
+			to = add(to, dc.ContactID, dc.Role)
+		}
+	}
+	if len(to) == 0 {
+		to = add(to, deal.ContactID, "")
+	}
+	for _, dc := range dealContacts[deal.ID] {
+		if dc.Role == models.DealContactBilling {
+			cc = add(cc, dc.ContactID, dc.Role)
+		}
+	}
+	return to, cc, nil
+}
+
+// handleQuotationDocument serves GET /api/quotations/document?id=.
+func (s *Server) handleQuotationDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	id := strings.TrimSpace(r.URL.Query().Get("id"))
+	quotations, err := s.store.LoadQuotationsFor(viewer(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	i := slices.IndexFunc(quotations, func(q models.Quotation) bool { return q.ID == id })
+	if id == "" || i < 0 {
+		writeJSON(w, http.StatusNotFound, errorResponse("quotation not found"))
+		return
+	}
+	doc := quotationDocument{Quotation: quotations[i], To: make([]recipient, 0), Cc: make([]recipient, 0)}
+	if doc.Items, err = s.store.LoadQuotationItemsByQuotation(id); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	deal, ok, err := s.store.FindDealByID(doc.Quotation.DealID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if ok {
+		doc.DealTitle = deal.Title
+		if doc.BillTo, err = s.billToFor(deal.OrganizationID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if doc.To, doc.Cc, err = s.quotationRecipients(deal); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+	}
+	doc.MailTo = quotationMailTo(doc)
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// quotationMailTo builds a mailto: link addressed to a document's
+// recipients, "" when it has none.
+func quotationMailTo(doc quotationDocument) string {
+	if len(doc.To) == 0 {
+		return ""
+	}
+	addresses := func(list []recipient) string {
+		out := make([]string, 0, len(list))
+		for _, rc := range list {
+			out = append(out, rc.Email)
+		}
+		return strings.Join(out, ",")
+	}
+	q := url.Values{}
+	if len(doc.Cc) > 0 {
+		q.Set("cc", addresses(doc.Cc))
+	}
+	q.Set("subject", strings.TrimSpace("Quotation "+strings.TrimSpace(doc.Quotation.Number+" "+doc.Quotation.Title)))
+	// mailto: wants %20 rather than + for spaces.
+	return "mailto:" + url.PathEscape(addresses(doc.To)) + "?" + strings.ReplaceAll(q.Encode(), "+", "%20")
+}
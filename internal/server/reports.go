@@ -0,0 +1,264 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+	"wemadeit/internal/reports"
+)
+
+// reportData is everything the reports aggregate over, loaded once per
+// request.
+type reportData struct {
+	payments []models.Payment
+	deals    []models.Deal
+	orgs     []models.Organization
+	currency string
+	convert  reports.Converter
+}
+
+// loadReportData loads the report inputs v may see, keeping only the deals
+// (and their payments) that carry every one of labelIDs, directly or
+// through their organization.
+func (s *Server) loadReportData(v db.Viewer, labelIDs []string) (reportData, error) {
+	var rd reportData
+	var err error
+	if rd.payments, err = s.store.LoadPaymentsFor(v); err != nil {
+		return rd, err
+	}
+	if rd.deals, err = s.store.LoadDealsFor(v); err != nil {
+		return rd, err
+	}
+	if rd.orgs, err = s.store.LoadOrganizations(); err != nil {
+		return rd, err
+	}
+	if len(labelIDs) > 0 {
+		dealTags, err := s.store.LoadTaggings("deal")
+		if err != nil {
+			return rd, err
+		}
+		orgTags, err := s.store.LoadTaggings("organization")
+		if err != nil {
+			return rd, err
+		}
+		kept := make(map[string]bool)
+		rd.deals = slices.DeleteFunc(rd.deals, func(d models.Deal) bool {
+			for _, l := range labelIDs {
+				if !slices.Contains(dealTags[d.ID], l) && !slices.Contains(orgTags[d.OrganizationID], l) {
+					return true
+				}
+			}
+			kept[d.ID] = true
+			return false
+		})
+		rd.payments = slices.DeleteFunc(rd.payments, func(p models.Payment) bool { return !kept[p.DealID] })
+	}
+	conv, currency, err := s.reportingConverter()
+	if err != nil {
+		return rd, err
+	}
+	rd.currency = currency
+	rd.convert = func(amount float64, from string, at time.Time) (float64, bool) {
+		if amount == 0 {
+			return 0, true
+		}
+		if strings.TrimSpace(from) == "" {
+			from = "EUR"
+		}
+		v, err := conv.Convert(amount, from, currency, at)
+		return v, err == nil
+	}
+	return rd, nil
+}
+
+func (s *Server) handleRevenueReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	q := r.URL.Query()
+	period, err := reports.ParsePeriod(q.Get("group"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	by, err := reports.ParseDimension(q.Get("by"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	from, to, err := reportRange(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	labels, ok := s.labelsFromQuery(w, r)
+	if !ok {
+		return
+	}
+	rd, err := s.loadReportData(viewer(r), labels)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	report := reports.Revenue(rd.payments, rd.deals, rd.orgs, reports.RevenueOptions{
+		Period:   period,
+		By:       by,
+		From:     from,
+		To:       to,
+		Currency: rd.currency,
+	})
+	writeReport(w, r, "revenue", report, report.Table())
+}
+
+func (s *Server) handleCashflowReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	q := r.URL.Query()
+	period, err := reports.ParsePeriod(q.Get("group"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	periods := 6
+	if v := strings.TrimSpace(q.Get("periods")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 60 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("periods must be between 1 and 60"))
+			return
+		}
+		periods = n
+	}
+	labels, ok := s.labelsFromQuery(w, r)
+	if !ok {
+		return
+	}
+	rd, err := s.loadReportData(viewer(r), labels)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	report := reports.Cashflow(rd.payments, rd.deals, reports.CashflowOptions{
+		Period:   period,
+		Periods:  periods,
+		Now:      time.Now(),
+		Currency: rd.currency,
+		Convert:  rd.convert,
+	})
+	writeReport(w, r, "cashflow", report, report.Table())
+}
+
+func (s *Server) handleMarginReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	from, to, err := reportRange(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	labels, ok := s.labelsFromQuery(w, r)
+	if !ok {
+		return
+	}
+	rd, err := s.loadReportData(viewer(r), labels)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	report := reports.Margin(rd.payments, rd.deals, rd.orgs, reports.MarginOptions{
+		From:     from,
+		To:       to,
+		Now:      time.Now(),
+		Currency: rd.currency,
+		Convert:  rd.convert,
+	})
+	writeReport(w, r, "margin", report, report.Table())
+}
+
+// writeReport answers with JSON, or CSV when ?format=csv is given.
+func writeReport(w http.ResponseWriter, r *http.Request, name string, report any, table reports.Table) {
+	switch strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format"))) {
+	case "", "json":
+		writeJSON(w, http.StatusOK, report)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		_ = table.WriteCSV(w)
+	default:
+		writeJSON(w, http.StatusBadRequest, errorResponse("format must be json or csv"))
+	}
+}
+
+// reportRange parses the optional from/to query dates (YYYY-MM-DD, both
+// inclusive) into a half-open range.
+func reportRange(r *http.Request) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if v := strings.TrimSpace(r.URL.Query().Get("from")); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("from must be YYYY-MM-DD")
+		}
+		from = &t
+	}
+	if v := strings.TrimSpace(r.URL.Query().Get("to")); v != "" {
+		t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return nil, nil, errors.New("to must be YYYY-MM-DD")
+		}
+		t = t.AddDate(0, 0, 1)
+		to = &t
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+func (s *Server) handlePipelineReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	labels, ok := s.labelsFromQuery(w, r)
+	if !ok {
+		return
+	}
+	rd, err := s.loadReportData(viewer(r), labels)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	stages, err := s.store.LoadPipelineStages()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	history, err := s.store.LoadHistoryByField("deal", "pipelineStageId")
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	report := reports.Pipeline(rd.deals, stages, history, reports.PipelineOptions{
+		PipelineID: strings.TrimSpace(r.URL.Query().Get("pipelineId")),
+		Now:        time.Now(),
+		Currency:   rd.currency,
+		Convert:    rd.convert,
+	})
+	table, ok := report.Table(strings.TrimSpace(r.URL.Query().Get("section")))
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, errorResponse("section must be stages, months, conversions, sources or lost_reasons"))
+		return
+	}
+	writeReport(w, r, "pipeline", report, table)
+}
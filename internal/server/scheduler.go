@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type job struct {
+	name string
+	run  func(now time.Time) error
+}
+
+func (s *Server) jobs() []job {
+	return []job{
+		{name: "billing plans", run: func(now time.Time) error {
+			_, err := s.generateBillingPayments(now)
+			return err
+		}},
+		{name: "domain refresh", run: s.refreshStaleDomains},
+		{name: "domain renewals", run: func(now time.Time) error {
+			_, err := s.generateDomainRenewals(now)
+			return err
+		}},
+		{name: "follow-up digests", run: s.sendFollowUpDigests},
+		{name: "meeting import", run: s.importMeetingsFromDir},
+		{name: "maildir", run: s.pollMaildir},
+		{name: "blob sweep", run: func(time.Time) error {
+			return s.sweepBlobs(context.Background())
+		}},
+		{name: "reporting amounts", run: func(time.Time) error {
+			_, err := s.recomputeReportingAmounts()
+			return err
+		}},
+	}
+}
+
+// RunScheduler runs the background jobs once at startup and then every
+// interval until ctx is cancelled. Jobs are idempotent, so missed or repeated
+// ticks are harmless.
+func (s *Server) RunScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.runJobs(time.Now())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runJobs(now)
+		}
+	}
+}
+
+func (s *Server) runJobs(now time.Time) {
+	for _, j := range s.jobs() {
+		if err := j.run(now); err != nil {
+			fmt.Println("Scheduler error:", j.name+":", err)
+		}
+	}
+}
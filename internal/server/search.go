@@ -0,0 +1,81 @@
+package server
+
+import (
+	"html"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+// handleSearch runs a full-text query over organizations, contacts, deals,
+// interactions, quotations and tasks: ?q= (words, word* prefixes and
+// "quoted phrases"), optionally ?types=deal,contact and ?limit=.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse("q is required"))
+		return
+	}
+	limit := 20
+	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 100 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("limit must be between 1 and 100"))
+			return
+		}
+		limit = n
+	}
+	var types []string
+	if v := strings.TrimSpace(r.URL.Query().Get("types")); v != "" {
+		known := db.SearchTypes()
+		for _, t := range strings.Split(v, ",") {
+			t = strings.TrimSpace(t)
+			if t == "" {
+				continue
+			}
+			if !slices.Contains(known, t) {
+				writeJSON(w, http.StatusBadRequest, errorResponse("types must be among "+strings.Join(known, ", ")))
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	// Types the caller's role may not read are never searched.
+	if len(types) == 0 {
+		types = db.SearchTypes()
+	}
+	role := mustAuth(r).User.Role
+	types = slices.DeleteFunc(types, func(t string) bool { return !canRead(role, t) })
+	if len(types) == 0 {
+		writeJSON(w, http.StatusOK, []models.SearchHit{})
+		return
+	}
+
+	hits, err := s.store.Search(viewer(r), q, types, limit)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	for i := range hits {
+		hits[i].Snippet = markSnippet(hits[i].Snippet)
+	}
+	writeJSON(w, http.StatusOK, hits)
+}
+
+// markSnippet escapes a search snippet and turns its match markers into
+// <mark> elements.
+func markSnippet(snippet string) string {
+	return strings.NewReplacer(
+		db.SearchMatchStart, "<mark>",
+		db.SearchMatchEnd, "</mark>",
+	).Replace(html.EscapeString(strings.TrimSpace(snippet)))
+}
@@ -12,8 +12,11 @@ import (
 	"time"
 
 	"wemadeit/internal/auth"
+	"wemadeit/internal/blob"
 	"wemadeit/internal/config"
 	"wemadeit/internal/db"
+	"wemadeit/internal/domaininfo"
+	"wemadeit/internal/fx"
 	"wemadeit/internal/models"
 )
 
@@ -22,6 +25,12 @@ type Server struct {
 	mu         sync.RWMutex
 	settings   config.Settings
 	configPath string
+	lookup     domaininfo.Lookup
+	blobs      blob.Store
+	// blobMu orders blob uploads against the sweep of unreferenced blobs.
+	blobMu sync.Mutex
+	// routes lists the authenticated routes and their resources.
+	routes []routePermission
 }
 
 type ctxKey int
@@ -48,23 +57,89 @@ func (s *Server) Handler() http.Handler {
 	// Auth (required for all other endpoints).
 	mux.HandleFunc("/api/login", s.handleLogin)
 
-	mux.HandleFunc("/api/me", s.requireAuth(s.handleMe))
-	mux.HandleFunc("/api/logout", s.requireAuth(s.handleLogout))
-
-	mux.HandleFunc("/api/state", s.requireAuth(s.handleState))
-
-	mux.HandleFunc("/api/organizations", s.requireAuth(s.handleOrganizations))
-	mux.HandleFunc("/api/contacts", s.requireAuth(s.handleContacts))
-	mux.HandleFunc("/api/deals", s.requireAuth(s.handleDeals))
-	mux.HandleFunc("/api/payments", s.requireAuth(s.handlePayments))
-	mux.HandleFunc("/api/projects", s.requireAuth(s.handleProjects))
-	mux.HandleFunc("/api/tasks", s.requireAuth(s.handleTasks))
-	mux.HandleFunc("/api/users", s.requireAuth(s.handleUsers))
-	mux.HandleFunc("/api/quotations", s.requireAuth(s.handleQuotations))
-	mux.HandleFunc("/api/quotation_items", s.requireAuth(s.handleQuotationItems))
-	mux.HandleFunc("/api/interactions", s.requireAuth(s.handleInteractions))
+	// handle registers an authenticated route, guarded by the permission
+	// matrix for resource.
+	s.routes = nil
+	handle := func(pattern, resource string, h http.HandlerFunc) {
+		mux.HandleFunc(pattern, s.requireAuth(s.authorize(resource, h)))
+		s.routes = append(s.routes, routePermission{Pattern: pattern, Resource: resource})
+	}
 
-	mux.HandleFunc("/api/settings", s.requireAuth(s.handleSettings))
+	handle("/api/me", "account", s.handleMe)
+	handle("/api/logout", "account", s.handleLogout)
+
+	handle("/api/state", "account", s.handleState)
+	handle("/api/dashboard", "account", s.handleDashboard)
+
+	handle("/api/organizations", "organizations", s.handleOrganizations)
+	handle("/api/organizations/duplicates", "organizations", s.handleOrganizationDuplicates)
+	handle("/api/organizations/merge", "organizations", s.handleOrganizationMerge)
+	handle("/api/organizations/import", "organizations", s.handleOrganizationImport)
+	handle("/api/organizations/export", "organizations", s.handleOrganizationExport)
+	handle("/api/contacts", "contacts", s.handleContacts)
+	handle("/api/contacts/duplicates", "contacts", s.handleContactDuplicates)
+	handle("/api/contacts/merge", "contacts", s.handleContactMerge)
+	handle("/api/contacts/import", "contacts", s.handleContactImport)
+	handle("/api/contacts/export", "contacts", s.handleContactExport)
+	handle("/api/deals", "deals", s.handleDeals)
+	handle("/api/payments", "payments", s.handlePayments)
+	handle("/api/payments/summary", "payments", s.handlePaymentsSummary)
+	handle("/api/projects", "projects", s.handleProjects)
+	handle("/api/projects/{id}/members", "project_members", s.handleProjectMembers)
+	handle("/api/projects/{id}/board", "tasks", s.handleTaskBoard)
+	handle("/api/projects/{id}/board/columns", "task_boards", s.handleTaskColumns)
+	handle("/api/projects/{id}/board/columns/reorder", "task_boards", s.handleTaskColumnsReorder)
+	handle("/api/tasks", "tasks", s.handleTasks)
+	handle("/api/tasks/move", "tasks", s.handleTaskMove)
+	handle("/api/users", "users", s.handleUsers)
+	handle("/api/quotations", "quotations", s.handleQuotations)
+	handle("/api/quotations/document", "quotations", s.handleQuotationDocument)
+	handle("/api/quotation_items", "quotations", s.handleQuotationItems)
+	handle("/api/interactions", "interactions", s.handleInteractions)
+	handle("/api/interactions/import_ics", "interactions", s.handleMeetingImport)
+	handle("/api/interactions/import_email", "interactions", s.handleEmailImport)
+	handle("/api/follow_ups", "follow_ups", s.handleFollowUps)
+	handle("/api/follow_ups/complete", "follow_ups", s.handleFollowUpComplete)
+	handle("/api/follow_ups/snooze", "follow_ups", s.handleFollowUpSnooze)
+	handle("/api/follow_ups/reschedule", "follow_ups", s.handleFollowUpReschedule)
+	handle("/api/notifications", "account", s.handleNotifications)
+	handle("/api/calendar_feed", "account", s.handleCalendarFeed)
+	handle("/api/calendar_feed/rotate", "account", s.handleCalendarFeedRotate)
+	// Calendar apps cannot log in; the token in the path authenticates.
+	mux.HandleFunc("GET /api/calendar/{file}", s.handleCalendar)
+
+	handle("/api/bank/import", "bank", s.handleBankImport)
+	handle("/api/bank/transactions", "bank", s.handleBankTransactions)
+	handle("/api/bank/reconcile", "bank", s.handleBankReconcile)
+	handle("/api/billing_plans", "billing_plans", s.handleBillingPlans)
+	handle("/api/billing_plans/run", "billing_plans", s.handleBillingPlansRun)
+	handle("/api/domains", "domains", s.handleDomains)
+	handle("/api/domains/expiring", "domains", s.handleDomainsExpiring)
+	handle("/api/domains/refresh", "domains", s.handleDomainsRefresh)
+	handle("/api/exchange_rates", "exchange_rates", s.handleExchangeRates)
+	handle("/api/exchange_rates/import", "exchange_rates", s.handleExchangeRatesImport)
+	handle("/api/exchange_rates/recompute", "exchange_rates", s.handleExchangeRatesRecompute)
+	handle("/api/reports/revenue", "reports", s.handleRevenueReport)
+	handle("/api/reports/cashflow", "reports", s.handleCashflowReport)
+	handle("/api/reports/margin", "reports", s.handleMarginReport)
+	handle("/api/reports/pipeline", "reports", s.handlePipelineReport)
+
+	handle("/api/labels", "labels", s.handleLabels)
+	handle("/api/labels/assign", "labels", s.handleLabelsAssign)
+	handle("/api/notes", "notes", s.handleNotes)
+	handle("/api/notes/revisions", "notes", s.handleNoteRevisions)
+	handle("/api/addresses", "addresses", s.handleAddresses)
+	handle("/api/attachments", "attachments", s.handleAttachments)
+	handle("/api/attachments/download", "attachments", s.handleAttachmentDownload)
+	handle("/api/search", "account", s.handleSearch)
+	handle("GET /api/organizations/{id}/timeline", "organizations", s.handleTimeline("organization"))
+	handle("GET /api/organizations/{id}/group", "organizations", s.handleOrganizationGroup)
+	handle("GET /api/contacts/{id}/timeline", "contacts", s.handleTimeline("contact"))
+	handle("GET /api/deals/{id}/timeline", "deals", s.handleTimeline("deal"))
+	handle("/api/custom_fields", "custom_fields", s.handleCustomFields)
+	handle("/api/custom_fields/reorder", "custom_fields", s.handleCustomFieldsReorder)
+	handle("/api/settings", "settings", s.handleSettings)
+	handle("/api/permissions", "permissions", s.handlePermissions)
 	return withCORS(mux)
 }
 
@@ -249,22 +324,22 @@ func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	deals, err := s.store.LoadDeals()
+	deals, err := s.store.LoadDealsFor(viewer(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	payments, err := s.store.LoadPayments()
+	payments, err := s.store.LoadPaymentsFor(viewer(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	projects, err := s.store.LoadProjects()
+	projects, err := s.store.LoadProjectsFor(viewer(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	tasks, err := s.store.LoadTasks()
+	tasks, err := s.store.LoadTasksFor(viewer(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
@@ -280,17 +355,76 @@ func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	quotations, err := s.store.LoadQuotations()
+	quotations, err := s.store.LoadQuotationsFor(viewer(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	quotationItems, err := s.store.LoadQuotationItems()
+	quotationItems, err := s.store.LoadQuotationItemsFor(viewer(r))
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
 	}
-	interactions, err := s.store.LoadInteractions()
+	interactions, err := s.store.LoadInteractionsFor(viewer(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	dealContacts, err := s.store.LoadDealContacts()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	// The overview leaves out what the user's role may not read.
+	role := mustAuth(r).User.Role
+	if !allowed(role, "payments", actionRead) {
+		payments = make([]models.Payment, 0)
+	}
+	if !allowed(role, "quotations", actionRead) {
+		quotations, quotationItems = make([]models.Quotation, 0), make([]models.QuotationItem, 0)
+	}
+
+	for entityType, attach := range map[string]func(entityAttrs){
+		"organization": func(a entityAttrs) {
+			for i := range orgs {
+				orgs[i].CustomFields, orgs[i].LabelIDs = a.customFields(orgs[i].ID), a.labelIDs(orgs[i].ID)
+			}
+		},
+		"contact": func(a entityAttrs) {
+			for i := range contacts {
+				contacts[i].CustomFields, contacts[i].LabelIDs = a.customFields(contacts[i].ID), a.labelIDs(contacts[i].ID)
+			}
+		},
+		"deal": func(a entityAttrs) {
+			for i := range deals {
+				deals[i].CustomFields, deals[i].LabelIDs = a.customFields(deals[i].ID), a.labelIDs(deals[i].ID)
+				deals[i].Contacts = dealContacts[deals[i].ID]
+			}
+		},
+		"project": func(a entityAttrs) {
+			for i := range projects {
+				projects[i].CustomFields, projects[i].LabelIDs = a.customFields(projects[i].ID), a.labelIDs(projects[i].ID)
+			}
+		},
+		"task": func(a entityAttrs) {
+			for i := range tasks {
+				tasks[i].LabelIDs = a.labelIDs(tasks[i].ID)
+			}
+		},
+	} {
+		attrs, err := s.loadEntityAttrs(entityType)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		attach(attrs)
+	}
+	labels, err := s.store.LoadLabels()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	addresses, err := s.store.LoadAddresses("")
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 		return
@@ -298,6 +432,7 @@ func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"organizations":  orgs,
+		"addresses":      addresses,
 		"contacts":       contacts,
 		"deals":          deals,
 		"payments":       payments,
@@ -308,6 +443,7 @@ func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
 		"quotationItems": quotationItems,
 		"interactions":   interactions,
 		"users":          users,
+		"labels":         labels,
 	})
 }
 
@@ -319,7 +455,23 @@ func (s *Server) handleOrganizations(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
-		writeJSON(w, http.StatusOK, orgs)
+		attrs, ok := s.listFilter(w, r, "organization")
+		if !ok {
+			return
+		}
+		_, byParent := r.URL.Query()["parentId"]
+		parentID := strings.TrimSpace(r.URL.Query().Get("parentId"))
+		out := make([]models.Organization, 0, len(orgs))
+		for _, o := range orgs {
+			o.CustomFields, o.LabelIDs = attrs.customFields(o.ID), attrs.labelIDs(o.ID)
+			if byParent && o.ParentID != parentID {
+				continue
+			}
+			if attrs.match(o.ID) {
+				out = append(out, o)
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
 	case http.MethodPost:
 		var org models.Organization
 		if err := readJSON(r, &org); err != nil {
@@ -327,7 +479,8 @@ func (s *Server) handleOrganizations(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		now := time.Now()
-		if org.ID == "" {
+		isNew := org.ID == ""
+		if isNew {
 			org.ID = newID()
 		}
 		if org.CreatedAt.IsZero() {
@@ -338,10 +491,44 @@ func (s *Server) handleOrganizations(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("name is required"))
 			return
 		}
+		org.ParentID = strings.TrimSpace(org.ParentID)
+		if !s.validParentOrganization(w, org) {
+			return
+		}
+		if !s.validLabelIDs(w, org.LabelIDs) {
+			return
+		}
+		custom, ok := s.customValuesFromRequest(w, "organization", org.ID, isNew, org.CustomFields)
+		if !ok {
+			return
+		}
+		prevOwner, ok := s.resolveOwner(w, r, "organization", org.ID, &org.OwnerUserID)
+		if !ok {
+			return
+		}
 		if err := s.store.SaveOrganization(org); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		if err := s.recordHistory("organization", org.ID, mustAuth(r).User.ID, fieldChange{field: "ownerUserId", oldValue: prevOwner, newValue: org.OwnerUserID}); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.notifyAssigned("organization", org.ID, org.Name, prevOwner, org.OwnerUserID, mustAuth(r).User); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.saveCustomValues("organization", org.ID, mustAuth(r).User.ID, custom); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		org.CustomFields = custom.next
+		labelIDs, err := s.saveEntityLabels("organization", org.ID, org.LabelIDs)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		org.LabelIDs = labelIDs
 		writeJSON(w, http.StatusOK, org)
 	case http.MethodDelete:
 		ids, err := deleteIDsFromRequest(r)
@@ -367,9 +554,8 @@ func (s *Server) handleOrganizations(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleContacts(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		contacts, err := s.store.LoadContacts()
-		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		contacts, _, ok := s.filteredContacts(w, r)
+		if !ok {
 			return
 		}
 		writeJSON(w, http.StatusOK, contacts)
@@ -380,7 +566,8 @@ func (s *Server) handleContacts(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		now := time.Now()
-		if c.ID == "" {
+		isNew := c.ID == ""
+		if isNew {
 			c.ID = newID()
 		}
 		if c.CreatedAt.IsZero() {
@@ -392,10 +579,28 @@ func (s *Server) handleContacts(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("organizationId is required"))
 			return
 		}
+		if !s.validLabelIDs(w, c.LabelIDs) {
+			return
+		}
+		custom, ok := s.customValuesFromRequest(w, "contact", c.ID, isNew, c.CustomFields)
+		if !ok {
+			return
+		}
 		if err := s.store.SaveContact(c); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		if err := s.saveCustomValues("contact", c.ID, mustAuth(r).User.ID, custom); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		c.CustomFields = custom.next
+		labelIDs, err := s.saveEntityLabels("contact", c.ID, c.LabelIDs)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		c.LabelIDs = labelIDs
 		writeJSON(w, http.StatusOK, c)
 	case http.MethodDelete:
 		ids, err := deleteIDsFromRequest(r)
@@ -421,12 +626,33 @@ func (s *Server) handleContacts(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleDeals(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		deals, err := s.store.LoadDeals()
+		deals, err := s.store.LoadDealsFor(viewer(r))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		attrs, ok := s.listFilter(w, r, "deal")
+		if !ok {
+			return
+		}
+		dealContacts, err := s.store.LoadDealContacts()
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
-		writeJSON(w, http.StatusOK, deals)
+		contactID := strings.TrimSpace(r.URL.Query().Get("contactId"))
+		out := make([]models.Deal, 0, len(deals))
+		for _, d := range deals {
+			d.CustomFields, d.LabelIDs = attrs.customFields(d.ID), attrs.labelIDs(d.ID)
+			d.Contacts = dealContacts[d.ID]
+			if contactID != "" && !dealInvolves(d, contactID) {
+				continue
+			}
+			if attrs.match(d.ID) {
+				out = append(out, d)
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
 	case http.MethodPost:
 		var d models.Deal
 		if err := readJSON(r, &d); err != nil {
@@ -450,8 +676,7 @@ func (s *Server) handleDeals(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("organizationId is required"))
 			return
 		}
-		if d.ContactID == "" {
-			writeJSON(w, http.StatusBadRequest, errorResponse("contactId is required"))
+		if !s.validDealContacts(w, &d) {
 			return
 		}
 		if d.Currency == "" {
@@ -477,10 +702,68 @@ func (s *Server) handleDeals(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		prev, existed, err := s.store.FindDealByID(d.ID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if existed && !s.canSee(w, r, "deal", d.ID) {
+			return
+		}
+		if _, ok := s.resolveOwner(w, r, "deal", d.ID, &d.OwnerUserID); !ok {
+			return
+		}
+		if !s.validLabelIDs(w, d.LabelIDs) {
+			return
+		}
+		custom, ok := s.customValuesFromRequest(w, "deal", d.ID, !existed, d.CustomFields)
+		if !ok {
+			return
+		}
+
 		if err := s.store.SaveDeal(d); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		var prevDeal *models.Deal
+		if existed {
+			prevDeal = &prev
+		}
+		if err := s.recordHistory("deal", d.ID, mustAuth(r).User.ID, dealChanges(prevDeal, d)...); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.notifyAssigned("deal", d.ID, d.Title, prev.OwnerUserID, d.OwnerUserID, mustAuth(r).User); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.saveCustomValues("deal", d.ID, mustAuth(r).User.ID, custom); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		d.CustomFields = custom.next
+		labelIDs, err := s.saveEntityLabels("deal", d.ID, d.LabelIDs)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		d.LabelIDs = labelIDs
+		if d.Contacts != nil {
+			if err := s.store.SetDealContacts(d.ID, d.Contacts); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		dealContacts, err := s.store.LoadDealContacts()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		d.Contacts = dealContacts[d.ID]
+		if err := s.syncDealDomain(d); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
 		writeJSON(w, http.StatusOK, d)
 	case http.MethodDelete:
 		ids, err := deleteIDsFromRequest(r)
@@ -492,6 +775,9 @@ func (s *Server) handleDeals(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSee(w, r, "deal", id) {
+				return
+			}
 			if err := s.store.DeleteDeal(id); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
@@ -514,9 +800,12 @@ func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request) {
 		var payments []models.Payment
 		var err error
 		if dealID != "" {
+			if !s.canSee(w, r, "deal", dealID) {
+				return
+			}
 			payments, err = s.store.LoadPaymentsByDeal(dealID)
 		} else {
-			payments, err = s.store.LoadPayments()
+			payments, err = s.store.LoadPaymentsFor(viewer(r))
 		}
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
@@ -543,6 +832,9 @@ func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("dealId is required"))
 			return
 		}
+		if !s.canSeeStoredDeal(w, r, "payment", p.ID) || !s.canSee(w, r, "deal", p.DealID) {
+			return
+		}
 		if strings.TrimSpace(p.Currency) == "" {
 			p.Currency = "EUR"
 		}
@@ -577,6 +869,13 @@ func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		conv, reporting, err := s.reportingConverter()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		applyReporting(conv, reporting, &p)
+
 		if err := s.store.SavePayment(p); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
@@ -592,6 +891,9 @@ func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSeeStoredDeal(w, r, "payment", id) {
+				return
+			}
 			if err := s.store.DeletePayment(id); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
@@ -606,12 +908,23 @@ func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		projects, err := s.store.LoadProjects()
+		projects, err := s.store.LoadProjectsFor(viewer(r))
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
-		writeJSON(w, http.StatusOK, projects)
+		attrs, ok := s.listFilter(w, r, "project")
+		if !ok {
+			return
+		}
+		out := make([]models.Project, 0, len(projects))
+		for _, p := range projects {
+			p.CustomFields, p.LabelIDs = attrs.customFields(p.ID), attrs.labelIDs(p.ID)
+			if attrs.match(p.ID) {
+				out = append(out, p)
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
 	case http.MethodPost:
 		var p models.Project
 		if err := readJSON(r, &p); err != nil {
@@ -619,7 +932,8 @@ func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		now := time.Now()
-		if p.ID == "" {
+		isNew := p.ID == ""
+		if isNew {
 			p.ID = newID()
 		}
 		if p.CreatedAt.IsZero() {
@@ -633,7 +947,7 @@ func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Project name is derived from its Deal to keep projects lightweight.
-		deals, err := s.store.LoadDeals()
+		deals, err := s.store.LoadDealsFor(viewer(r))
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
@@ -658,11 +972,69 @@ func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 		if p.Status == "" {
 			p.Status = models.ProjectActive
 		}
+		if !s.validLabelIDs(w, p.LabelIDs) {
+			return
+		}
+		custom, ok := s.customValuesFromRequest(w, "project", p.ID, isNew, p.CustomFields)
+		if !ok {
+			return
+		}
+
+		prevStatus, err := s.projectStatus(p.ID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if prevStatus != "" && !s.canSee(w, r, "project", p.ID) {
+			return
+		}
+		prevOwner, ok := s.resolveOwner(w, r, "project", p.ID, &p.OwnerUserID)
+		if !ok {
+			return
+		}
 
 		if err := s.store.SaveProject(p); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		if err := s.recordHistory("project", p.ID, mustAuth(r).User.ID,
+			fieldChange{field: "status", oldValue: prevStatus, newValue: string(p.Status)},
+			fieldChange{field: "ownerUserId", oldValue: prevOwner, newValue: p.OwnerUserID},
+		); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.ensureProjectLead(p); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.notifyAssigned("project", p.ID, p.Name, prevOwner, p.OwnerUserID, mustAuth(r).User); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if prevStatus != "" && prevStatus != string(p.Status) {
+			if err := s.notifyProjectMembers(p.ID, models.Notification{
+				Kind:       "project_status",
+				Title:      p.Name + " is now " + string(p.Status),
+				EntityType: "project",
+				EntityID:   p.ID,
+				Key:        "project_status:" + p.ID + ":" + now.Format(time.RFC3339Nano),
+			}, mustAuth(r).User); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		if err := s.saveCustomValues("project", p.ID, mustAuth(r).User.ID, custom); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		p.CustomFields = custom.next
+		labelIDs, err := s.saveEntityLabels("project", p.ID, p.LabelIDs)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		p.LabelIDs = labelIDs
 		writeJSON(w, http.StatusOK, p)
 	case http.MethodDelete:
 		ids, err := deleteIDsFromRequest(r)
@@ -674,6 +1046,9 @@ func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSee(w, r, "project", id) {
+				return
+			}
 			if err := s.store.DeleteProject(id); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
@@ -688,12 +1063,23 @@ func (s *Server) handleProjects(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		tasks, err := s.store.LoadTasks()
+		tasks, err := s.store.LoadTasksFor(viewer(r))
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
-		writeJSON(w, http.StatusOK, tasks)
+		attrs, ok := s.listFilter(w, r, "task")
+		if !ok {
+			return
+		}
+		out := make([]models.Task, 0, len(tasks))
+		for _, t := range tasks {
+			t.LabelIDs = attrs.labelIDs(t.ID)
+			if attrs.match(t.ID) {
+				out = append(out, t)
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
 	case http.MethodPost:
 		var t models.Task
 		if err := readJSON(r, &t); err != nil {
@@ -718,28 +1104,88 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("projectId is required"))
 			return
 		}
+		prev, existed, err := s.store.FindTask(t.ID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if existed && !s.canSee(w, r, "task", t.ID) {
+			return
+		}
+		if !s.canSee(w, r, "project", t.ProjectID) {
+			return
+		}
+		user := mustAuth(r).User
+		if !viewer(r).SeesAll() {
+			// Moving a task to another project needs a place on both.
+			projectIDs := []string{t.ProjectID}
+			if existed && prev.ProjectID != t.ProjectID {
+				projectIDs = append(projectIDs, prev.ProjectID)
+			}
+			for _, projectID := range projectIDs {
+				if ok, err := s.canWorkOn(projectID, user.ID); err != nil {
+					writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+					return
+				} else if !ok {
+					writeJSON(w, http.StatusForbidden, errorResponse("only the project's leads and contributors can edit its tasks"))
+					return
+				}
+			}
+		}
 		if t.Status == "" {
 			t.Status = models.TaskTodo
 		}
-		if strings.TrimSpace(t.OwnerUserID) == "" && isNew {
-			t.OwnerUserID = mustAuth(r).User.ID
-		}
-		if strings.TrimSpace(t.OwnerUserID) != "" {
-			_, ok, err := s.store.FindUserByID(t.OwnerUserID)
-			if err != nil {
+		t.OwnerUserID = strings.TrimSpace(t.OwnerUserID)
+		if t.OwnerUserID == "" && isNew {
+			// New tasks go to their creator when they work on the project.
+			if ok, err := s.canWorkOn(t.ProjectID, user.ID); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
+			} else if ok {
+				t.OwnerUserID = user.ID
 			}
-			if !ok {
-				writeJSON(w, http.StatusBadRequest, errorResponse("ownerUserId not found"))
+		}
+		prevOwner := prev.OwnerUserID
+		if t.OwnerUserID != "" && t.OwnerUserID != prevOwner {
+			if ok, err := s.canWorkOn(t.ProjectID, t.OwnerUserID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			} else if !ok {
+				writeJSON(w, http.StatusBadRequest, errorResponse("ownerUserId must be a lead or contributor on the project"))
 				return
 			}
 		}
+		if !s.validLabelIDs(w, t.LabelIDs) {
+			return
+		}
+		// Columns and positions change through /api/tasks/move.
+		if !s.placeTask(w, &t, prev, existed) {
+			return
+		}
 
-		if err := s.store.SaveTask(t); err != nil {
+		save := s.store.SaveTask
+		if t.ColumnID != "" && (!existed || t.ColumnID != prev.ColumnID) {
+			// The WIP limit of the column the task enters is checked again
+			// as it is written, in case another save took the last place.
+			save = s.store.SaveTaskIntoColumn
+		}
+		if err := save(t); errors.Is(err, db.ErrColumnFull) {
+			writeJSON(w, http.StatusConflict, errorResponse(err.Error()))
+			return
+		} else if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if err := s.notifyAssigned("task", t.ID, t.Title, prevOwner, t.OwnerUserID, user); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		labelIDs, err := s.saveEntityLabels("task", t.ID, t.LabelIDs)
+		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		t.LabelIDs = labelIDs
 		writeJSON(w, http.StatusOK, t)
 	case http.MethodDelete:
 		ids, err := deleteIDsFromRequest(r)
@@ -751,6 +1197,9 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSee(w, r, "task", id) {
+				return
+			}
 			if err := s.store.DeleteTask(id); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
@@ -762,13 +1211,8 @@ func (s *Server) handleTasks(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleUsers is admin-only through the permission matrix.
 func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
-	ac := mustAuth(r)
-	if ac.User.Role != models.RoleAdmin {
-		writeJSON(w, http.StatusForbidden, errorResponse("forbidden"))
-		return
-	}
-
 	switch r.Method {
 	case http.MethodGet:
 		users, err := s.store.LoadUsers()
@@ -948,7 +1392,7 @@ func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleQuotations(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		quotes, err := s.store.LoadQuotations()
+		quotes, err := s.store.LoadQuotationsFor(viewer(r))
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
@@ -976,6 +1420,9 @@ func (s *Server) handleQuotations(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("dealId is required"))
 			return
 		}
+		if !s.canSeeStoredDeal(w, r, "quotation", q.ID) || !s.canSee(w, r, "deal", q.DealID) {
+			return
+		}
 		if strings.TrimSpace(q.Title) == "" {
 			writeJSON(w, http.StatusBadRequest, errorResponse("title is required"))
 			return
@@ -1006,10 +1453,20 @@ func (s *Server) handleQuotations(w http.ResponseWriter, r *http.Request) {
 			q.PublicToken = tok
 		}
 
+		prevStatus, err := s.quotationStatus(q.ID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+
 		if err := s.store.SaveQuotation(q); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		if err := s.recordHistory("quotation", q.ID, mustAuth(r).User.ID, fieldChange{field: "status", oldValue: prevStatus, newValue: string(q.Status)}); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
 		_ = s.store.RecalcQuotationTotals(q.ID)
 		writeJSON(w, http.StatusOK, q)
 	case http.MethodDelete:
@@ -1022,6 +1479,9 @@ func (s *Server) handleQuotations(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSeeStoredDeal(w, r, "quotation", id) {
+				return
+			}
 			if err := s.store.DeleteQuotation(id); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
@@ -1036,7 +1496,7 @@ func (s *Server) handleQuotations(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleQuotationItems(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		items, err := s.store.LoadQuotationItems()
+		items, err := s.store.LoadQuotationItemsFor(viewer(r))
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
@@ -1061,6 +1521,9 @@ func (s *Server) handleQuotationItems(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, errorResponse("quotationId is required"))
 			return
 		}
+		if !s.canSeeStoredDeal(w, r, "quotation_item", it.ID) || !s.canSee(w, r, "quotation", it.QuotationID) {
+			return
+		}
 		if strings.TrimSpace(it.Name) == "" {
 			writeJSON(w, http.StatusBadRequest, errorResponse("name is required"))
 			return
@@ -1097,6 +1560,9 @@ func (s *Server) handleQuotationItems(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSeeStoredDeal(w, r, "quotation_item", id) {
+				return
+			}
 			qid, err := s.store.DeleteQuotationItem(id)
 			if err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
@@ -1118,11 +1584,20 @@ func (s *Server) handleQuotationItems(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleInteractions(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		interactions, err := s.store.LoadInteractions()
+		interactions, err := s.store.LoadInteractionsFor(viewer(r))
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		if thread := strings.TrimSpace(r.URL.Query().Get("threadId")); thread != "" {
+			filtered := make([]models.Interaction, 0)
+			for _, i := range interactions {
+				if i.ThreadID == thread {
+					filtered = append(filtered, i)
+				}
+			}
+			interactions = filtered
+		}
 		writeJSON(w, http.StatusOK, interactions)
 	case http.MethodPost:
 		var i models.Interaction
@@ -1155,6 +1630,12 @@ func (s *Server) handleInteractions(w http.ResponseWriter, r *http.Request) {
 		if strings.TrimSpace(i.TranscriptionStatus) == "" {
 			i.TranscriptionStatus = "pending"
 		}
+		if !s.canSeeStoredDeal(w, r, "interaction", i.ID) {
+			return
+		}
+		if i.DealID != "" && !s.canSee(w, r, "deal", i.DealID) {
+			return
+		}
 
 		if err := s.store.SaveInteraction(i); err != nil {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
@@ -1171,6 +1652,9 @@ func (s *Server) handleInteractions(w http.ResponseWriter, r *http.Request) {
 			if strings.TrimSpace(id) == "" {
 				continue
 			}
+			if !s.canSeeStoredDeal(w, r, "interaction", id) {
+				return
+			}
 			if err := s.store.DeleteInteraction(id); err != nil {
 				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 				return
@@ -1204,6 +1688,16 @@ func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 			"auto_summary":                   cfg.AutoSummary,
 			"has_openai_key":                 cfg.OpenAIKey != "",
 			"has_anthropic_key":              cfg.AnthropicKey != "",
+			"billing_horizon_days":           cfg.BillingHorizonDays,
+			"domain_renewal_lead_days":       cfg.DomainRenewalLeadDays,
+			"rdap_base_url":                  cfg.RDAPBaseURL,
+			"whois_server":                   cfg.WHOISServer,
+			"domain_refresh_days":            cfg.DomainRefreshDays,
+			"reporting_currency":             cfg.ReportingCurrency,
+			"ecb_rates_url":                  cfg.ECBRatesURL,
+			"follow_up_digest_hour":          cfg.FollowUpDigestHour,
+			"meeting_import_dir":             cfg.MeetingImportDir,
+			"maildir_path":                   cfg.MaildirPath,
 		})
 	case http.MethodPost:
 		var payload struct {
@@ -1222,6 +1716,16 @@ func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 			AutoSummary                 *bool               `json:"auto_summary"`
 			OpenAIKey                   string              `json:"openai_key"`
 			AnthropicKey                string              `json:"anthropic_key"`
+			BillingHorizonDays          *int                `json:"billing_horizon_days"`
+			DomainRenewalLeadDays       *int                `json:"domain_renewal_lead_days"`
+			RDAPBaseURL                 string              `json:"rdap_base_url"`
+			WHOISServer                 *string             `json:"whois_server"`
+			DomainRefreshDays           *int                `json:"domain_refresh_days"`
+			ReportingCurrency           string              `json:"reporting_currency"`
+			ECBRatesURL                 string              `json:"ecb_rates_url"`
+			FollowUpDigestHour          *int                `json:"follow_up_digest_hour"`
+			MeetingImportDir            *string             `json:"meeting_import_dir"`
+			MaildirPath                 *string             `json:"maildir_path"`
 		}
 		if err := readJSON(r, &payload); err != nil {
 			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
@@ -1273,6 +1777,38 @@ func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 		if payload.AnthropicKey != "" {
 			s.settings.AnthropicKey = payload.AnthropicKey
 		}
+		if payload.BillingHorizonDays != nil && *payload.BillingHorizonDays > 0 {
+			s.settings.BillingHorizonDays = *payload.BillingHorizonDays
+		}
+		if payload.DomainRenewalLeadDays != nil && *payload.DomainRenewalLeadDays > 0 {
+			s.settings.DomainRenewalLeadDays = *payload.DomainRenewalLeadDays
+		}
+		if payload.RDAPBaseURL != "" {
+			s.settings.RDAPBaseURL = payload.RDAPBaseURL
+		}
+		if payload.WHOISServer != nil {
+			s.settings.WHOISServer = strings.TrimSpace(*payload.WHOISServer)
+		}
+		if payload.DomainRefreshDays != nil && *payload.DomainRefreshDays >= 0 {
+			s.settings.DomainRefreshDays = *payload.DomainRefreshDays
+		}
+		currencyChanged := false
+		if c := fx.Code(payload.ReportingCurrency); c != "" && c != s.settings.ReportingCurrency {
+			s.settings.ReportingCurrency = c
+			currencyChanged = true
+		}
+		if payload.ECBRatesURL != "" {
+			s.settings.ECBRatesURL = payload.ECBRatesURL
+		}
+		if payload.FollowUpDigestHour != nil && *payload.FollowUpDigestHour >= -1 && *payload.FollowUpDigestHour <= 23 {
+			s.settings.FollowUpDigestHour = *payload.FollowUpDigestHour
+		}
+		if payload.MeetingImportDir != nil {
+			s.settings.MeetingImportDir = strings.TrimSpace(*payload.MeetingImportDir)
+		}
+		if payload.MaildirPath != nil {
+			s.settings.MaildirPath = strings.TrimSpace(*payload.MaildirPath)
+		}
 		cfg := s.settings
 		s.mu.Unlock()
 
@@ -1280,6 +1816,12 @@ func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
 			return
 		}
+		if currencyChanged {
+			if _, err := s.recomputeReportingAmounts(); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
 		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
 	default:
 		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
@@ -1298,6 +1840,42 @@ func readJSON(r *http.Request, dest any) error {
 	return decoder.Decode(dest)
 }
 
+// readUpload returns the file sent in a multipart "file" field, or the raw
+// request body for non-multipart requests. Other form values stay available
+// through r.FormValue.
+func readUpload(r *http.Request, maxBytes int64) ([]byte, string, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxBytes); err != nil {
+			return nil, "", err
+		}
+		f, hdr, err := r.FormFile("file")
+		if err != nil {
+			return nil, "", errors.New("file is required")
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+		if err != nil {
+			return nil, "", err
+		}
+		if int64(len(data)) > maxBytes {
+			return nil, "", errors.New("upload too large")
+		}
+		return data, hdr.Filename, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, "", errors.New("upload too large")
+	}
+	if len(data) == 0 {
+		return nil, "", errors.New("file is required")
+	}
+	return data, "", nil
+}
+
 func errorResponse(message string) map[string]string {
 	return map[string]string{"error": message}
 }
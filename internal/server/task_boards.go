@@ -0,0 +1,461 @@
+package server
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+var taskStatuses = []models.TaskStatus{models.TaskTodo, models.TaskInProgress, models.TaskBlocked, models.TaskDone}
+
+// minTaskGap is the smallest gap left between two neighbouring positions
+// before a column is renumbered.
+const minTaskGap = 1e-9
+
+type boardColumn struct {
+	models.TaskColumn
+	Tasks []models.Task `json:"tasks"`
+}
+
+// full reports whether the column is at its WIP limit, not counting the
+// task being moved.
+func (c boardColumn) full(taskID string) bool {
+	if c.WIPLimit <= 0 {
+		return false
+	}
+	n := 0
+	for _, t := range c.Tasks {
+		if t.ID != taskID {
+			n++
+		}
+	}
+	return n >= c.WIPLimit
+}
+
+// columnFor returns the index of the column a task sits in: its own column,
+// else the first one of its status, else the first one. exact is false in
+// the last case.
+func columnFor(columns []boardColumn, t models.Task) (i int, exact bool) {
+	if i := slices.IndexFunc(columns, func(c boardColumn) bool { return c.ID == t.ColumnID }); i >= 0 {
+		return i, true
+	}
+	if i := slices.IndexFunc(columns, func(c boardColumn) bool { return c.Category == t.Status }); i >= 0 {
+		return i, true
+	}
+	return 0, false
+}
+
+// taskBoard lays out a project's tasks in its columns, ordered by position.
+// Tasks that were never placed show in the first column of their status.
+func (s *Server) taskBoard(projectID string) ([]boardColumn, error) {
+	columns, err := s.store.LoadTaskColumns(projectID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.store.LoadTasks()
+	if err != nil {
+		return nil, err
+	}
+	board := make([]boardColumn, 0, len(columns))
+	for _, c := range columns {
+		board = append(board, boardColumn{TaskColumn: c, Tasks: make([]models.Task, 0)})
+	}
+	for _, t := range tasks {
+		if t.ProjectID != projectID {
+			continue
+		}
+		i, _ := columnFor(board, t)
+		board[i].Tasks = append(board[i].Tasks, t)
+	}
+	for _, c := range board {
+		slices.SortStableFunc(c.Tasks, func(a, b models.Task) int {
+			if n := cmp.Compare(a.Position, b.Position); n != 0 {
+				return n
+			}
+			if n := a.CreatedAt.Compare(b.CreatedAt); n != 0 {
+				return n
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+	}
+	return board, nil
+}
+
+// placeTask puts a task that is about to be saved on its project's board.
+// Tasks keep their column while it still matches their status; new tasks
+// and tasks whose status moved on go to the bottom of the first column of
+// their status. It writes a 409 and returns false when that column is at
+// its WIP limit.
+func (s *Server) placeTask(w http.ResponseWriter, t *models.Task, prev models.Task, existed bool) bool {
+	board, err := s.taskBoard(t.ProjectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	from := -1
+	if existed && prev.ProjectID == t.ProjectID {
+		t.ColumnID, t.Position = prev.ColumnID, prev.Position
+		from, _ = columnFor(board, prev)
+		if board[from].Category == t.Status {
+			return true
+		}
+	}
+	to, exact := columnFor(board, models.Task{Status: t.Status})
+	if !exact && from >= 0 {
+		// No column has the new status; leave the task where it is.
+		return true
+	}
+	if to == from {
+		return true
+	}
+	if board[to].full(t.ID) {
+		writeJSON(w, http.StatusConflict, errorResponse(board[to].Name+" is at its WIP limit of "+strconv.Itoa(board[to].WIPLimit)))
+		return false
+	}
+	t.ColumnID, t.Position = board[to].ID, 1
+	if n := len(board[to].Tasks); n > 0 {
+		t.Position = board[to].Tasks[n-1].Position + 1
+	}
+	return true
+}
+
+// isProjectLead reports whether user may manage a project: admins and
+// project managers always can, everyone else only as one of its leads.
+func (s *Server) isProjectLead(user models.User, projectID string) (bool, error) {
+	if user.Role == models.RoleAdmin || user.Role == models.RoleProjectManager {
+		return true, nil
+	}
+	role, err := s.projectRole(projectID, user.ID)
+	return role == models.ProjectLead, err
+}
+
+// handleTaskBoard serves GET /api/projects/{id}/board: the project's
+// columns with their tasks. It takes the same label filters as the task
+// list.
+func (s *Server) handleTaskBoard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	projectID := r.PathValue("id")
+	if !s.canSee(w, r, "project", projectID) {
+		return
+	}
+	board, err := s.taskBoard(projectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	attrs, ok := s.listFilter(w, r, "task")
+	if !ok {
+		return
+	}
+	for i, c := range board {
+		tasks := make([]models.Task, 0, len(c.Tasks))
+		for _, t := range c.Tasks {
+			t.LabelIDs = attrs.labelIDs(t.ID)
+			if attrs.match(t.ID) {
+				tasks = append(tasks, t)
+			}
+		}
+		board[i].Tasks = tasks
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"projectId": projectID, "columns": board})
+}
+
+// handleTaskColumns serves /api/projects/{id}/board/columns. Anyone who can
+// see the project can list its columns; admins, project managers and the
+// project's leads can change them.
+func (s *Server) handleTaskColumns(w http.ResponseWriter, r *http.Request) {
+	projectID := r.PathValue("id")
+	if !s.canSee(w, r, "project", projectID) {
+		return
+	}
+	if r.Method != http.MethodGet && !s.requireProjectLead(w, r, projectID, "the board") {
+		return
+	}
+	board, err := s.taskBoard(projectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		columns := make([]models.TaskColumn, 0, len(board))
+		for _, c := range board {
+			columns = append(columns, c.TaskColumn)
+		}
+		writeJSON(w, http.StatusOK, columns)
+	case http.MethodPost:
+		var c models.TaskColumn
+		if err := readJSON(r, &c); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		c.ProjectID = projectID
+		c.Name = strings.TrimSpace(c.Name)
+		if c.Name == "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse("name is required"))
+			return
+		}
+		c.Color = strings.TrimSpace(c.Color)
+		if c.Color == "" {
+			c.Color = defaultLabelColor
+		}
+		if !labelColorPattern.MatchString(c.Color) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("color must be #rrggbb"))
+			return
+		}
+		c.Color = strings.ToLower(c.Color)
+		c.Category = models.TaskStatus(strings.ToLower(strings.TrimSpace(string(c.Category))))
+		if c.Category == "" {
+			c.Category = models.TaskTodo
+		}
+		if !slices.Contains(taskStatuses, c.Category) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("category must be todo, in_progress, blocked or done"))
+			return
+		}
+		if c.WIPLimit < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("wipLimit cannot be negative"))
+			return
+		}
+
+		now := time.Now()
+		c.CreatedAt, c.UpdatedAt = now, now
+		if c.ID == "" {
+			c.ID = newID()
+			c.Position = len(board) + 1
+		} else {
+			i := slices.IndexFunc(board, func(b boardColumn) bool { return b.ID == c.ID })
+			if i < 0 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("column not found"))
+				return
+			}
+			c.CreatedAt, c.Position = board[i].CreatedAt, board[i].Position
+		}
+		// The column's tasks follow its new category.
+		if err := s.store.SaveTaskColumn(c); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		writeJSON(w, http.StatusOK, c)
+	case http.MethodDelete:
+		ids, err := deleteIDsFromRequest(r)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+			return
+		}
+		remaining := len(board)
+		for _, id := range ids {
+			i := slices.IndexFunc(board, func(b boardColumn) bool { return b.ID == id })
+			if i < 0 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("column not found"))
+				return
+			}
+			if n := len(board[i].Tasks); n > 0 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("move the "+strconv.Itoa(n)+" task(s) out of "+board[i].Name+" first"))
+				return
+			}
+			remaining--
+		}
+		if remaining < 1 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("a board needs at least one column"))
+			return
+		}
+		for _, id := range ids {
+			if err := s.store.DeleteTaskColumn(id); err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "deleted": ids})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+	}
+}
+
+// requireProjectLead writes a 403 and returns false unless the user may
+// manage the project; what names the part of it they tried to change.
+func (s *Server) requireProjectLead(w http.ResponseWriter, r *http.Request, projectID, what string) bool {
+	ok, err := s.isProjectLead(mustAuth(r).User, projectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return false
+	}
+	if !ok {
+		writeJSON(w, http.StatusForbidden, errorResponse("only project leads can change "+what))
+		return false
+	}
+	return true
+}
+
+// handleTaskColumnsReorder serves POST /api/projects/{id}/board/columns/reorder
+// with every column of the board in its new order.
+func (s *Server) handleTaskColumnsReorder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	projectID := r.PathValue("id")
+	if !s.canSee(w, r, "project", projectID) || !s.requireProjectLead(w, r, projectID, "the board") {
+		return
+	}
+	var payload struct {
+		IDs []string `json:"ids"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	columns, err := s.store.LoadTaskColumns(projectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if len(payload.IDs) != len(columns) {
+		writeJSON(w, http.StatusBadRequest, errorResponse("ids must list every column of the board"))
+		return
+	}
+	for _, c := range columns {
+		if !slices.Contains(payload.IDs, c.ID) {
+			writeJSON(w, http.StatusBadRequest, errorResponse("ids must list every column of the board"))
+			return
+		}
+	}
+	if err := s.store.ReorderTaskColumns(projectID, payload.IDs); err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	columns, err = s.store.LoadTaskColumns(projectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, columns)
+}
+
+// handleTaskMove serves POST /api/tasks/move. The task goes into columnId
+// right after afterTaskId or right before beforeTaskId, at the bottom when
+// neither is given, and takes the column's category as its status. Its
+// position is put halfway between its new neighbours so no other task is
+// written, unless repeated moves have left no room between them.
+func (s *Server) handleTaskMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse("method not allowed"))
+		return
+	}
+	var payload struct {
+		TaskID       string `json:"taskId"`
+		ColumnID     string `json:"columnId"`
+		AfterTaskID  string `json:"afterTaskId"`
+		BeforeTaskID string `json:"beforeTaskId"`
+	}
+	if err := readJSON(r, &payload); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse(err.Error()))
+		return
+	}
+	if !s.canSee(w, r, "task", payload.TaskID) {
+		return
+	}
+	t, _, err := s.store.FindTask(payload.TaskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if !viewer(r).SeesAll() {
+		if ok, err := s.canWorkOn(t.ProjectID, mustAuth(r).User.ID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		} else if !ok {
+			writeJSON(w, http.StatusForbidden, errorResponse("only the project's leads and contributors can edit its tasks"))
+			return
+		}
+	}
+	board, err := s.taskBoard(t.ProjectID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	to := slices.IndexFunc(board, func(c boardColumn) bool { return c.ID == payload.ColumnID })
+	if to < 0 {
+		writeJSON(w, http.StatusBadRequest, errorResponse("columnId must be a column of the task's project"))
+		return
+	}
+	if from, _ := columnFor(board, t); from != to && board[to].full(t.ID) {
+		writeJSON(w, http.StatusConflict, errorResponse(board[to].Name+" is at its WIP limit of "+strconv.Itoa(board[to].WIPLimit)))
+		return
+	}
+
+	others := slices.DeleteFunc(slices.Clone(board[to].Tasks), func(o models.Task) bool { return o.ID == t.ID })
+	index := func(id string) int {
+		return slices.IndexFunc(others, func(o models.Task) bool { return o.ID == id })
+	}
+	at := len(others)
+	switch {
+	case payload.AfterTaskID != "":
+		if at = index(payload.AfterTaskID) + 1; at == 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("afterTaskId is not in the column"))
+			return
+		}
+		if payload.BeforeTaskID != "" && index(payload.BeforeTaskID) != at {
+			writeJSON(w, http.StatusBadRequest, errorResponse("afterTaskId and beforeTaskId must be neighbours"))
+			return
+		}
+	case payload.BeforeTaskID != "":
+		if at = index(payload.BeforeTaskID); at < 0 {
+			writeJSON(w, http.StatusBadRequest, errorResponse("beforeTaskId is not in the column"))
+			return
+		}
+	}
+
+	var position float64
+	var renumbered []string
+	switch {
+	case len(others) == 0:
+		position = 1
+	case at == 0:
+		position = others[0].Position - 1
+	case at == len(others):
+		position = others[at-1].Position + 1
+	default:
+		prev, next := others[at-1].Position, others[at].Position
+		position = prev + (next-prev)/2
+		if next-prev < minTaskGap {
+			renumbered = make([]string, 0, len(others)+1)
+			for _, o := range others {
+				renumbered = append(renumbered, o.ID)
+			}
+			renumbered = slices.Insert(renumbered, at, t.ID)
+			position = float64(at + 1)
+		}
+	}
+	// The move goes first so the WIP limit is checked before the column is
+	// renumbered around the task.
+	if err := s.store.MoveTask(t.ID, board[to].ID, board[to].Category, position); errors.Is(err, db.ErrColumnFull) {
+		writeJSON(w, http.StatusConflict, errorResponse(board[to].Name+" is at its WIP limit of "+strconv.Itoa(board[to].WIPLimit)))
+		return
+	} else if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	if renumbered != nil {
+		if err := s.store.RenumberColumnTasks(board[to].ID, renumbered); err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+	}
+	t, _, err = s.store.FindTask(t.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"wemadeit/internal/db"
+	"wemadeit/internal/models"
+)
+
+// Timeline event types.
+const (
+	timelineInteraction     = "interaction"
+	timelineStageChange     = "stage_change"
+	timelineDealStatus      = "deal_status"
+	timelineQuotationStatus = "quotation_status"
+	timelinePayment         = "payment"
+	timelineProjectStatus   = "project_status"
+	timelineNote            = "note"
+)
+
+var timelineTypes = []string{
+	timelineInteraction,
+	timelineStageChange,
+	timelineDealStatus,
+	timelineQuotationStatus,
+	timelinePayment,
+	timelineProjectStatus,
+	timelineNote,
+}
+
+// timelineEntityTypes is the record type each event type reports on. Notes
+// are checked against the record they are attached to.
+var timelineEntityTypes = map[string]string{
+	timelineInteraction:     "interaction",
+	timelineStageChange:     "deal",
+	timelineDealStatus:      "deal",
+	timelineQuotationStatus: "quotation",
+	timelinePayment:         "payment",
+	timelineProjectStatus:   "project",
+}
+
+// timelineItem is one event in a record's activity feed. EntityType and
+// EntityID name the record the event happened on; From and To carry the old
+// and new value of status and stage changes.
+type timelineItem struct {
+	Type       string    `json:"type"`
+	At         time.Time `json:"at"`
+	EntityType string    `json:"entityType"`
+	EntityID   string    `json:"entityId"`
+	DealID     string    `json:"dealId,omitempty"`
+	ContactID  string    `json:"contactId,omitempty"`
+	Title      string    `json:"title"`
+	Detail     string    `json:"detail,omitempty"`
+	From       string    `json:"from,omitempty"`
+	To         string    `json:"to,omitempty"`
+	UserID     string    `json:"userId,omitempty"`
+}
+
+type timelinePage struct {
+	Items   []timelineItem `json:"items"`
+	Total   int            `json:"total"`
+	HasMore bool           `json:"hasMore"`
+	// Contacts lists who is involved in a deal and in what role; it is only
+	// set on deal timelines.
+	Contacts []models.DealContact `json:"contacts,omitempty"`
+}
+
+// timelineScope is the set of records whose events make up a timeline.
+type timelineScope struct {
+	entityType string
+	id         string
+	contacts   map[string]bool
+	deals      map[string]bool
+}
+
+func (sc timelineScope) hasInteraction(it models.Interaction) bool {
+	switch sc.entityType {
+	case "organization":
+		return it.OrganizationID == sc.id || sc.contacts[it.ContactID] || sc.deals[it.DealID]
+	case "contact":
+		return it.ContactID == sc.id
+	default:
+		return it.DealID == sc.id
+	}
+}
+
+// handleTimeline serves GET /api/{organizations,contacts,deals}/{id}/timeline:
+// interactions, deal stage and status changes, quotation status changes,
+// payments, project status changes and notes, newest first. ?types= filters
+// by event type; ?limit= and ?offset= page through the feed.
+func (s *Server) handleTimeline(entityType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		missing, err := s.store.MissingEntities(entityType, []string{id})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		if len(missing) > 0 {
+			writeJSON(w, http.StatusNotFound, errorResponse(entityType+" not found"))
+			return
+		}
+		if entityType == "deal" && !s.canSee(w, r, "deal", id) {
+			return
+		}
+
+		q := r.URL.Query()
+		limit, offset := 50, 0
+		if v := strings.TrimSpace(q.Get("limit")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 500 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("limit must be between 1 and 500"))
+				return
+			}
+			limit = n
+		}
+		if v := strings.TrimSpace(q.Get("offset")); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSON(w, http.StatusBadRequest, errorResponse("offset must be a non-negative number"))
+				return
+			}
+			offset = n
+		}
+		types := timelineTypes
+		if v := strings.TrimSpace(q.Get("types")); v != "" {
+			types = nil
+			for _, t := range strings.Split(v, ",") {
+				t = strings.TrimSpace(t)
+				if !slices.Contains(timelineTypes, t) {
+					writeJSON(w, http.StatusBadRequest, errorResponse("types must be among "+strings.Join(timelineTypes, ", ")))
+					return
+				}
+				types = append(types, t)
+			}
+		}
+
+		items, err := s.timeline(viewer(r), entityType, id, types)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+			return
+		}
+		page := timelinePage{Items: make([]timelineItem, 0), Total: len(items)}
+		if offset < len(items) {
+			end := min(offset+limit, len(items))
+			page.Items = items[offset:end]
+			page.HasMore = end < len(items)
+		}
+		if entityType == "deal" {
+			dealContacts, err := s.store.LoadDealContacts()
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, errorResponse(err.Error()))
+				return
+			}
+			page.Contacts = dealContacts[id]
+		}
+		writeJSON(w, http.StatusOK, page)
+	}
+}
+
+// timeline collects the events of the given types for one organization,
+// contact or deal, newest first. An organization's feed covers its contacts
+// and deals; a contact's covers the deals it is involved in. Deals v may not
+// see are left out, with everything that happened on them, and so are
+// events on records v's role may not read.
+func (s *Server) timeline(v db.Viewer, entityType, id string, types []string) ([]timelineItem, error) {
+	sc := timelineScope{entityType: entityType, id: id, contacts: map[string]bool{}, deals: map[string]bool{}}
+	deals, err := s.store.LoadDealsFor(v)
+	if err != nil {
+		return nil, err
+	}
+	dealContacts, err := s.store.LoadDealContacts()
+	if err != nil {
+		return nil, err
+	}
+	dealTitles := make(map[string]string, len(deals))
+	for _, d := range deals {
+		dealTitles[d.ID] = d.Title
+		switch entityType {
+		case "organization":
+			if d.OrganizationID == id {
+				sc.deals[d.ID] = true
+			}
+		case "contact":
+			d.Contacts = dealContacts[d.ID]
+			if dealInvolves(d, id) {
+				sc.deals[d.ID] = true
+			}
+		case "deal":
+			if d.ID == id {
+				sc.deals[d.ID] = true
+			}
+		}
+	}
+	if entityType == "organization" {
+		contacts, err := s.store.LoadContacts()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range contacts {
+			if c.OrganizationID == id {
+				sc.contacts[c.ID] = true
+			}
+		}
+	}
+
+	items := make([]timelineItem, 0)
+	want := func(t string) bool {
+		return slices.Contains(types, t) && (t == timelineNote || canRead(v.Role, timelineEntityTypes[t]))
+	}
+
+	if want(timelineInteraction) {
+		interactions, err := s.store.LoadInteractionsFor(v)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range interactions {
+			if !sc.hasInteraction(it) {
+				continue
+			}
+			items = append(items, timelineItem{
+				Type:       timelineInteraction,
+				At:         it.OccurredAt,
+				EntityType: "interaction",
+				EntityID:   it.ID,
+				DealID:     it.DealID,
+				ContactID:  it.ContactID,
+				Title:      it.Subject,
+				Detail:     string(it.InteractionType),
+				UserID:     it.UserID,
+			})
+		}
+	}
+
+	if want(timelineStageChange) || want(timelineDealStatus) {
+		stages, err := s.store.LoadPipelineStages()
+		if err != nil {
+			return nil, err
+		}
+		stageNames := make(map[string]string, len(stages))
+		for _, st := range stages {
+			stageNames[st.ID] = st.Name
+		}
+		for dealID := range sc.deals {
+			history, err := s.store.LoadHistory("deal", dealID)
+			if err != nil {
+				return nil, err
+			}
+			for _, h := range history {
+				item := timelineItem{
+					At:         h.CreatedAt,
+					EntityType: "deal",
+					EntityID:   dealID,
+					DealID:     dealID,
+					Title:      dealTitles[dealID],
+					UserID:     h.UserID,
+				}
+				switch h.Field {
+				case "pipelineStageId":
+					item.Type, item.From, item.To = timelineStageChange, stageNames[h.OldValue], stageNames[h.NewValue]
+				case "status":
+					item.Type, item.From, item.To = timelineDealStatus, h.OldValue, h.NewValue
+				default:
+					continue
+				}
+				if want(item.Type) {
+					items = append(items, item)
+				}
+			}
+		}
+	}
+
+	// Quotations and projects of the scope's deals carry status changes
+	// and can have notes of their own.
+	noteTargets := [][2]string{{entityType, id}}
+	for contactID := range sc.contacts {
+		noteTargets = append(noteTargets, [2]string{"contact", contactID})
+	}
+	for dealID := range sc.deals {
+		if entityType != "deal" {
+			noteTargets = append(noteTargets, [2]string{"deal", dealID})
+		}
+	}
+
+	quotations, err := s.store.LoadQuotationsFor(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, qt := range quotations {
+		if !sc.deals[qt.DealID] {
+			continue
+		}
+		noteTargets = append(noteTargets, [2]string{"quotation", qt.ID})
+		if !want(timelineQuotationStatus) {
+			continue
+		}
+		history, err := s.store.LoadHistory("quotation", qt.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range history {
+			if h.Field != "status" {
+				continue
+			}
+			items = append(items, timelineItem{
+				Type:       timelineQuotationStatus,
+				At:         h.CreatedAt,
+				EntityType: "quotation",
+				EntityID:   qt.ID,
+				DealID:     qt.DealID,
+				Title:      strings.TrimSpace(qt.Number + " " + qt.Title),
+				From:       h.OldValue,
+				To:         h.NewValue,
+				UserID:     h.UserID,
+			})
+		}
+	}
+
+	projects, err := s.store.LoadProjectsFor(v)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if !sc.deals[p.DealID] {
+			continue
+		}
+		noteTargets = append(noteTargets, [2]string{"project", p.ID})
+		if !want(timelineProjectStatus) {
+			continue
+		}
+		history, err := s.store.LoadHistory("project", p.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range history {
+			if h.Field != "status" {
+				continue
+			}
+			items = append(items, timelineItem{
+				Type:       timelineProjectStatus,
+				At:         h.CreatedAt,
+				EntityType: "project",
+				EntityID:   p.ID,
+				DealID:     p.DealID,
+				Title:      p.Name,
+				From:       h.OldValue,
+				To:         h.NewValue,
+				UserID:     h.UserID,
+			})
+		}
+	}
+
+	if want(timelinePayment) {
+		payments, err := s.store.LoadPaymentsFor(v)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		for _, p := range payments {
+			if !sc.deals[p.DealID] || p.Status == models.PaymentVoid {
+				continue
+			}
+			// Paid payments show when they were paid, open ones once they
+			// fall due; future instalments are not history yet.
+			at := p.CreatedAt
+			if p.PaidAt != nil {
+				at = *p.PaidAt
+			} else if p.DueAt != nil {
+				at = *p.DueAt
+			}
+			if at.After(now) {
+				continue
+			}
+			items = append(items, timelineItem{
+				Type:       timelinePayment,
+				At:         at,
+				EntityType: "payment",
+				EntityID:   p.ID,
+				DealID:     p.DealID,
+				Title:      p.Title,
+				Detail:     strconv.FormatFloat(p.Amount, 'f', 2, 64) + " " + p.Currency,
+				To:         string(p.Status),
+			})
+		}
+	}
+
+	if want(timelineNote) {
+		for _, target := range noteTargets {
+			if !canRead(v.Role, target[0]) {
+				continue
+			}
+			notes, err := s.store.LoadNotes(target[0], target[1])
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range slices.Backward(notes) {
+				item := timelineItem{
+					Type:       timelineNote,
+					At:         n.CreatedAt,
+					EntityType: n.EntityType,
+					EntityID:   n.EntityID,
+					Title:      "Note",
+					Detail:     n.Body,
+					UserID:     n.AuthorUserID,
+				}
+				if n.EntityType == "deal" {
+					item.DealID = n.EntityID
+				}
+				items = append(items, item)
+			}
+		}
+	}
+
+	// Each source above is collected oldest first; reversing before the
+	// stable sort keeps events recorded in the same second newest first.
+	slices.Reverse(items)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].At.After(items[j].At) })
+	return items, nil
+}